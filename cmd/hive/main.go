@@ -1,12 +1,17 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"slices"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/charmbracelet/bubbles/list"
@@ -17,29 +22,44 @@ import (
 	"github.com/tuanbt/hive/internal/config"
 	"github.com/tuanbt/hive/internal/git"
 	"github.com/tuanbt/hive/internal/logger"
+	"github.com/tuanbt/hive/internal/logtail"
 	"github.com/tuanbt/hive/internal/orchestrator"
 	"github.com/tuanbt/hive/internal/task"
 )
 
 var version = "v0.2.1"
 
+// shutdownGrace is how long the TUI waits for the embedded orchestrator
+// beyond its shutdown timeout, covering the kill and final cleanup.
+const shutdownGrace = 5 * time.Second
+
 func main() {
 	configPath := flag.String("config", "config.json", "Path to config file")
 	showVersion := flag.Bool("version", false, "Show version and exit")
 	showHeadless := flag.Bool("headless", false, "Run in headless mode (orchestrator only)")
 	disableGit := flag.Bool("no-git", false, "Disable Git integration")
+	readOnly := flag.Bool("readonly", false, "Run the TUI as a read-only monitor")
+	force := flag.Bool("force", false, "Start even if another orchestrator holds the lock file")
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: %s [flags] <command> [args]\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "\nFlags:\n")
 		flag.PrintDefaults()
 		fmt.Fprintf(os.Stderr, "\nCommands:\n")
 		fmt.Fprintf(os.Stderr, "  list           List all tasks\n")
-		fmt.Fprintf(os.Stderr, "  add            Add a new task (usage: add -title \"...\" -role \"...\")\n")
+		fmt.Fprintf(os.Stderr, "  show           Show task details (usage: show <id>)\n")
+		fmt.Fprintf(os.Stderr, "  search         Find tasks by title or description (usage: search <query>)\n")
+		fmt.Fprintf(os.Stderr, "  add            Add a new task (usage: add -title \"...\" [-desc-file path] -role \"...\" [-id id])\n")
 		fmt.Fprintf(os.Stderr, "  done           Mark a task as completed (usage: done <id>)\n")
 		fmt.Fprintf(os.Stderr, "  delete         Delete a task (usage: delete <id>)\n")
-		fmt.Fprintf(os.Stderr, "  retry          Retry a failed task (usage: retry <id>)\n")
-		fmt.Fprintf(os.Stderr, "  logs           Show logs for a task (usage: logs <id>)\n")
+		fmt.Fprintf(os.Stderr, "  clone          Copy a task as a new pending task (usage: clone <id>)\n")
+		fmt.Fprintf(os.Stderr, "  retry          Retry a failed task (usage: retry <id> [-append \"...\"])\n")
+		fmt.Fprintf(os.Stderr, "  reset          Move a stuck in-progress task back to pending (usage: reset <id>)\n")
+		fmt.Fprintf(os.Stderr, "  logs           Show logs for a task, -f to follow them (usage: logs [-f] <id>)\n")
 		fmt.Fprintf(os.Stderr, "  cleanup        Delete all completed tasks\n")
+		fmt.Fprintf(os.Stderr, "  export         Write all tasks as JSON (usage: export [file])\n")
+		fmt.Fprintf(os.Stderr, "  import         Load tasks from an export (usage: import [-replace] <file>)\n")
+		fmt.Fprintf(os.Stderr, "  doctor         Check the tasks file for duplicate IDs and invalid statuses (usage: doctor [-fix])\n")
+		fmt.Fprintf(os.Stderr, "  validate-config Check a config file and print the result (usage: validate-config [-config path] [-o json])\n")
 		fmt.Fprintf(os.Stderr, "  tui            Run the Terminal UI (default)\n")
 	}
 
@@ -50,25 +70,27 @@ func main() {
 		os.Exit(0)
 	}
 
+	// Validation must not touch the tasks file or fail on the global load
+	if flag.Arg(0) == "validate-config" {
+		handleValidateConfig(*configPath, flag.Args()[1:])
+		return
+	}
+
 	cfg, err := config.Load(*configPath)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
 		os.Exit(1)
 	}
+	for _, warning := range cfg.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
 
 	// Override Git config if flag is set
 	if *disableGit {
 		cfg.GitIntegration.Enabled = false
 	}
 
-	// Resolve paths
-	pwd, _ := os.Getwd()
-	if !filepath.IsAbs(cfg.TasksFile) {
-		cfg.TasksFile = filepath.Join(pwd, cfg.TasksFile)
-	}
-	if !filepath.IsAbs(cfg.LogDirectory) {
-		cfg.LogDirectory = filepath.Join(pwd, cfg.LogDirectory)
-	}
+	resolvePaths(cfg)
 
 	args := flag.Args()
 	cmd := "tui"
@@ -81,7 +103,23 @@ func main() {
 		cmd = "headless"
 	}
 
-	tm := task.NewManager(cfg.TasksFile)
+	// Only the commands that run the orchestrator probe the tasks directory
+	// for writes; the rest fail on their own save, if they make one
+	if cmd == "headless" || (cmd == "tui" && !*readOnly) {
+		if err := cfg.CheckWritable(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	tm := cfg.NewTaskManager()
+	// Doctor reads the file as it is: migrating first would rewrite it, and
+	// the rewrite fails on the duplicate IDs doctor is there to repair
+	if cmd == "doctor" {
+		handleDoctor(tm, args[1:])
+		return
+	}
+
 	if err := tm.EnsureFile(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error initializing tasks file: %v\n", err)
 		os.Exit(1)
@@ -89,42 +127,163 @@ func main() {
 
 	switch cmd {
 	case "tui":
-		runTUI(cfg, tm)
+		runTUI(cfg, tm, *readOnly, *force)
 	case "headless":
 		runHeadless(cfg, tm)
 	case "list":
 		handleList(tm)
+	case "show":
+		handleShow(tm, args[1:])
+	case "search":
+		handleSearch(tm, args[1:])
 	case "add":
-		handleAdd(tm, args[1:])
+		handleAdd(cfg, tm, args[1:])
 	case "done":
 		handleStatusChange(tm, args[1:], task.StatusCompleted)
 	case "rm", "delete":
 		handleDelete(tm, args[1:])
+	case "clone":
+		handleClone(tm, args[1:])
 	case "retry":
 		handleRetry(tm, args[1:])
+	case "reset":
+		handleReset(tm, args[1:])
 	case "logs":
 		handleLogs(cfg.LogDirectory, args[1:])
 	case "cleanup":
 		handleCleanup(tm)
+	case "export":
+		handleExport(tm, args[1:])
+	case "import":
+		handleImport(tm, args[1:])
 	default:
 		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", cmd)
 		os.Exit(1)
 	}
 }
 
+// resolvePaths makes the tasks file, log directory and audit file paths
+// absolute, relative to the current directory.
+func resolvePaths(cfg *config.Config) {
+	pwd, _ := os.Getwd()
+	if !filepath.IsAbs(cfg.TasksFile) {
+		cfg.TasksFile = filepath.Join(pwd, cfg.TasksFile)
+	}
+	if !filepath.IsAbs(cfg.LogDirectory) {
+		cfg.LogDirectory = filepath.Join(pwd, cfg.LogDirectory)
+	}
+	if cfg.AuditFile != "" && !filepath.IsAbs(cfg.AuditFile) {
+		cfg.AuditFile = filepath.Join(pwd, cfg.AuditFile)
+	}
+}
+
+func handleValidateConfig(defaultPath string, args []string) {
+	fs := flag.NewFlagSet("validate-config", flag.ExitOnError)
+	path := fs.String("config", defaultPath, "Path to config file")
+	output := fs.String("o", "text", "Output format for the resolved config (text or json)")
+	fs.Parse(args)
+
+	if *output != "text" && *output != "json" {
+		fmt.Fprintf(os.Stderr, "Error: unknown output format %q (must be text or json)\n", *output)
+		os.Exit(1)
+	}
+
+	// Load falls back to the defaults for a missing file, which would hide a typo in the path
+	if _, err := os.Stat(*path); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	// Load ignores keys it does not know, so a misspelled setting would
+	// silently fall back to its default
+	data, err := os.ReadFile(*path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(config.DefaultConfig()); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to parse config file: %v\n", err)
+		os.Exit(1)
+	}
+	cfg, err := config.Load(*path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	for _, warning := range cfg.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+	resolvePaths(cfg)
+
+	data, err = json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error encoding config: %v\n", err)
+		os.Exit(1)
+	}
+	if *output == "json" {
+		fmt.Println(string(data))
+		return
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		fmt.Fprintf(os.Stderr, "Error encoding config: %v\n", err)
+		os.Exit(1)
+	}
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	fmt.Printf("%s: OK\n\n", *path)
+	for _, key := range keys {
+		var compact bytes.Buffer
+		json.Compact(&compact, fields[key])
+		fmt.Printf("%-32s %s\n", key, compact.String())
+	}
+}
+
 func handleLogs(logDir string, args []string) {
-	if len(args) < 1 {
-		fmt.Fprintf(os.Stderr, "Usage: logs <id>\n")
+	fs := flag.NewFlagSet("logs", flag.ExitOnError)
+	follow := fs.Bool("f", false, "Keep printing new log lines until interrupted")
+	fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		fmt.Fprintf(os.Stderr, "Usage: logs [-f] <id>\n")
 		os.Exit(1)
 	}
-	id := args[0]
+	id := fs.Arg(0)
 	path := filepath.Join(logDir, fmt.Sprintf("%s.log", id))
-	content, err := os.ReadFile(path)
-	if err != nil {
+
+	if !*follow {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading logs: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(content))
+		return
+	}
+
+	// A task that hasn't started yet has no log; wait for it to appear
+	content, offset, err := logtail.ReadTail(path, 0)
+	if err != nil && !os.IsNotExist(err) {
 		fmt.Fprintf(os.Stderr, "Error reading logs: %v\n", err)
 		os.Exit(1)
 	}
-	fmt.Println(string(content))
+	if os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "Waiting for %s...\n", path)
+	}
+	fmt.Print(content)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := logtail.Follow(ctx, path, offset, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Error following logs: %v\n", err)
+		os.Exit(1)
+	}
 }
 
 func handleCleanup(tm *task.Manager) {
@@ -147,6 +306,90 @@ func handleCleanup(tm *task.Manager) {
 	fmt.Printf("Cleaned up %d completed tasks.\n", count)
 }
 
+func handleExport(tm *task.Manager, args []string) {
+	out := os.Stdout
+	if len(args) > 0 {
+		f, err := os.Create(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating export file: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	if err := tm.Export(out); err != nil {
+		fmt.Fprintf(os.Stderr, "Error exporting tasks: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func handleImport(tm *task.Manager, args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	replace := fs.Bool("replace", false, "Replace all existing tasks instead of merging")
+	fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		fmt.Fprintf(os.Stderr, "Usage: import [-replace] <file>\n")
+		os.Exit(1)
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening import file: %v\n", err)
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	mode := task.ImportMerge
+	if *replace {
+		mode = task.ImportReplace
+	}
+	added, err := tm.Import(f, mode)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error importing tasks: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Imported %d tasks.\n", added)
+}
+
+func handleDoctor(tm *task.Manager, args []string) {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	fix := fs.Bool("fix", false, "Give tasks with duplicate IDs fresh IDs")
+	fs.Parse(args)
+
+	problems, err := tm.CheckIntegrity()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error checking tasks: %v\n", err)
+		os.Exit(1)
+	}
+	if len(problems) == 0 {
+		fmt.Println("No problems found.")
+		return
+	}
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+
+	if !*fix {
+		os.Exit(1)
+	}
+	renamed, err := tm.RepairDuplicateIDs()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error repairing tasks: %v\n", err)
+		os.Exit(1)
+	}
+	for newID, oldID := range renamed {
+		fmt.Printf("Renamed duplicate of %s to %s\n", oldID, newID)
+	}
+
+	// Invalid statuses need a hand edit, so report whatever is left
+	if problems, err := tm.CheckIntegrity(); err == nil && len(problems) > 0 {
+		fmt.Fprintf(os.Stderr, "%d problems need fixing by hand.\n", len(problems))
+		os.Exit(1)
+	}
+}
+
 func handleList(tm *task.Manager) {
 	tasks, err := tm.LoadAll()
 	if err != nil {
@@ -158,7 +401,31 @@ func handleList(tm *task.Manager) {
 		fmt.Println("No tasks found.")
 		return
 	}
+	printTaskTable(tasks)
+}
 
+func handleSearch(tm *task.Manager, args []string) {
+	query := strings.Join(args, " ")
+	if strings.TrimSpace(query) == "" {
+		fmt.Fprintf(os.Stderr, "Usage: search <query>\n")
+		os.Exit(1)
+	}
+
+	tasks, err := tm.Search(query)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error searching tasks: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(tasks) == 0 {
+		fmt.Printf("No tasks match %q.\n", query)
+		return
+	}
+	printTaskTable(tasks)
+}
+
+// printTaskTable prints one row per task with its ID, title, role and status.
+func printTaskTable(tasks []task.Task) {
 	fmt.Printf("%-20s %-30s %-15s %-10s\n", "ID", "TITLE", "ROLE", "STATUS")
 	fmt.Println(strings.Repeat("-", 80))
 	for _, t := range tasks {
@@ -166,11 +433,62 @@ func handleList(tm *task.Manager) {
 	}
 }
 
-func handleAdd(tm *task.Manager, args []string) {
+func handleShow(tm *task.Manager, args []string) {
+	if len(args) < 1 {
+		fmt.Fprintf(os.Stderr, "Usage: show <id>\n")
+		os.Exit(1)
+	}
+	t, err := tm.GetByID(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("ID:          %s\n", t.ID)
+	fmt.Printf("Title:       %s\n", t.Title)
+	fmt.Printf("Role:        %s\n", t.Role)
+	if t.ParentID != "" {
+		fmt.Printf("Parent:      %s\n", t.ParentID)
+	}
+	fmt.Printf("Status:      %s\n", t.Outcome())
+	fmt.Printf("Priority:    %d\n", t.Priority)
+	fmt.Printf("Retries:     %d\n", t.RetryCount)
+	if len(t.AgentCmd) > 0 {
+		fmt.Printf("Agent cmd:   %s\n", strings.Join(t.AgentCmd, " "))
+	}
+	if t.FailReason != "" {
+		fmt.Printf("Fail reason: %s\n", t.FailReason)
+	}
+	if t.LastError != "" {
+		fmt.Printf("Last error:  %s\n", t.LastError)
+	}
+	if t.ExtraInstructions != "" {
+		fmt.Printf("Extra:       %s\n", t.ExtraInstructions)
+	}
+	if t.Description != "" {
+		fmt.Printf("\n%s\n", t.Description)
+	}
+
+	children, err := tm.Children(t.ID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading subtasks: %v\n", err)
+		os.Exit(1)
+	}
+	if len(children) > 0 {
+		fmt.Printf("\nSubtasks:\n")
+		for _, c := range children {
+			fmt.Printf("  └─ %-12s %s  %s\n", c.Status, c.ID, c.Title)
+		}
+	}
+}
+
+func handleAdd(cfg *config.Config, tm *task.Manager, args []string) {
 	fs := flag.NewFlagSet("add", flag.ExitOnError)
 	title := fs.String("title", "", "Task title")
 	desc := fs.String("desc", "", "Task description")
+	descFile := fs.String("desc-file", "", "Read the task description from a file")
 	role := fs.String("role", "", "Task role (ba, backend, frontend, etc)")
+	taskID := fs.String("id", "", "Task ID; re-running with the same ID updates the task instead of failing")
 	fs.Parse(args)
 
 	if *title == "" {
@@ -179,19 +497,45 @@ func handleAdd(tm *task.Manager, args []string) {
 		os.Exit(1)
 	}
 
-	// Simple ID generation
-	id := fmt.Sprintf("task-%d", time.Now().Unix())
+	if *descFile != "" {
+		if *desc != "" {
+			fmt.Fprintf(os.Stderr, "Error: use either -desc or -desc-file, not both\n")
+			os.Exit(1)
+		}
+		content, err := task.ReadDescriptionFile(*descFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		*desc = content
+	}
 
+	id := *taskID
 	t := task.NewTask(id, *title, *desc)
 	if *role != "" {
 		t.Role = *role
 	}
+	t.ApplyRolePriority(cfg.RolePriorities)
+
+	if *taskID != "" {
+		created, err := tm.Upsert(t)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error adding task: %v\n", err)
+			os.Exit(1)
+		}
+		if !created {
+			fmt.Printf("Task updated: %s\n", id)
+			return
+		}
+		fmt.Printf("Task added: %s\n", id)
+		return
+	}
 
-	if err := tm.AddTask(t); err != nil {
+	if err := tm.Create(t); err != nil {
 		fmt.Fprintf(os.Stderr, "Error adding task: %v\n", err)
 		os.Exit(1)
 	}
-	fmt.Printf("Task added: %s\n", id)
+	fmt.Printf("Task added: %s\n", t.ID)
 }
 
 func handleDelete(tm *task.Manager, args []string) {
@@ -207,6 +551,19 @@ func handleDelete(tm *task.Manager, args []string) {
 	fmt.Printf("Task deleted: %s\n", id)
 }
 
+func handleClone(tm *task.Manager, args []string) {
+	if len(args) < 1 {
+		fmt.Fprintf(os.Stderr, "Usage: clone <id>\n")
+		os.Exit(1)
+	}
+	clone, err := tm.Clone(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error cloning task: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Task %s cloned as %s\n", args[0], clone.ID)
+}
+
 func handleStatusChange(tm *task.Manager, args []string, status task.Status) {
 	if len(args) < 1 {
 		fmt.Fprintf(os.Stderr, "Usage: <cmd> <id>\n")
@@ -222,17 +579,25 @@ func handleStatusChange(tm *task.Manager, args []string, status task.Status) {
 
 func handleRetry(tm *task.Manager, args []string) {
 	if len(args) < 1 {
-		fmt.Fprintf(os.Stderr, "Usage: retry <id>\n")
+		fmt.Fprintf(os.Stderr, "Usage: retry <id> [-append \"extra instructions\"]\n")
 		os.Exit(1)
 	}
 	id := args[0]
+
+	fs := flag.NewFlagSet("retry", flag.ExitOnError)
+	extra := fs.String("append", "", "Instructions appended to the prompt until the task succeeds")
+	fs.Parse(args[1:])
+
 	t, err := tm.GetByID(id)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	t.ResetForRetry()
+	t.RetryByHand()
+	if *extra != "" {
+		t.ExtraInstructions = *extra
+	}
 	if err := tm.UpdateTask(t); err != nil {
 		fmt.Fprintf(os.Stderr, "Error resetting task: %v\n", err)
 		os.Exit(1)
@@ -240,55 +605,102 @@ func handleRetry(tm *task.Manager, args []string) {
 	fmt.Printf("Task %s reset for retry\n", id)
 }
 
-func runTUI(cfg *config.Config, tm *task.Manager) {
-	// Try to acquire lock to become the "Leader" (Orchestrator Node)
-	// If lock exists, we run in "Client Mode" (TUI only)
-	lockFile := filepath.Join(filepath.Dir(cfg.TasksFile), "hive.lock")
-
-	f, err := os.OpenFile(lockFile, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
-	isLeader := (err == nil)
-
-	if isLeader {
-		// We are the leader
-		fmt.Fprintf(f, "%d", os.Getpid())
-		f.Close()
-
-		defer os.Remove(lockFile)
+func handleReset(tm *task.Manager, args []string) {
+	if len(args) < 1 {
+		fmt.Fprintf(os.Stderr, "Usage: reset <id>\n")
+		os.Exit(1)
+	}
+	id := args[0]
+	if err := tm.ForceReset(id); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Task %s reset to pending\n", id)
+}
 
+func runTUI(cfg *config.Config, tm *task.Manager, readOnly, force bool) {
+	// Become the "Leader" (Orchestrator Node) by taking the orchestrator
+	// lock, -force taking it from a live owner. If another orchestrator
+	// holds it, we run in "Client Mode" (TUI only). Read-only monitors
+	// never take the lead, they only observe
+	var orch *orchestrator.Orchestrator
+	var clientReason error
+	if !readOnly {
 		// 1. Setup Embedded Orchestrator
 		log, err := logger.NewEmbeddedLogger(cfg)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error creating logger: %v\n", err)
 			os.Exit(1)
 		}
+		tm.WithLogger(log)
 
 		gitClient := git.NewClient(cfg.WorkDirectory)
 
-		orch, err := orchestrator.New(cfg, log, gitClient, tm)
+		orch, err = orchestrator.New(cfg, log, gitClient, tm)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error creating orchestrator: %v\n", err)
 			os.Exit(1)
 		}
+		orch.SetForceLock(force)
+		orch.SetPlanReview(cfg.ReviewPlans)
+		if err := orch.AcquireLock(); err != nil {
+			clientReason = fmt.Errorf("running as a client: %w", err)
+			orch = nil
+		}
+	}
+
+	// 2. Run TUI (Both Leader and Client run the UI)
+	model := initialModel(cfg, tm)
+	model.Orchestrator = orch
+	model.ReadOnly = readOnly
+	if clientReason != nil {
+		model.Err = clientReason
+		model.ErrTime = time.Now()
+		model.ErrCount = 1
+	}
+
+	p := tea.NewProgram(model, tea.WithAltScreen())
 
-		// Run Orchestrator in background
+	// Run Orchestrator in background; if it stops early the TUI drops back
+	// to client mode
+	var stopOrch context.CancelFunc
+	orchDone := make(chan struct{})
+	if orch != nil {
 		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
+		stopOrch = cancel
 
 		go func() {
+			defer close(orchDone)
 			if err := orch.Run(ctx); err != nil && err != context.Canceled {
-				log.Error("orchestrator stopped with error", "error", err)
+				p.Send(tui.OrchestratorStoppedMsg{Error: err})
 			}
 		}()
 	}
 
-	// 2. Run TUI (Both Leader and Client run the UI)
-	model := initialModel(cfg, tm)
-
-	p := tea.NewProgram(model, tea.WithAltScreen())
 	if _, err := p.Run(); err != nil {
 		fmt.Printf("Error running hive: %v\n", err)
 		os.Exit(1)
 	}
+
+	// Let the embedded orchestrator drain in-flight tasks and release its
+	// lock. Shutdown kills agents after the timeout, so only a hung
+	// shutdown is given up on.
+	if stopOrch != nil {
+		stopOrch()
+		timeout := time.Duration(orch.Config().ShutdownTimeoutSeconds)*time.Second + shutdownGrace
+		select {
+		case <-orchDone:
+			return
+		default:
+		}
+		fmt.Printf("Waiting up to %s for running tasks to stop...\n", timeout)
+		select {
+		case <-orchDone:
+		case <-time.After(timeout):
+			fmt.Fprintf(os.Stderr, "Orchestrator did not stop within %s\n", timeout)
+		}
+	}
 }
 
 func initialModel(cfg *config.Config, tm *task.Manager) tui.Model {
@@ -309,13 +721,37 @@ func initialModel(cfg *config.Config, tm *task.Manager) tui.Model {
 	ti.Width = 80
 	ti.Blur() // Start in selection mode
 
+	// A history that fails to load still records this session's inputs
+	history, err := tui.LoadHistory(filepath.Join(cfg.LogDirectory, tui.HistoryFile))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	// Cancelled by the model on quit
+	watchCtx, watchCancel := context.WithCancel(context.Background())
+
 	return tui.Model{
-		TasksFile:     cfg.TasksFile,
-		LogDir:        cfg.LogDirectory,
-		WorkDirectory: cfg.WorkDirectory,
-		TaskManager:   tm,
-		TaskList:      l,
-		LogView:       logView,
-		Input:         ti,
+		TasksFile:        cfg.TasksFile,
+		LogDir:           cfg.LogDirectory,
+		AlertsOffset:     tui.AlertsStart(cfg.LogDirectory),
+		WorkDirectory:    cfg.WorkDirectory,
+		StripANSI:        cfg.StripANSI,
+		LogPreloadLines:  cfg.LogPreloadLines,
+		Theme:            cfg.Theme,
+		Git:              cfg.GitIntegration,
+		WorkerLayout:     cfg.WorkerLayout,
+		RolePriorities:   cfg.RolePriorities,
+		PlanningTriggers: cfg.PlanningTriggers,
+		PlanningRole:     cfg.PlanningRole,
+		Roles:            cfg.Roles(),
+		NumWorkers:       cfg.NumWorkers,
+		DispatchOrder:    cfg.DispatchOrder,
+		TaskManager:      tm,
+		TaskList:         l,
+		LogView:          logView,
+		Input:            ti,
+		History:          history,
+		WatchCtx:         watchCtx,
+		WatchCancel:      watchCancel,
 	}
 }
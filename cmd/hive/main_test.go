@@ -0,0 +1,125 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tuanbt/hive/internal/task"
+)
+
+// TestMain runs main instead of the tests when a test re-executes the
+// binary through runCLI.
+func TestMain(m *testing.M) {
+	if os.Getenv("HIVE_CLI_MAIN") == "1" {
+		os.Args = append([]string{"hive"}, strings.Split(os.Getenv("HIVE_CLI_ARGS"), "\n")...)
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runCLI runs hive with args in dir and returns its combined output and
+// exit code. Commands call os.Exit, so each run gets its own process.
+func runCLI(t *testing.T, dir string, args ...string) (string, int) {
+	t.Helper()
+
+	cmd := exec.Command(os.Args[0])
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "HIVE_CLI_MAIN=1", "HIVE_CLI_ARGS="+strings.Join(args, "\n"))
+	out, err := cmd.CombinedOutput()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return string(out), exitErr.ExitCode()
+	}
+	if err != nil {
+		t.Fatalf("failed to run hive: %v", err)
+	}
+	return string(out), 0
+}
+
+func TestDoctorFixesV0FileWithDuplicateIDs(t *testing.T) {
+	dir := t.TempDir()
+	tasksPath := filepath.Join(dir, "tasks.json")
+
+	// A version 0 file is a bare array, and early files could leave the
+	// status empty
+	v0 := `[
+		{"id": "task-1", "title": "First"},
+		{"id": "task-1", "title": "Second"},
+		{"id": "task-2", "title": "Third", "status": "completed"}
+	]`
+	if err := os.WriteFile(tasksPath, []byte(v0), 0644); err != nil {
+		t.Fatalf("failed to write tasks file: %v", err)
+	}
+
+	out, code := runCLI(t, dir, "doctor")
+	if code != 1 || !strings.Contains(out, "duplicate ID task-1 used by 2 tasks") {
+		t.Fatalf("expected doctor to report the duplicate, got exit %d:\n%s", code, out)
+	}
+
+	out, code = runCLI(t, dir, "doctor", "-fix")
+	if code != 0 || !strings.Contains(out, "Renamed duplicate of task-1") {
+		t.Fatalf("expected doctor -fix to repair the file, got exit %d:\n%s", code, out)
+	}
+
+	tasks, err := task.NewManager(tasksPath).LoadAll()
+	if err != nil {
+		t.Fatalf("failed to load repaired tasks: %v", err)
+	}
+	if len(tasks) != 3 || tasks[0].ID != "task-1" || tasks[1].ID == "task-1" || tasks[1].Title != "Second" {
+		t.Errorf("unexpected tasks after repair: %+v", tasks)
+	}
+
+	// The repaired file migrates and loads like any other
+	out, code = runCLI(t, dir, "list")
+	if code != 0 || !strings.Contains(out, "Second") {
+		t.Errorf("expected list to work after repair, got exit %d:\n%s", code, out)
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		wantCode int
+		wantOut  string
+	}{
+		{
+			name:     "valid config",
+			config:   `{"num_workers": 3, "max_task_retries": 2}`,
+			wantCode: 0,
+			wantOut:  "OK",
+		},
+		{
+			name:     "invalid value",
+			config:   `{"dispatch_order": "random"}`,
+			wantCode: 1,
+			wantOut:  "invalid configuration",
+		},
+		{
+			name:     "unknown key",
+			config:   `{"num_wrokers": 3}`,
+			wantCode: 1,
+			wantOut:  `unknown field "num_wrokers"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "config.json")
+			if err := os.WriteFile(path, []byte(tt.config), 0644); err != nil {
+				t.Fatalf("failed to write config: %v", err)
+			}
+
+			out, code := runCLI(t, dir, "validate-config", "-config", path)
+			if code != tt.wantCode || !strings.Contains(out, tt.wantOut) {
+				t.Errorf("expected exit %d with %q, got exit %d:\n%s", tt.wantCode, tt.wantOut, code, out)
+			}
+		})
+	}
+}
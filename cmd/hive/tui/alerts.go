@@ -0,0 +1,156 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tuanbt/hive/internal/logger"
+)
+
+// maxAlerts is how many recent orchestrator errors the alerts strip shows.
+const maxAlerts = 3
+
+// Alert is an error-level record from the orchestrator log.
+type Alert struct {
+	Time   time.Time
+	Msg    string
+	TaskID string
+	Error  string
+}
+
+// String renders the alert as a single line.
+func (a Alert) String() string {
+	line := a.Time.Format("15:04:05") + " " + a.Msg
+	if a.Error != "" {
+		line += ": " + a.Error
+	}
+	if a.TaskID != "" {
+		line += " [" + a.TaskID + "]"
+	}
+	return line
+}
+
+// parseAlert decodes a JSON orchestrator log line, returning it as an alert
+// if it was logged at error level.
+func parseAlert(line string) (Alert, bool) {
+	var record struct {
+		Time   time.Time `json:"time"`
+		Level  string    `json:"level"`
+		Msg    string    `json:"msg"`
+		TaskID string    `json:"task_id"`
+		Error  string    `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(line), &record); err != nil {
+		return Alert{}, false
+	}
+	if !strings.EqualFold(record.Level, "error") {
+		return Alert{}, false
+	}
+	return Alert{Time: record.Time, Msg: record.Msg, TaskID: record.TaskID, Error: record.Error}, true
+}
+
+// orchestratorLogPath returns the orchestrator log in logDir.
+func orchestratorLogPath(logDir string) string {
+	return filepath.Join(logDir, logger.OrchestratorLogFile)
+}
+
+// AlertsStart returns the offset the alerts strip starts reading the
+// orchestrator log from: its current end, so errors from earlier sessions
+// aren't shown.
+func AlertsStart(logDir string) int64 {
+	info, err := os.Stat(orchestratorLogPath(logDir))
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+// readAlerts returns the alerts in the complete lines appended to the
+// orchestrator log after offset, and the offset to resume from. A partly
+// written last line is left for the next read; a truncated log is read
+// again from the start.
+func readAlerts(path string, offset int64) ([]Alert, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, offset, nil
+		}
+		return nil, offset, fmt.Errorf("failed to open orchestrator log: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, offset, fmt.Errorf("failed to stat orchestrator log: %w", err)
+	}
+	if info.Size() < offset {
+		offset = 0
+	}
+	if info.Size() == offset {
+		return nil, offset, nil
+	}
+
+	data := make([]byte, info.Size()-offset)
+	n, err := file.ReadAt(data, offset)
+	if err != nil && err != io.EOF {
+		return nil, offset, fmt.Errorf("failed to read orchestrator log: %w", err)
+	}
+	data = data[:n]
+
+	end := bytes.LastIndexByte(data, '\n')
+	if end < 0 {
+		return nil, offset, nil
+	}
+
+	var alerts []Alert
+	for _, line := range strings.Split(string(data[:end]), "\n") {
+		if alert, ok := parseAlert(line); ok {
+			alerts = append(alerts, alert)
+		}
+	}
+	return alerts, offset + int64(end) + 1, nil
+}
+
+// pollAlerts picks up errors logged by the orchestrator since the last poll,
+// keeping the newest maxAlerts.
+func (m *Model) pollAlerts() {
+	alerts, offset, err := readAlerts(orchestratorLogPath(m.LogDir), m.AlertsOffset)
+	if err != nil {
+		m.setError(err)
+		return
+	}
+	m.AlertsOffset = offset
+	m.Alerts = append(m.Alerts, alerts...)
+	if len(m.Alerts) > maxAlerts {
+		m.Alerts = m.Alerts[len(m.Alerts)-maxAlerts:]
+	}
+}
+
+// latestAlertTask returns the task ID of the newest alert tied to a task.
+func (m Model) latestAlertTask() string {
+	for i := len(m.Alerts) - 1; i >= 0; i-- {
+		if m.Alerts[i].TaskID != "" {
+			return m.Alerts[i].TaskID
+		}
+	}
+	return ""
+}
+
+// renderAlerts draws one line per alert, newest last, cut to the width.
+func (m Model) renderAlerts() string {
+	lines := make([]string, len(m.Alerts))
+	for i, alert := range m.Alerts {
+		line := "⚠ " + alert.String()
+		if runes := []rune(line); m.Width > 0 && len(runes) > m.Width {
+			line = string(runes[:m.Width-1]) + "…"
+		}
+		lines[i] = StyleError.Render(line)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,85 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadAlerts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "orchestrator.log")
+	lines := `{"time":"2025-01-10T10:00:00Z","level":"INFO","msg":"task dispatched","task_id":"task-1"}
+{"time":"2025-01-10T10:00:01Z","level":"ERROR","msg":"failed to create branch","task_id":"task-1","error":"exit status 128"}
+not json
+{"time":"2025-01-10T10:00:02Z","level":"ERROR","msg":"agent not found"}
+{"time":"2025-01-10T10:00:03Z","level":"ERROR","msg":"half written`
+	if err := os.WriteFile(path, []byte(lines), 0644); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+
+	alerts, offset, err := readAlerts(path, 0)
+	if err != nil {
+		t.Fatalf("readAlerts() failed: %v", err)
+	}
+	if len(alerts) != 2 {
+		t.Fatalf("expected 2 error alerts, got %+v", alerts)
+	}
+	if alerts[0].TaskID != "task-1" || alerts[0].Error != "exit status 128" {
+		t.Errorf("unexpected first alert: %+v", alerts[0])
+	}
+	if got := alerts[0].String(); got != "10:00:01 failed to create branch: exit status 128 [task-1]" {
+		t.Errorf("unexpected alert line: %q", got)
+	}
+
+	// The half-written line is picked up once it is finished
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("failed to open log: %v", err)
+	}
+	f.WriteString(`"}` + "\n")
+	f.Close()
+
+	alerts, offset, err = readAlerts(path, offset)
+	if err != nil {
+		t.Fatalf("readAlerts() failed: %v", err)
+	}
+	if len(alerts) != 1 || alerts[0].Msg != "half written" {
+		t.Fatalf("expected the completed line as an alert, got %+v", alerts)
+	}
+
+	// A truncated log is read again from the start
+	if err := os.WriteFile(path, []byte(`{"level":"ERROR","msg":"after rotate"}`+"\n"), 0644); err != nil {
+		t.Fatalf("failed to rewrite log: %v", err)
+	}
+	alerts, _, err = readAlerts(path, offset)
+	if err != nil {
+		t.Fatalf("readAlerts() failed: %v", err)
+	}
+	if len(alerts) != 1 || alerts[0].Msg != "after rotate" {
+		t.Errorf("expected the rewritten log to be read, got %+v", alerts)
+	}
+}
+
+func TestPollAlertsKeepsLatest(t *testing.T) {
+	dir := t.TempDir()
+	var lines string
+	for _, msg := range []string{"one", "two", "three", "four"} {
+		lines += `{"level":"ERROR","msg":"` + msg + `","task_id":"task-` + msg + `"}` + "\n"
+	}
+	if err := os.WriteFile(orchestratorLogPath(dir), []byte(lines), 0644); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+
+	m := Model{LogDir: dir}
+	m.pollAlerts()
+	if len(m.Alerts) != maxAlerts || m.Alerts[0].Msg != "two" {
+		t.Fatalf("expected the newest %d alerts, got %+v", maxAlerts, m.Alerts)
+	}
+	if got := m.latestAlertTask(); got != "task-four" {
+		t.Errorf("expected latest alert task task-four, got %q", got)
+	}
+
+	if AlertsStart(dir) != int64(len(lines)) {
+		t.Errorf("expected alerts to start at the end of the existing log")
+	}
+}
@@ -1,46 +1,66 @@
 package tui
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/atotto/clipboard"
 	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/tuanbt/hive/internal/logtail"
 	"github.com/tuanbt/hive/internal/task"
 )
 
-// LoadTasks reads tasks from the tasks.json file via TaskManager
+// LoadTasks reads tasks from the tasks.json file via TaskManager, keeping
+// only those matching the active search. The footer's queue figures are
+// taken from the same snapshot, so the two always agree.
 func (m *Model) LoadTasks() []list.Item {
-	tasks, err := m.TaskManager.LoadAll()
+	snap, err := m.TaskManager.Snapshot()
 	if err != nil {
 		return []list.Item{}
 	}
+	m.ActiveTasks = snap.ActiveCount()
+	stats := snap.Stats()
+	m.PendingTasks = stats.Counts[task.StatusPending]
+	m.AvgTaskTime = stats.AvgCompletedDuration
+
+	tasks := snap.All()
+	if m.TaskSearch != "" {
+		tasks = snap.Search(m.TaskSearch)
+	}
 
 	items := make([]list.Item, len(tasks))
 	for i, t := range tasks {
-		statusIcon := "⏳"
-		switch t.Status {
-		case task.StatusInProgress:
-			statusIcon = "🏃"
-		case task.StatusReviewing:
-			statusIcon = "👀"
-		case task.StatusCompleted:
-			statusIcon = "✅"
-		case task.StatusFailed:
-			statusIcon = "❌"
-		}
+		statusIcon := StatusIcon(string(t.Status))
 
 		desc := string(t.Status)
 		if t.Status == task.StatusInProgress || t.Status == task.StatusReviewing {
 			desc = fmt.Sprintf("%s | ID: %s", t.Status, t.ID)
 		} else if t.Status == task.StatusFailed {
 			desc = fmt.Sprintf("Failed: %s", t.FailReason)
+			if t.CompletionReason != "" {
+				desc = fmt.Sprintf("Failed: %s", t.CompletionLabel())
+			}
+		} else if t.Status == task.StatusBlocked {
+			desc = fmt.Sprintf("Blocked: %s", t.FailReason)
+		}
+
+		// Indent subtasks generated by a planning task
+		title := fmt.Sprintf("%s %s", statusIcon, t.Title)
+		if t.ParentID != "" {
+			title = "└─ " + title
 		}
 
 		items[i] = TaskItem{
 			ID:          t.ID,
-			Title:       fmt.Sprintf("%s %s", statusIcon, t.Title),
+			Title:       title,
 			Status:      string(t.Status),
 			Description: desc,
 		}
@@ -50,23 +70,37 @@ func (m *Model) LoadTasks() []list.Item {
 
 // AddTask appends a new task to the file
 func (m *Model) AddTask(title string) error {
-	t := task.NewTask(
-		fmt.Sprintf("task-%d", time.Now().UnixNano()),
-		title,
-		title,
-	)
+	t := task.NewTask("", title, title)
+
+	return m.TaskManager.Create(t)
+}
 
-	return m.TaskManager.AddTask(t)
+// ansiPattern matches CSI, OSC and single-character escape sequences
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)|\x1b[@-Z\\-_]`)
+
+// StripANSI removes terminal escape sequences from s
+func StripANSI(s string) string {
+	return ansiPattern.ReplaceAllString(s, "")
 }
 
 // ReadLogs reads the log file for the selected task
 func (m *Model) ReadLogs(taskID string) string {
+	return m.readLogs(taskID, 0)
+}
+
+// ReadLogTail reads the last LogPreloadLines lines of the task's log
+func (m *Model) ReadLogTail(taskID string) string {
+	return m.readLogs(taskID, m.LogPreloadLines)
+}
+
+// readLogs reads the last n lines of a task's log, or all of it if n <= 0
+func (m *Model) readLogs(taskID string, n int) string {
 	if taskID == "" {
 		return "No task selected."
 	}
 
 	path := filepath.Join(m.LogDir, fmt.Sprintf("%s.log", taskID))
-	content, err := os.ReadFile(path)
+	content, _, err := logtail.ReadTail(path, n)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return "Waiting for logs..."
@@ -76,7 +110,99 @@ func (m *Model) ReadLogs(taskID string) string {
 	if len(content) == 0 {
 		return "Log file empty..."
 	}
-	return string(content)
+	if m.StripANSI {
+		return StripANSI(content)
+	}
+	return content
+}
+
+// ErrNoLogs is returned by CopyLogs when the task has no log output yet.
+var ErrNoLogs = errors.New("nothing to copy: no log output yet")
+
+// CopyLogs copies a task's log to the system clipboard. When no clipboard
+// is available the log is written to a timestamped file in the log
+// directory instead, and that file's path is returned. A task without log
+// output yields ErrNoLogs.
+func (m *Model) CopyLogs(taskID string) (string, error) {
+	path := filepath.Join(m.LogDir, fmt.Sprintf("%s.log", taskID))
+	content, _, err := logtail.ReadTail(path, 0)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read logs: %w", err)
+	}
+	if content == "" {
+		return "", ErrNoLogs
+	}
+	if m.StripANSI {
+		content = StripANSI(content)
+	}
+	if !clipboard.Unsupported {
+		if err := clipboard.WriteAll(content); err == nil {
+			return "", nil
+		}
+	}
+
+	name := fmt.Sprintf("%s-%s.txt", taskID, time.Now().Format("20060102-150405"))
+	path = filepath.Join(m.LogDir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return "", fmt.Errorf("failed to write log copy: %w", err)
+	}
+	return path, nil
+}
+
+// InspectBranch shows the git branch of a task. With an inspect command
+// configured, the command runs in the work directory and its output is
+// delivered as an InspectResultMsg; otherwise the branch name is shown as
+// a toast.
+func (m *Model) InspectBranch(taskID string) tea.Cmd {
+	branch := m.Git.BranchName(taskID)
+	command, err := m.Git.InspectCommand(taskID)
+	if err != nil {
+		m.setError(err)
+		return nil
+	}
+	if command == "" {
+		m.showToast("branch: " + branch)
+		return nil
+	}
+
+	dir := m.WorkDirectory
+	return func() tea.Msg {
+		cmd := exec.Command("sh", "-c", command)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		return InspectResultMsg{TaskID: taskID, Branch: branch, Output: string(out), Err: err}
+	}
+}
+
+// FilterLogs keeps only the lines of content containing filter, ignoring
+// case, and highlights each match. An empty filter returns content as is.
+func FilterLogs(content, filter string) string {
+	if filter == "" {
+		return content
+	}
+
+	// Matching on the original line keeps the offsets valid, lowercasing
+	// can change a line's byte length
+	pattern := regexp.MustCompile("(?i)" + regexp.QuoteMeta(filter))
+	var kept []string
+	for _, line := range strings.Split(content, "\n") {
+		matches := pattern.FindAllStringIndex(line, -1)
+		if matches == nil {
+			continue
+		}
+
+		// Highlight every match, preserving the original casing
+		var b strings.Builder
+		last := 0
+		for _, match := range matches {
+			b.WriteString(line[last:match[0]])
+			b.WriteString(StyleHighlight.Render(line[match[0]:match[1]]))
+			last = match[1]
+		}
+		b.WriteString(line[last:])
+		kept = append(kept, b.String())
+	}
+	return strings.Join(kept, "\n")
 }
 
 // DeleteTask removes a task from the file
@@ -84,24 +210,91 @@ func (m *Model) DeleteTask(taskID string) error {
 	return m.TaskManager.DeleteTask(taskID)
 }
 
+// CloneTask adds a pending copy of a task and returns the copy's ID
+func (m *Model) CloneTask(taskID string) (string, error) {
+	clone, err := m.TaskManager.Clone(taskID)
+	if err != nil {
+		return "", err
+	}
+	return clone.ID, nil
+}
+
 // RetryTask resets a failed task for retry
 func (m *Model) RetryTask(taskID string) error {
 	t, err := m.TaskManager.GetByID(taskID)
 	if err != nil {
 		return err
 	}
-	t.ResetForRetry()
+	t.RetryByHand()
 	return m.TaskManager.UpdateTask(t)
 }
 
-// Nuke cancels all active tasks
+// SetTaskRole routes a pending task to another role, which must be one of
+// m.Roles.
+func (m *Model) SetTaskRole(taskID, role string) error {
+	if !slices.Contains(m.Roles, role) {
+		return fmt.Errorf("unknown role %q (known: %s)", role, strings.Join(m.Roles, ", "))
+	}
+	return m.TaskManager.Modify(taskID, func(t *task.Task) error {
+		if err := requirePending(t); err != nil {
+			return err
+		}
+		t.Role = role
+		return nil
+	})
+}
+
+// SetTaskPriority changes a pending task's priority
+func (m *Model) SetTaskPriority(taskID string, priority int) error {
+	return m.TaskManager.Modify(taskID, func(t *task.Task) error {
+		if err := requirePending(t); err != nil {
+			return err
+		}
+		t.Priority = priority
+		return nil
+	})
+}
+
+// requirePending refuses edits to tasks a worker may already be running
+func requirePending(t *task.Task) error {
+	if t.Status != task.StatusPending {
+		return fmt.Errorf("task %s is %s, only pending tasks can be changed", t.ID, t.Status)
+	}
+	return nil
+}
+
+// MoveTaskToTop moves a task to the front of the queue
+func (m *Model) MoveTaskToTop(taskID string) error {
+	return m.TaskManager.MoveToTop(taskID)
+}
+
+// MoveTaskUp swaps a task with the one listed directly above it
+func (m *Model) MoveTaskUp(taskID string) error {
+	idx := m.TaskList.Index()
+	if idx <= 0 {
+		return nil
+	}
+	prev, ok := m.TaskList.Items()[idx-1].(TaskItem)
+	if !ok {
+		return nil
+	}
+	return m.TaskManager.MoveBefore(taskID, prev.ID)
+}
+
+// Nuke cancels all active tasks. When this instance runs the orchestrator,
+// in-flight agent processes are killed as well.
 func (m *Model) Nuke() error {
+	if m.Orchestrator != nil {
+		_, err := m.Orchestrator.CancelAll()
+		return err
+	}
+
 	tasks, err := m.TaskManager.LoadAll()
 	if err != nil {
 		return err
 	}
 	for _, t := range tasks {
-		if t.Status == task.StatusInProgress || t.Status == task.StatusPending || t.Status == task.StatusReviewing {
+		if t.Status == task.StatusPending || t.Status.IsActive() {
 			m.TaskManager.UpdateStatus(t.ID, task.StatusFailed, "Nuked by user")
 		}
 	}
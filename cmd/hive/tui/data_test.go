@@ -0,0 +1,21 @@
+package tui
+
+import "testing"
+
+func TestFilterLogs(t *testing.T) {
+	content := "ȺȺ error: disk full\nall good\nERROR again"
+
+	got := FilterLogs(content, "error")
+	want := "ȺȺ " + StyleHighlight.Render("error") + ": disk full\n" + StyleHighlight.Render("ERROR") + " again"
+	if got != want {
+		t.Errorf("FilterLogs() = %q, want %q", got, want)
+	}
+
+	// Lowercasing Ⱥ adds a byte, the match must still line up
+	if got := FilterLogs("ȺȺȺȺ warn", "WARN"); got != "ȺȺȺȺ "+StyleHighlight.Render("warn") {
+		t.Errorf("unexpected non-ASCII match %q", got)
+	}
+	if got := FilterLogs(content, ""); got != content {
+		t.Errorf("expected an empty filter to keep everything, got %q", got)
+	}
+}
@@ -43,7 +43,7 @@ func (d TaskDelegate) Render(w io.Writer, m list.Model, index int, listItem list
 		// Selected item secondary line (maybe distinct color?)
 		fmt.Fprint(w, StyleDimmed.Render(fmt.Sprintf("    %s", logStr)))
 	} else {
-		fmt.Fprint(w, StyleTaskDimmed.Render(fmt.Sprintf("  %s", titleStr))+"\n")
+		fmt.Fprint(w, statusStyle(it.Status).Render(fmt.Sprintf("  %s", titleStr))+"\n")
 		fmt.Fprint(w, StyleDimmed.Render(fmt.Sprintf("    %s", logStr)))
 	}
 }
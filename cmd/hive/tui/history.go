@@ -0,0 +1,107 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxHistory is how many submitted inputs the history keeps.
+const maxHistory = 500
+
+// HistoryFile is the name of the input history file in the log directory.
+const HistoryFile = "tui_history"
+
+// History is a bounded list of submitted inputs that can be recalled with
+// the arrow keys. It is persisted to a file, one entry per line, so it
+// survives across sessions.
+type History struct {
+	path    string
+	entries []string
+	pos     int    // index being recalled; len(entries) when not recalling
+	draft   string // input typed before recall started
+}
+
+// LoadHistory reads the history stored at path. A missing file yields an
+// empty history; an empty path keeps it in memory only.
+func LoadHistory(path string) (*History, error) {
+	h := &History{path: path}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return h, fmt.Errorf("failed to read history: %w", err)
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if line != "" {
+				h.entries = append(h.entries, line)
+			}
+		}
+		if len(h.entries) > maxHistory {
+			h.entries = h.entries[len(h.entries)-maxHistory:]
+		}
+	}
+	h.pos = len(h.entries)
+	return h, nil
+}
+
+// Add records a submitted input and ends any recall in progress. Repeating
+// the latest entry is not recorded twice.
+func (h *History) Add(entry string) error {
+	h.pos = len(h.entries)
+	h.draft = ""
+	if entry == "" || strings.Contains(entry, "\n") {
+		return nil
+	}
+	if n := len(h.entries); n > 0 && h.entries[n-1] == entry {
+		return nil
+	}
+
+	h.entries = append(h.entries, entry)
+	if len(h.entries) > maxHistory {
+		h.entries = h.entries[len(h.entries)-maxHistory:]
+	}
+	h.pos = len(h.entries)
+	return h.save()
+}
+
+// Prev steps back to the previous entry. current is the input being edited,
+// restored once Next walks past the newest entry.
+func (h *History) Prev(current string) (string, bool) {
+	if h.pos == 0 {
+		return "", false
+	}
+	if h.pos == len(h.entries) {
+		h.draft = current
+	}
+	h.pos--
+	return h.entries[h.pos], true
+}
+
+// Next steps forward to the next entry, returning to the draft after the
+// newest one.
+func (h *History) Next() (string, bool) {
+	if h.pos >= len(h.entries) {
+		return "", false
+	}
+	h.pos++
+	if h.pos == len(h.entries) {
+		return h.draft, true
+	}
+	return h.entries[h.pos], true
+}
+
+// save writes the history to its file.
+func (h *History) save() error {
+	if h.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	data := strings.Join(h.entries, "\n") + "\n"
+	if err := os.WriteFile(h.path, []byte(data), 0644); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package tui
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestHistoryRecallAndPersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), HistoryFile)
+
+	h, err := LoadHistory(path)
+	if err != nil {
+		t.Fatalf("failed to load history: %v", err)
+	}
+	for _, entry := range []string{"first task", "/filter err", "/filter err", "second task"} {
+		if err := h.Add(entry); err != nil {
+			t.Fatalf("failed to add entry: %v", err)
+		}
+	}
+
+	// Walk back past the oldest entry, then forward to the draft
+	var got []string
+	for {
+		entry, ok := h.Prev("half typed")
+		if !ok {
+			break
+		}
+		got = append(got, entry)
+	}
+	want := []string{"second task", "/filter err", "first task"}
+	if len(got) != len(want) {
+		t.Fatalf("recalled %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("recalled %v, want %v", got, want)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		h.Next()
+	}
+	if entry, _ := h.Next(); entry != "half typed" {
+		t.Errorf("expected the draft after the newest entry, got %q", entry)
+	}
+	if _, ok := h.Next(); ok {
+		t.Error("expected nothing past the draft")
+	}
+
+	reloaded, err := LoadHistory(path)
+	if err != nil {
+		t.Fatalf("failed to reload history: %v", err)
+	}
+	if entry, ok := reloaded.Prev(""); !ok || entry != "second task" {
+		t.Errorf("expected the persisted newest entry, got %q", entry)
+	}
+}
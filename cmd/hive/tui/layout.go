@@ -0,0 +1,61 @@
+package tui
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/tuanbt/hive/internal/config"
+	"github.com/tuanbt/hive/internal/worker"
+)
+
+// workerColumns returns how many workers the worker panel shows side by
+// side for the layout. Auto stacks a single worker, splits two and tiles
+// up to four as 2x2; more than that are stacked to stay readable.
+func workerColumns(layout string, workers int) int {
+	if workers < 1 {
+		return 1
+	}
+	switch layout {
+	case config.LayoutRows:
+		return 1
+	case config.LayoutColumns:
+		return workers
+	case config.LayoutGrid:
+		return int(math.Ceil(math.Sqrt(float64(workers))))
+	}
+	if workers <= 4 {
+		return min(workers, 2)
+	}
+	return 1
+}
+
+// workerCard renders a worker as a few short lines for the side by side
+// layouts.
+func workerCard(w worker.WorkerInfo) string {
+	title := fmt.Sprintf("worker %d", w.ID)
+	if !w.Busy() {
+		return StyleDimmed.Render(title + "\nidle")
+	}
+	elapsed := time.Since(w.StartedAt).Round(time.Second)
+	return fmt.Sprintf("%s\nbusy %s\n%s", title, elapsed, w.TaskID)
+}
+
+// layoutWorkers arranges the worker cards in rows of cols cells, each
+// width columns wide.
+func layoutWorkers(workers []worker.WorkerInfo, cols, width int) string {
+	cell := lipgloss.NewStyle().Width(max(width/cols, 1)).MaxWidth(max(width/cols, 1))
+
+	var rows []string
+	for start := 0; start < len(workers); start += cols {
+		end := min(start+cols, len(workers))
+		cells := make([]string, 0, end-start)
+		for _, w := range workers[start:end] {
+			cells = append(cells, cell.Render(workerCard(w)))
+		}
+		rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, cells...))
+	}
+	return strings.Join(rows, "\n\n")
+}
@@ -0,0 +1,47 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tuanbt/hive/internal/config"
+	"github.com/tuanbt/hive/internal/worker"
+)
+
+func TestWorkerColumns(t *testing.T) {
+	tests := []struct {
+		layout  string
+		workers int
+		want    int
+	}{
+		{config.LayoutAuto, 1, 1},
+		{config.LayoutAuto, 2, 2},
+		{config.LayoutAuto, 4, 2},
+		{config.LayoutAuto, 6, 1},
+		{config.LayoutRows, 4, 1},
+		{config.LayoutColumns, 4, 4},
+		{config.LayoutGrid, 4, 2},
+		{config.LayoutGrid, 5, 3},
+		{config.LayoutColumns, 0, 1},
+	}
+	for _, tt := range tests {
+		if got := workerColumns(tt.layout, tt.workers); got != tt.want {
+			t.Errorf("workerColumns(%q, %d) = %d, want %d", tt.layout, tt.workers, got, tt.want)
+		}
+	}
+}
+
+func TestViewWorkersColumns(t *testing.T) {
+	workers := []worker.WorkerInfo{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}}
+
+	m := Model{Width: 200, Workers: workers, WorkerLayout: config.LayoutColumns}
+	lines := strings.Split(m.viewWorkers(), "\n")
+	if !strings.Contains(lines[0], "worker 1") || !strings.Contains(lines[0], "worker 4") {
+		t.Errorf("expected all workers on the first line, got %q", lines[0])
+	}
+
+	m.WorkerLayout = config.LayoutRows
+	if got := strings.Count(m.viewWorkers(), "\n"); got != len(workers)+1 {
+		t.Errorf("expected one line per worker plus the summary, got %d newlines", got)
+	}
+}
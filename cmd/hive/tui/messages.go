@@ -10,12 +10,21 @@ type TasksUpdatedMsg struct{}
 type LogLineMsg struct {
 	TaskID string
 	Line   string
+	Offset int64 // file offset where tailing resumes after Line
 }
 
 // WatcherErrorMsg signals that the file watcher encountered an error.
 // The TUI should fall back to polling mode when receiving this message.
 type WatcherErrorMsg struct {
-	Error error
+	Watcher string // WatcherTasks or WatcherLogs
+	Error   error
+}
+
+// WatchRetryMsg reports the outcome of /watch-retry. On success Watchers
+// lists the watchers that can be restarted.
+type WatchRetryMsg struct {
+	Watchers []string
+	Error    error
 }
 
 // LogFileCreatedMsg signals that a new log file was created in the logs directory.
@@ -25,8 +34,23 @@ type LogFileCreatedMsg struct {
 	Path   string
 }
 
+// InspectResultMsg carries the output of the inspect command run for a
+// task's git branch.
+type InspectResultMsg struct {
+	TaskID string
+	Branch string
+	Output string
+	Err    error
+}
+
 // TailerStoppedMsg signals that a log tailer has stopped (task completed or error).
 type TailerStoppedMsg struct {
 	TaskID string
 	Error  error
 }
+
+// OrchestratorStoppedMsg signals that the embedded orchestrator stopped
+// with an error while the TUI was still running.
+type OrchestratorStoppedMsg struct {
+	Error error
+}
@@ -7,7 +7,10 @@ import (
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
+	"github.com/tuanbt/hive/internal/config"
+	"github.com/tuanbt/hive/internal/orchestrator"
 	"github.com/tuanbt/hive/internal/task"
+	"github.com/tuanbt/hive/internal/worker"
 )
 
 type tickMsg time.Time
@@ -19,13 +22,50 @@ const (
 	ModeInsert
 )
 
+// Pane is a pane of the main layout that can hold the focus.
+type Pane int
+
+const (
+	PaneTasks Pane = iota
+	PaneLogs
+	paneCount
+)
+
 type Model struct {
 	// Core dependencies
 	TaskManager   *task.Manager
+	Orchestrator  *orchestrator.Orchestrator // nil when running as a client
 	TasksFile     string
 	LogDir        string
 	WorkDirectory string
 
+	// RolePriorities holds the default priority for tasks of each role
+	RolePriorities map[string]int
+
+	// PlanningTriggers are title prefixes that give a new task PlanningRole
+	PlanningTriggers []string
+	PlanningRole     string
+
+	// Roles are the task roles /role accepts
+	Roles []string
+
+	// DispatchOrder is the order pending tasks are picked in; t and K only
+	// reorder the queue under the priority order
+	DispatchOrder task.DispatchOrder
+
+	// NumWorkers spreads the pending tasks over the workers in the queue ETA
+	NumWorkers int
+
+	// LogPreloadLines is how many trailing log lines are loaded on selection
+	LogPreloadLines int
+
+	// Theme is the configured palette, applied when the program starts
+	Theme config.ThemeConfig
+
+	// Git is the git integration config; the branch key is hidden when
+	// it is disabled
+	Git config.GitConfig
+
 	// UI Components
 	TaskList list.Model
 	LogView viewport.Model // Single viewport for selected task
@@ -36,20 +76,62 @@ type Model struct {
 	Width          int
 	Height         int
 	Mode           ViewMode
+	FocusedPane    Pane   // pane receiving scroll keys, cycled with tab
+	LogContent     string // everything in LogView, which only renders what fits
 	Err            error
+	ErrTime        time.Time // when the latest Err was reported
+	ErrCount       int       // errors reported since the last dismissal
 	Ready          bool
+	StripANSI      bool
+	ReadOnly       bool   // disables mutating keybindings and commands
+	LogFilter      string // only log lines containing this are shown
+	LogPartial     string // unfinished last log line, held back from the filter
+	TaskSearch     string // only tasks matching this are listed
+	Toast          string
+	ToastExpiry    time.Time
+	ActiveTasks    int                 // queued, running and reviewing tasks
+	PendingTasks   int                 // tasks waiting for a worker
+	AvgTaskTime    time.Duration       // mean run time of completed tasks, zero without history
+	Inspect        *InspectResultMsg   // branch inspection shown over the log pane
+	ShowWorkers    bool                // worker status shown over the log pane
+	Workers        []worker.WorkerInfo // refreshed on each tick while shown
+	WorkerLayout   string              // arrangement of the worker panel, see config.LayoutAuto
+
+	// FallbackPolling is set once a file watcher fails; updates then only
+	// arrive with the periodic tick until /watch-retry restores it
+	FallbackPolling bool
+	DownWatchers    []string // watchers to restart on /watch-retry
+
+	// Plans are the agent plans held for review by the orchestrator,
+	// refreshed on each tick and shown over the log pane until accepted
+	// or discarded
+	Plans []orchestrator.PendingPlan
+
+	// Alerts are the latest errors from the orchestrator log, read from
+	// AlertsOffset on each tick and shown above the footer
+	Alerts       []Alert
+	AlertsOffset int64
 
 	// Real-time tracking
 	TailerCtx    context.Context
 	TailerCancel context.CancelFunc
 	LogOffsets   map[string]int64
 
+	// WatchCtx stops the file watchers when WatchCancel is called on quit;
+	// once Quitting is set, finished watchers are no longer re-armed
+	WatchCtx    context.Context
+	WatchCancel context.CancelFunc
+	Quitting    bool
+
 	// Suggestions (for @ and / commands)
 	SuggestionActive bool
 	SuggestionType   string // "@" or "/"
 	Suggestions      []string
 	SuggestionIdx    int
 	SuggestionStart  int // Cursor index where @ started
+
+	// History holds submitted inputs, recalled with up/down; nil disables it
+	History *History
 }
 
 // TaskItem implements list.Item
@@ -65,15 +147,7 @@ func (i TaskItem) FilterValue() string       { return i.Title }
 func (t TaskItem) TitleString() string       { return t.Title }
 func (t TaskItem) DescriptionString() string { return t.Description }
 
-// GetRunningTasks returns tasks that are currently in progress
-func (m Model) GetRunningTasks() []TaskItem {
-	var running []TaskItem
-	for _, item := range m.TaskList.Items() {
-		if t, ok := item.(TaskItem); ok {
-			if t.Status == "in_progress" || t.Status == "reviewing" {
-				running = append(running, t)
-			}
-		}
-	}
-	return running
+// watchConfig returns the configuration the file watchers run with.
+func (m Model) watchConfig() WatchConfig {
+	return WatchConfig{TasksFile: m.TasksFile, LogDir: m.LogDir, Ctx: m.WatchCtx}
 }
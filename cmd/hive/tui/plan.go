@@ -0,0 +1,59 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// refreshPlans picks up the agent plans the orchestrator holds for review.
+func (m *Model) refreshPlans() {
+	if m.Orchestrator != nil {
+		m.Plans = m.Orchestrator.Plans()
+	}
+}
+
+// acceptPlan adds the subtasks of the oldest held plan to the queue.
+func (m *Model) acceptPlan() {
+	if len(m.Plans) == 0 || m.Orchestrator == nil {
+		return
+	}
+	added, err := m.Orchestrator.AcceptPlan(m.Plans[0].TaskID)
+	if err != nil {
+		m.setError(err)
+	} else {
+		m.showToast(fmt.Sprintf("added %d planned tasks", added))
+	}
+	m.refreshPlans()
+	m.setTasks(m.LoadTasks())
+}
+
+// discardPlan drops the oldest held plan.
+func (m *Model) discardPlan() {
+	if len(m.Plans) == 0 || m.Orchestrator == nil {
+		return
+	}
+	if err := m.Orchestrator.DiscardPlan(m.Plans[0].TaskID); err != nil {
+		m.setError(err)
+	} else {
+		m.showToast("plan discarded")
+	}
+	m.refreshPlans()
+}
+
+// viewPlan renders the oldest held plan with one line per subtask.
+func (m Model) viewPlan() string {
+	plan := m.Plans[0]
+	lines := []string{fmt.Sprintf("%s proposes %d subtasks:", plan.Title, len(plan.Tasks)), ""}
+	for i, t := range plan.Tasks {
+		role := t.Role
+		if role == "" {
+			role = "-"
+		}
+		lines = append(lines, fmt.Sprintf("%2d. %-12s %s", i+1, role, t.Title))
+	}
+	lines = append(lines, "", StyleHelp.Render("A=accept X=discard"))
+	if more := len(m.Plans) - 1; more > 0 {
+		lines = append(lines, StyleDimmed.Render(fmt.Sprintf("%d more plans waiting", more)))
+	}
+	return strings.Join(lines, "\n")
+}
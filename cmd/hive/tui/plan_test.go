@@ -0,0 +1,71 @@
+package tui
+
+import (
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/tuanbt/hive/internal/config"
+	"github.com/tuanbt/hive/internal/git"
+	"github.com/tuanbt/hive/internal/orchestrator"
+	"github.com/tuanbt/hive/internal/task"
+)
+
+func TestPlanKeys(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.DefaultConfig()
+	cfg.TasksFile = filepath.Join(dir, "tasks.json")
+	cfg.LogDirectory = filepath.Join(dir, "logs")
+	cfg.WorkDirectory = dir
+
+	tm := task.NewManager(cfg.TasksFile)
+	o, err := orchestrator.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)), git.NewClient(dir), tm)
+	if err != nil {
+		t.Fatalf("failed to create orchestrator: %v", err)
+	}
+	for _, id := range []string{"plan-1", "plan-2"} {
+		if err := tm.AddTask(task.NewTask(id, "Plan "+id, "")); err != nil {
+			t.Fatalf("failed to add task: %v", err)
+		}
+		if err := tm.HoldPlan(id, []*task.Task{task.NewTask("", id+" subtask", "")}); err != nil {
+			t.Fatalf("failed to hold plan: %v", err)
+		}
+	}
+
+	m := Model{
+		TaskManager:  tm,
+		Orchestrator: o,
+		TaskList:     list.New(nil, list.NewDefaultDelegate(), 0, 0),
+	}
+	m.refreshPlans()
+	if len(m.Plans) != 2 {
+		t.Fatalf("expected 2 held plans, got %d", len(m.Plans))
+	}
+
+	// A accepts the oldest plan and queues its subtasks
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("A")})
+	if m.Err != nil {
+		t.Fatalf("unexpected error: %v", m.Err)
+	}
+	if len(m.Plans) != 1 || m.Plans[0].TaskID != "plan-2" {
+		t.Fatalf("expected only plan-2 to be left, got %+v", m.Plans)
+	}
+	if children, _ := tm.Children("plan-1"); len(children) != 1 {
+		t.Errorf("expected accepting to add 1 subtask, got %d", len(children))
+	}
+
+	// X drops the next plan without adding anything
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("X")})
+	if m.Err != nil {
+		t.Fatalf("unexpected error: %v", m.Err)
+	}
+	if len(m.Plans) != 0 {
+		t.Errorf("expected no plans left, got %+v", m.Plans)
+	}
+	if children, _ := tm.Children("plan-2"); len(children) != 0 {
+		t.Errorf("expected discarding to add nothing, got %d subtasks", len(children))
+	}
+}
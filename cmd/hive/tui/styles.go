@@ -1,8 +1,12 @@
 package tui
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"github.com/charmbracelet/lipgloss"
+	"github.com/tuanbt/hive/internal/config"
+	"github.com/tuanbt/hive/internal/task"
+)
 
-// Fixed color palette (hacker theme only)
+// Color palette, the hacker theme unless overridden by ApplyTheme
 var (
 	ColorBg      = lipgloss.Color("#000000") // Pure black
 	ColorFg      = lipgloss.Color("#00FF00") // Bright green
@@ -11,8 +15,84 @@ var (
 	ColorError   = lipgloss.Color("#FF0000") // Red
 )
 
-// Essential styles only
+// Status icons and colors, overridden per status by ApplyTheme
 var (
+	statusIcons = map[string]string{
+		string(task.StatusPending):    "⏳",
+		string(task.StatusQueued):     "📥",
+		string(task.StatusInProgress): "🏃",
+		string(task.StatusReviewing):  "👀",
+		string(task.StatusCompleted):  "✅",
+		string(task.StatusFailed):     "❌",
+		string(task.StatusBlocked):    "🚧",
+	}
+	statusColors = map[string]lipgloss.Color{}
+	statusStyles = map[string]lipgloss.Style{}
+)
+
+// Essential styles only, built from the palette by buildStyles
+var (
+	StyleBorder        lipgloss.Style
+	StyleBorderFocused lipgloss.Style
+	StyleTitle         lipgloss.Style
+	StyleDimmed        lipgloss.Style
+	StyleTaskSelected  lipgloss.Style
+	StyleTaskNormal    lipgloss.Style
+	StyleTaskDimmed    lipgloss.Style
+	StyleInput         lipgloss.Style
+	StyleStatus        lipgloss.Style
+	StyleHelp          lipgloss.Style
+	StyleError         lipgloss.Style
+	StyleHighlight     lipgloss.Style
+	StyleBadge         lipgloss.Style
+)
+
+func init() {
+	buildStyles()
+}
+
+// ApplyTheme replaces the palette with the configured colors and rebuilds
+// the styles. Empty colors keep their current value.
+func ApplyTheme(theme config.ThemeConfig) {
+	set := func(c *lipgloss.Color, hex string) {
+		if hex != "" {
+			*c = lipgloss.Color(hex)
+		}
+	}
+	set(&ColorFg, theme.Foreground)
+	set(&ColorBg, theme.Background)
+	set(&ColorPrimary, theme.Primary)
+	set(&ColorDim, theme.Dim)
+	set(&ColorError, theme.Error)
+	for status, icon := range theme.StatusIcons {
+		if icon != "" {
+			statusIcons[status] = icon
+		}
+	}
+	for status, hex := range theme.StatusColors {
+		if hex != "" {
+			statusColors[status] = lipgloss.Color(hex)
+		}
+	}
+	buildStyles()
+}
+
+// StatusIcon returns the icon shown for a task status.
+func StatusIcon(status string) string {
+	return statusIcons[status]
+}
+
+// statusStyle returns the style for an unselected task with the given
+// status, the dimmed task style unless the theme colors that status.
+func statusStyle(status string) lipgloss.Style {
+	if style, ok := statusStyles[status]; ok {
+		return style
+	}
+	return StyleTaskDimmed
+}
+
+// buildStyles derives every style from the current palette
+func buildStyles() {
 	StyleBorder = lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
 		BorderForeground(ColorDim)
@@ -35,6 +115,9 @@ var (
 	StyleTaskNormal = lipgloss.NewStyle().
 		Foreground(ColorFg)
 
+	StyleTaskDimmed = lipgloss.NewStyle().
+		Foreground(ColorDim)
+
 	StyleInput = lipgloss.NewStyle().
 		Foreground(ColorPrimary).
 		Bold(true)
@@ -49,4 +132,19 @@ var (
 
 	StyleError = lipgloss.NewStyle().
 		Foreground(ColorError)
-)
+
+	StyleHighlight = lipgloss.NewStyle().
+		Foreground(ColorBg).
+		Background(ColorPrimary)
+
+	StyleBadge = lipgloss.NewStyle().
+		Foreground(ColorBg).
+		Background(ColorPrimary).
+		Bold(true).
+		Padding(0, 1)
+
+	for status, color := range statusColors {
+		statusStyles[status] = lipgloss.NewStyle().
+			Foreground(color)
+	}
+}
@@ -2,11 +2,10 @@ package tui
 
 import (
 	"context"
-	"io"
 	"os"
-	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/tuanbt/hive/internal/logtail"
 )
 
 // LogTailer handles tailing a log file and streaming new lines.
@@ -34,11 +33,11 @@ func (t *LogTailer) Stop() {
 }
 
 // startTailing returns a tea.Cmd that starts tailing a log file.
-// It reads the entire existing content first, then tails new lines.
-func startTailing(taskID, path string, ctx context.Context) tea.Cmd {
+// It preloads the last lines of the existing content first, then tails new lines.
+func startTailing(taskID, path string, ctx context.Context, lines int) tea.Cmd {
 	return func() tea.Msg {
-		// First, read existing content
-		content, err := os.ReadFile(path)
+		// First, read the tail of the existing content
+		content, size, err := logtail.ReadTail(path, lines)
 		if err != nil {
 			if os.IsNotExist(err) {
 				// File doesn't exist yet, that's okay
@@ -49,7 +48,7 @@ func startTailing(taskID, path string, ctx context.Context) tea.Cmd {
 
 		// Return existing content as first message
 		if len(content) > 0 {
-			return LogLineMsg{TaskID: taskID, Line: string(content)}
+			return LogLineMsg{TaskID: taskID, Line: content, Offset: size}
 		}
 
 		return LogLineMsg{TaskID: taskID, Line: "Log file empty, waiting..."}
@@ -57,49 +56,16 @@ func startTailing(taskID, path string, ctx context.Context) tea.Cmd {
 }
 
 // continueTailing returns a tea.Cmd that continues tailing after the initial read.
+// It polls the file until it grows past offset and returns only the new bytes.
 func continueTailing(taskID, path string, ctx context.Context, offset int64) tea.Cmd {
 	return func() tea.Msg {
-		// Check context
-		select {
-		case <-ctx.Done():
-			return TailerStoppedMsg{TaskID: taskID, Error: nil}
-		default:
-		}
-
-		// Open file
-		file, err := os.Open(path)
+		content, next, err := logtail.Next(ctx, path, offset)
 		if err != nil {
-			time.Sleep(100 * time.Millisecond)
-			return continueTailing(taskID, path, ctx, offset)()
-		}
-		defer file.Close()
-
-		// Get current size
-		info, _ := file.Stat()
-		currentSize := info.Size()
-
-		// If file has grown, read new content
-		if currentSize > offset {
-			file.Seek(offset, io.SeekStart)
-			newContent := make([]byte, currentSize-offset)
-			n, err := file.Read(newContent)
-			if err != nil && err != io.EOF {
-				return TailerStoppedMsg{TaskID: taskID, Error: err}
+			if ctx.Err() != nil {
+				return TailerStoppedMsg{TaskID: taskID, Error: nil}
 			}
-			if n > 0 {
-				return LogLineMsg{TaskID: taskID, Line: string(newContent[:n])}
-			}
-		}
-
-		// Wait before checking again
-		time.Sleep(50 * time.Millisecond)
-
-		// Continue tailing
-		select {
-		case <-ctx.Done():
-			return TailerStoppedMsg{TaskID: taskID, Error: nil}
-		default:
-			return continueTailing(taskID, path, ctx, currentSize)()
+			return TailerStoppedMsg{TaskID: taskID, Error: err}
 		}
+		return LogLineMsg{TaskID: taskID, Line: content, Offset: next}
 	}
 }
@@ -0,0 +1,54 @@
+package tui
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTailerStreamsOnlyNewContent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "task-1.log")
+
+	var existing strings.Builder
+	for i := 0; i < 1000; i++ {
+		existing.WriteString("old line\n")
+	}
+	if err := os.WriteFile(path, []byte(existing.String()), 0644); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	preload, ok := startTailing("task-1", path, ctx, 10)().(LogLineMsg)
+	if !ok {
+		t.Fatal("expected a LogLineMsg from the preload")
+	}
+	if got := strings.Count(preload.Line, "\n"); got != 10 {
+		t.Errorf("expected 10 preloaded lines, got %d", got)
+	}
+	if preload.Offset != int64(existing.Len()) {
+		t.Errorf("expected offset %d after preload, got %d", existing.Len(), preload.Offset)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("failed to open log: %v", err)
+	}
+	f.WriteString("new line\n")
+	f.Close()
+
+	next, ok := continueTailing("task-1", path, ctx, preload.Offset)().(LogLineMsg)
+	if !ok {
+		t.Fatal("expected a LogLineMsg for the appended line")
+	}
+	if next.Line != "new line\n" {
+		t.Errorf("expected only the new line to stream, got %q", next.Line)
+	}
+	if next.Offset != preload.Offset+int64(len("new line\n")) {
+		t.Errorf("unexpected offset after streaming: %d", next.Offset)
+	}
+}
@@ -2,36 +2,62 @@ package tui
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/tuanbt/hive/cmd/hive/tui/files"
 	"github.com/tuanbt/hive/cmd/hive/tui/shell"
+	"github.com/tuanbt/hive/internal/config"
 	"github.com/tuanbt/hive/internal/task"
 )
 
 const HELP_TEXT = `
 HIVE Commands:
   i          - Enter insert mode
-  j/k        - Navigate tasks
+  j/k        - Navigate tasks, or scroll the logs when focused
+  tab        - Cycle focus between the task list and logs (shift+tab back)
+  g/G        - Jump to the top/bottom of the logs when focused
   d          - Delete selected task
   r          - Retry selected task
+  c          - Clone selected task as a new pending task
+  t          - Move selected task to top of queue
+  K          - Move selected task up one place (t and K need dispatch_order priority)
+  a          - Toggle ANSI escape stripping in logs
+  y          - Copy selected task's log to the clipboard
+  b          - Show selected task's git branch (git integration only, esc closes)
   @file      - Reference file
   !command   - Execute shell command
   /command   - Execute slash command
+  /filter x  - Only show log lines containing x (/filter clear to reset)
+  /search x  - Only list tasks whose title or description contains x (/search clear to reset)
+  /logs id   - Jump to the task whose ID ends with id
+  /role x    - Route the selected task to role x
+  /priority n - Set the selected task's priority to n
+  /workers   - Show what each worker is doing (esc closes)
+  /layout x  - Arrange the worker panel: auto, rows, columns or grid
+  /watch-retry - Restart file watchers after falling back to polling
+  e          - Jump to the task of the latest orchestrator alert
+  /alerts clear - Dismiss the orchestrator alerts
+  A/X        - Accept or discard the agent plan waiting for review
+  up/down    - Recall previous inputs (insert mode)
   esc        - Exit insert mode
   q/ctrl+c   - Quit
 `
 
 func (m Model) Init() tea.Cmd {
+	ApplyTheme(m.Theme)
 	return tea.Batch(
 		textinput.Blink,
-		startWatchers(m.TasksFile, m.LogDir),
+		startWatchers(m.watchConfig()),
 		fallbackTick(),
 	)
 }
@@ -56,15 +82,54 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.updateLayout()
 		return m, nil
 	case TasksUpdatedMsg:
-		m.TaskList.SetItems(m.LoadTasks())
+		if m.Quitting {
+			return m, nil
+		}
+		m.setTasks(m.LoadTasks())
 		m.updateLayout()
-		cmds = append(cmds, watchTasksFile(WatchConfig{
-			TasksFile: m.TasksFile,
-			LogDir:    m.LogDir,
-		}))
+		cmds = append(cmds, watchTasksFile(m.watchConfig()))
 		return m, tea.Batch(cmds...)
 	case LogLineMsg:
 		return m.handleLogLine(msg)
+	case WatcherErrorMsg:
+		m.setError(msg.Error)
+		m.FallbackPolling = true
+		if !slices.Contains(m.DownWatchers, msg.Watcher) {
+			m.DownWatchers = append(m.DownWatchers, msg.Watcher)
+		}
+		return m, nil
+	case WatchRetryMsg:
+		if m.Quitting {
+			return m, nil
+		}
+		if msg.Error != nil {
+			m.setError(fmt.Errorf("watchers still unavailable: %w", msg.Error))
+			return m, nil
+		}
+		cfg := m.watchConfig()
+		for _, name := range msg.Watchers {
+			cmds = append(cmds, watchCmd(name, cfg))
+		}
+		m.FallbackPolling = false
+		m.DownWatchers = nil
+		m.showToast("watchers restored")
+		return m, tea.Batch(cmds...)
+	case TailerStoppedMsg:
+		m.setError(msg.Error)
+		return m, nil
+	case OrchestratorStoppedMsg:
+		m.setError(fmt.Errorf("orchestrator stopped, running as a client: %w", msg.Error))
+		m.Orchestrator = nil
+		m.ShowWorkers = false
+		return m, nil
+	case InspectResultMsg:
+		if msg.Err != nil {
+			m.setError(fmt.Errorf("inspect %s: %w", msg.Branch, msg.Err))
+		}
+		if msg.TaskID == m.SelectedTaskID {
+			m.Inspect = &msg
+		}
+		return m, nil
 	case tickMsg:
 		return m.handleTick()
 	}
@@ -81,17 +146,31 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// quit stops the log tailer and the file watchers and exits the program.
+func (m Model) quit() (tea.Model, tea.Cmd) {
+	m.Quitting = true
+	if m.TailerCancel != nil {
+		m.TailerCancel()
+	}
+	if m.WatchCancel != nil {
+		m.WatchCancel()
+	}
+	return m, tea.Quit
+}
+
 // handleKey - simplified key handling
 func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	// Global quit
 	if msg.String() == "ctrl+c" || msg.String() == "q" {
-		if m.TailerCancel != nil {
-			m.TailerCancel()
-		}
-		return m, tea.Quit
+		return m.quit()
 	}
 
-	// Mode switching
+	// Any keypress dismisses the error line
+	if m.Err != nil {
+		m.clearError()
+	}
+
+	// Mode switching (insert mode only adds tasks or runs commands)
 	if msg.String() == "i" && m.Mode == ModeSelection {
 		m.Mode = ModeInsert
 		m.Input.Focus()
@@ -117,28 +196,98 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 func (m Model) handleSelectionKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	prevSelected := m.SelectedTaskID
 
+	if m.ReadOnly && isMutatingKey(msg.String()) {
+		return m, nil
+	}
+	if m.FocusedPane == PaneLogs && m.scrollLogs(msg.String()) {
+		return m, nil
+	}
+
 	switch msg.String() {
+	case "tab":
+		m.FocusedPane = (m.FocusedPane + 1) % paneCount
+	case "shift+tab":
+		m.FocusedPane = (m.FocusedPane + paneCount - 1) % paneCount
 	case "j", "down":
 		m.TaskList.CursorDown()
 	case "k", "up":
 		m.TaskList.CursorUp()
 	case "d":
 		if m.SelectedTaskID != "" {
-			m.DeleteTask(m.SelectedTaskID)
+			m.setError(m.DeleteTask(m.SelectedTaskID))
 		}
 	case "r":
 		if m.SelectedTaskID != "" {
-			m.RetryTask(m.SelectedTaskID)
+			m.setError(m.RetryTask(m.SelectedTaskID))
+		}
+	case "c":
+		if m.SelectedTaskID != "" {
+			id, err := m.CloneTask(m.SelectedTaskID)
+			if err != nil {
+				m.setError(err)
+				break
+			}
+			m.SelectedTaskID = id
+			m.setTasks(m.LoadTasks())
+			m.showToast("cloned as " + id)
+			return m, m.startLogTailer(id)
+		}
+	case "t":
+		if m.SelectedTaskID != "" && m.reorderAllowed() {
+			m.setError(m.MoveTaskToTop(m.SelectedTaskID))
+			m.TaskList.SetItems(m.LoadTasks())
+			m.TaskList.Select(0)
+		}
+	case "K":
+		if m.SelectedTaskID != "" && m.TaskList.Index() > 0 && m.reorderAllowed() {
+			idx := m.TaskList.Index()
+			m.setError(m.MoveTaskUp(m.SelectedTaskID))
+			m.TaskList.SetItems(m.LoadTasks())
+			m.TaskList.Select(idx - 1)
 		}
 	case "ctrl+r":
-		items := m.LoadTasks()
-		m.TaskList.SetItems(items)
+		m.setTasks(m.LoadTasks())
+	case "y":
+		if m.SelectedTaskID != "" {
+			path, err := m.CopyLogs(m.SelectedTaskID)
+			switch {
+			case errors.Is(err, ErrNoLogs):
+				m.showToast("nothing to copy")
+			case err != nil:
+				m.setError(err)
+			case path != "":
+				m.showToast("clipboard unavailable, saved to " + path)
+			default:
+				m.showToast("copied")
+			}
+		}
+	case "a":
+		m.StripANSI = !m.StripANSI
+		m.refreshLogs()
+	case "b":
+		if m.Git.Enabled && m.SelectedTaskID != "" {
+			// InspectBranch updates m, so call it before m is returned
+			cmd := m.InspectBranch(m.SelectedTaskID)
+			return m, cmd
+		}
+	case "e":
+		if id := m.latestAlertTask(); id != "" {
+			return m.jumpToLogs(id)
+		}
+	case "A":
+		m.acceptPlan()
+	case "X":
+		m.discardPlan()
+	case "esc":
+		m.Inspect = nil
+		m.ShowWorkers = false
 	}
 
 	// Check selection change
 	if item, ok := m.TaskList.SelectedItem().(TaskItem); ok {
 		m.SelectedTaskID = item.ID
 		if m.SelectedTaskID != prevSelected {
+			m.Inspect = nil
 			return m, m.startLogTailer(m.SelectedTaskID)
 		}
 	}
@@ -146,6 +295,47 @@ func (m Model) handleSelectionKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// scrollLogs scrolls the log pane for a navigation key, reporting whether
+// the key was one.
+func (m *Model) scrollLogs(key string) bool {
+	switch key {
+	case "j", "down":
+		m.LogView.ScrollDown(1)
+	case "k", "up":
+		m.LogView.ScrollUp(1)
+	case "pgdown":
+		m.LogView.PageDown()
+	case "pgup":
+		m.LogView.PageUp()
+	case "g", "home":
+		m.LogView.GotoTop()
+	case "G", "end":
+		m.LogView.GotoBottom()
+	default:
+		return false
+	}
+	return true
+}
+
+// isMutatingKey reports whether a selection-mode key modifies the registry.
+func isMutatingKey(key string) bool {
+	switch key {
+	case "d", "r", "c", "t", "K", "A", "X":
+		return true
+	}
+	return false
+}
+
+// isViewCommand reports whether a slash command only changes what the TUI
+// shows, so it stays available in read-only mode.
+func isViewCommand(name string) bool {
+	switch name {
+	case "/quit", "/exit", "/help", "/?", "/filter", "/search", "/logs", "/workers", "/layout", "/alerts", "/watch-retry":
+		return true
+	}
+	return false
+}
+
 // handleInsertKey - simplified input handling
 func (m Model) handleInsertKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
@@ -175,6 +365,24 @@ func (m Model) handleInsertKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 	}
 
+	// History recall
+	if m.History != nil {
+		switch msg.String() {
+		case "up":
+			if entry, ok := m.History.Prev(m.Input.Value()); ok {
+				m.Input.SetValue(entry)
+				m.Input.CursorEnd()
+			}
+			return m, nil
+		case "down":
+			if entry, ok := m.History.Next(); ok {
+				m.Input.SetValue(entry)
+				m.Input.CursorEnd()
+			}
+			return m, nil
+		}
+	}
+
 	// Trigger suggestions
 	if msg.String() == "@" {
 		m.SuggestionActive = true
@@ -190,7 +398,7 @@ func (m Model) handleInsertKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.SuggestionActive = true
 		m.SuggestionType = "/"
 		m.SuggestionStart = 0
-		m.Suggestions = []string{"/help", "/quit", "/retry", "/nuke"}
+		m.Suggestions = []string{"/help", "/quit", "/retry", "/role", "/priority", "/nuke", "/filter", "/search", "/logs", "/workers", "/layout", "/watch-retry", "/alerts clear"}
 		m.SuggestionIdx = 0
 		return m, nil
 	}
@@ -247,6 +455,17 @@ func (m Model) handleSubmit() (tea.Model, tea.Cmd) {
 	if val == "" {
 		return m, nil
 	}
+	if m.ReadOnly {
+		fields := strings.Fields(val)
+		if len(fields) == 0 || !isViewCommand(fields[0]) {
+			m.setError(fmt.Errorf("read-only mode: only /help, /filter, /search, /logs, /workers, /layout and /alerts are available"))
+			m.Input.SetValue("")
+			return m, nil
+		}
+	}
+	if m.History != nil {
+		m.setError(m.History.Add(val))
+	}
 
 	// Slash commands
 	if strings.HasPrefix(val, "/") {
@@ -264,7 +483,10 @@ func (m Model) handleSubmit() (tea.Model, tea.Cmd) {
 	}
 
 	// Add task
-	m.addTask(val)
+	if err := m.addTask(val); err != nil {
+		m.setError(err)
+		return m, nil
+	}
 	m.Input.SetValue("")
 	return m, nil
 }
@@ -278,18 +500,108 @@ func (m Model) executeSlashCommand(val string) (tea.Model, tea.Cmd) {
 
 	switch parts[0] {
 	case "/quit", "/exit":
-		return m, tea.Quit
+		return m.quit()
 	case "/help", "/?":
 		m.Err = fmt.Errorf(HELP_TEXT)
 		m.Input.SetValue("")
 	case "/retry":
 		if m.SelectedTaskID != "" {
-			m.RetryTask(m.SelectedTaskID)
+			m.setError(m.RetryTask(m.SelectedTaskID))
 		}
 		m.Input.SetValue("")
+	case "/role":
+		m.Input.SetValue("")
+		if len(parts) < 2 {
+			m.setError(fmt.Errorf("usage: /role <role>"))
+			return m, nil
+		}
+		if m.SelectedTaskID == "" {
+			m.setError(fmt.Errorf("no task selected"))
+			return m, nil
+		}
+		if err := m.SetTaskRole(m.SelectedTaskID, parts[1]); err != nil {
+			m.setError(err)
+			return m, nil
+		}
+		m.showToast("role: " + parts[1])
+	case "/priority":
+		m.Input.SetValue("")
+		if len(parts) < 2 {
+			m.setError(fmt.Errorf("usage: /priority <n>"))
+			return m, nil
+		}
+		priority, err := strconv.Atoi(parts[1])
+		if err != nil {
+			m.setError(fmt.Errorf("usage: /priority <n>"))
+			return m, nil
+		}
+		if m.SelectedTaskID == "" {
+			m.setError(fmt.Errorf("no task selected"))
+			return m, nil
+		}
+		if err := m.SetTaskPriority(m.SelectedTaskID, priority); err != nil {
+			m.setError(err)
+			return m, nil
+		}
+		m.showToast(fmt.Sprintf("priority: %d", priority))
 	case "/nuke":
-		m.Nuke()
+		m.setError(m.Nuke())
 		m.Input.SetValue("")
+	case "/filter":
+		expr := strings.TrimSpace(strings.TrimPrefix(val, parts[0]))
+		if expr == "clear" {
+			expr = ""
+		}
+		m.LogFilter = expr
+		m.refreshLogs()
+		m.Input.SetValue("")
+	case "/search":
+		query := strings.TrimSpace(strings.TrimPrefix(val, parts[0]))
+		if query == "clear" {
+			query = ""
+		}
+		m.TaskSearch = query
+		m.setTasks(m.LoadTasks())
+		m.Input.SetValue("")
+	case "/logs":
+		if len(parts) < 2 {
+			m.setError(fmt.Errorf("usage: /logs <id-suffix>"))
+			return m, nil
+		}
+		return m.jumpToLogs(parts[1])
+	case "/workers":
+		if m.Orchestrator == nil {
+			m.setError(fmt.Errorf("worker status is only available in the TUI running the orchestrator"))
+			return m, nil
+		}
+		m.ShowWorkers = true
+		m.Workers = m.Orchestrator.WorkerStatus()
+		m.Input.SetValue("")
+		m.Mode = ModeSelection
+		m.Input.Blur()
+	case "/layout":
+		m.Input.SetValue("")
+		if len(parts) < 2 || !config.ValidLayout(parts[1]) {
+			m.setError(fmt.Errorf("usage: /layout auto|rows|columns|grid"))
+			return m, nil
+		}
+		m.WorkerLayout = parts[1]
+		m.showToast("worker layout: " + parts[1])
+	case "/alerts":
+		m.Input.SetValue("")
+		if len(parts) < 2 || parts[1] != "clear" {
+			m.setError(fmt.Errorf("usage: /alerts clear"))
+			return m, nil
+		}
+		m.Alerts = nil
+		m.updateLayout()
+	case "/watch-retry":
+		m.Input.SetValue("")
+		if !m.FallbackPolling {
+			m.showToast("watchers are running")
+			return m, nil
+		}
+		return m, retryWatchers(m.watchConfig(), m.DownWatchers)
 	default:
 		m.Input.SetValue("")
 	}
@@ -297,23 +609,70 @@ func (m Model) executeSlashCommand(val string) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-// addTask - smart task creation
-func (m *Model) addTask(title string) {
-	id := fmt.Sprintf("task-%d", time.Now().UnixNano())
-	t := task.NewTask(id, title, title)
+// jumpToLogs selects the task whose ID ends with suffix and shows its log.
+// Several matches open a picker listing the full IDs instead.
+func (m Model) jumpToLogs(suffix string) (tea.Model, tea.Cmd) {
+	var matches []string
+	for _, item := range m.TaskList.Items() {
+		if ti, ok := item.(TaskItem); ok && strings.HasSuffix(ti.ID, suffix) {
+			matches = append(matches, ti.ID)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		m.setError(fmt.Errorf("no task ID ends with %q", suffix))
+		return m, nil
+	case 1:
+	default:
+		m.SuggestionActive = true
+		m.SuggestionType = "/"
+		m.SuggestionStart = 0
+		m.Suggestions = make([]string, len(matches))
+		for i, id := range matches {
+			m.Suggestions[i] = "/logs " + id
+		}
+		m.SuggestionIdx = 0
+		return m, nil
+	}
+
+	for i, item := range m.TaskList.Items() {
+		if ti, ok := item.(TaskItem); ok && ti.ID == matches[0] {
+			m.TaskList.Select(i)
+			break
+		}
+	}
+	m.SelectedTaskID = matches[0]
+	m.Input.SetValue("")
+	m.Mode = ModeSelection
+	m.Input.Blur()
+	m.setLogContent("")
+	return m, m.startLogTailer(m.SelectedTaskID)
+}
+
+// addTask - smart task creation, @file mentions are expanded into the description
+func (m *Model) addTask(title string) error {
+	desc, err := task.ExpandFileReferences(title, m.WorkDirectory)
+	if err != nil {
+		return err
+	}
+	t := task.NewTask("", title, desc)
 
 	// Smart role detection
 	lowerTitle := strings.ToLower(title)
-	if strings.HasPrefix(lowerTitle, "i want") ||
-		strings.HasPrefix(lowerTitle, "build") ||
-		strings.HasPrefix(lowerTitle, "create") ||
-		strings.HasPrefix(lowerTitle, "plan") {
-		t.Role = "ba"
+	for _, trigger := range m.PlanningTriggers {
+		if trigger != "" && strings.HasPrefix(lowerTitle, strings.ToLower(trigger)) {
+			t.Role = m.PlanningRole
+			break
+		}
 	}
+	t.ApplyRolePriority(m.RolePriorities)
 
-	m.TaskManager.AddTask(t)
-	items := m.LoadTasks()
-	m.TaskList.SetItems(items)
+	if err := m.TaskManager.Create(t); err != nil {
+		return err
+	}
+	m.setTasks(m.LoadTasks())
+	return nil
 }
 
 // applySuggestion - insert selected suggestion
@@ -339,23 +698,137 @@ func (m *Model) applySuggestion() {
 // handleLogLine - simplified log handling
 func (m Model) handleLogLine(msg LogLineMsg) (tea.Model, tea.Cmd) {
 	if msg.TaskID == m.SelectedTaskID {
-		current := m.LogView.View()
-		m.LogView.SetContent(current + msg.Line)
-		m.LogView.GotoBottom()
+		line := msg.Line
+		if m.StripANSI {
+			line = StripANSI(line)
+		}
+		m.setLogContent(m.LogContent + m.filterLogChunk(line))
+
+		// Resume exactly where this chunk ended
+		if m.LogOffsets == nil {
+			m.LogOffsets = make(map[string]int64)
+		}
+		m.LogOffsets[msg.TaskID] = msg.Offset
+		if m.TailerCtx != nil {
+			logPath := filepath.Join(m.LogDir, fmt.Sprintf("%s.log", msg.TaskID))
+			return m, continueTailing(msg.TaskID, logPath, m.TailerCtx, msg.Offset)
+		}
 	}
 	return m, nil
 }
 
+// setTasks replaces the list items while keeping the cursor on the selected
+// task, so the log pane doesn't jump to another task when the order changes
+func (m *Model) setTasks(items []list.Item) {
+	m.TaskList.SetItems(items)
+	if m.SelectedTaskID == "" {
+		return
+	}
+	for i, item := range items {
+		if ti, ok := item.(TaskItem); ok && ti.ID == m.SelectedTaskID {
+			m.TaskList.Select(i)
+			return
+		}
+	}
+}
+
+// refreshLogs reloads the selected task's log with the current filter applied
+func (m *Model) refreshLogs() {
+	if m.SelectedTaskID == "" {
+		return
+	}
+	m.LogPartial = ""
+	m.setLogContent(m.filterLogChunk(m.ReadLogTail(m.SelectedTaskID)))
+	m.LogView.GotoBottom()
+}
+
+// filterLogChunk applies the log filter to the complete lines of a chunk of
+// log output. A trailing partial line is held back in LogPartial and judged
+// once the rest of it arrives with a later chunk.
+func (m *Model) filterLogChunk(chunk string) string {
+	if m.LogFilter == "" {
+		return chunk
+	}
+	data := m.LogPartial + chunk
+	end := strings.LastIndex(data, "\n") + 1
+	m.LogPartial = data[end:]
+	if end == 0 {
+		return ""
+	}
+	kept := FilterLogs(data[:end-1], m.LogFilter)
+	if kept == "" {
+		return ""
+	}
+	return kept + "\n"
+}
+
+// setLogContent replaces the log pane content, following new output unless
+// the logs were scrolled up to read earlier lines.
+func (m *Model) setLogContent(content string) {
+	follow := m.LogView.AtBottom()
+	m.LogContent = content
+	m.LogView.SetContent(content)
+	if follow {
+		m.LogView.GotoBottom()
+	}
+}
+
+// setError records err as the latest error shown in the footer. Errors
+// accumulate into a count until the next keypress dismisses them.
+func (m *Model) setError(err error) {
+	if err == nil {
+		return
+	}
+	m.Err = err
+	m.ErrTime = time.Now()
+	m.ErrCount++
+}
+
+// clearError dismisses the error shown in the footer
+func (m *Model) clearError() {
+	m.Err = nil
+	m.ErrCount = 0
+}
+
+// showToast displays a short-lived message in the footer
+func (m *Model) showToast(msg string) {
+	m.Toast = msg
+	m.ToastExpiry = time.Now().Add(3 * time.Second)
+}
+
+// reorderAllowed reports whether moving a task in the file changes when it
+// is dispatched, which only holds under the priority order; fifo and lifo
+// go by creation time alone. A toast explains why nothing moves otherwise.
+func (m *Model) reorderAllowed() bool {
+	if m.DispatchOrder == "" || m.DispatchOrder == task.DispatchPriority {
+		return true
+	}
+	m.showToast(fmt.Sprintf("dispatch order is %s, tasks are picked by creation time", m.DispatchOrder))
+	return false
+}
+
 // handleTick - simplified polling
 func (m Model) handleTick() (tea.Model, tea.Cmd) {
-	items := m.LoadTasks()
-	m.TaskList.SetItems(items)
+	if m.Toast != "" && time.Now().After(m.ToastExpiry) {
+		m.Toast = ""
+	}
+
+	m.setTasks(m.LoadTasks())
+	m.refreshPlans()
+	shown := len(m.Alerts)
+	m.pollAlerts()
+	if len(m.Alerts) != shown {
+		m.updateLayout()
+	}
+	if m.ShowWorkers && m.Orchestrator != nil {
+		m.Workers = m.Orchestrator.WorkerStatus()
+	}
 
 	if m.SelectedTaskID != "" {
-		logs := m.ReadLogs(m.SelectedTaskID)
-		if logs != m.LogView.View() {
-			m.LogView.SetContent(logs)
-			m.LogView.GotoBottom()
+		m.LogPartial = ""
+		logs := m.filterLogChunk(m.ReadLogTail(m.SelectedTaskID))
+		if logs != m.LogContent {
+			m.setLogContent(logs)
 		}
 	}
 
@@ -379,12 +852,14 @@ func (m *Model) startLogTailer(taskID string) tea.Cmd {
 		m.LogOffsets = make(map[string]int64)
 	}
 	m.LogOffsets[taskID] = 0
+	m.LogPartial = ""
 
 	logPath := filepath.Join(m.LogDir, fmt.Sprintf("%s.log", taskID))
 
-	// Check if file exists and get initial content
+	// Check if file exists and get initial content, the offset is set
+	// from the preload message once it arrives
 	if _, err := os.Stat(logPath); err == nil {
-		return startTailing(taskID, logPath, ctx)
+		return startTailing(taskID, logPath, ctx, m.LogPreloadLines)
 	}
 
 	// File doesn't exist yet
@@ -399,7 +874,7 @@ func (m *Model) updateLayout() {
 		return
 	}
 
-	footerHeight := 3
+	footerHeight := 3 + len(m.Alerts)
 	contentHeight := m.Height - footerHeight
 
 	// Task list: 30% width
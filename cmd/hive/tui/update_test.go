@@ -0,0 +1,286 @@
+package tui
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/tuanbt/hive/internal/config"
+	"github.com/tuanbt/hive/internal/task"
+)
+
+// press sends a key to the model in selection mode.
+func press(t *testing.T, m Model, key tea.KeyMsg) Model {
+	t.Helper()
+	updated, _ := m.handleSelectionKey(key)
+	return updated.(Model)
+}
+
+func TestFocusScrollsLogs(t *testing.T) {
+	m := Model{
+		TaskList: list.New(nil, list.NewDefaultDelegate(), 0, 0),
+		LogView:  viewport.New(40, 3),
+	}
+	var lines []string
+	for i := 0; i < 10; i++ {
+		lines = append(lines, "line")
+	}
+	m.setLogContent(strings.Join(lines, "\n"))
+	if !m.LogView.AtBottom() {
+		t.Fatal("expected new logs to be followed")
+	}
+
+	// Scroll keys only reach the logs once they have the focus
+	up := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("k")}
+	m = press(t, m, up)
+	if !m.LogView.AtBottom() {
+		t.Fatal("expected k to leave the logs alone while the task list is focused")
+	}
+
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyTab})
+	if m.FocusedPane != PaneLogs {
+		t.Fatalf("expected tab to focus the logs, got %v", m.FocusedPane)
+	}
+	m = press(t, m, up)
+	if m.LogView.AtBottom() {
+		t.Fatal("expected k to scroll the focused logs up")
+	}
+
+	// Scrolled up logs are not pulled back down by new output
+	offset := m.LogView.YOffset
+	m.setLogContent(m.LogContent + "\nmore")
+	if m.LogView.YOffset != offset {
+		t.Errorf("expected the scroll position to be kept, got offset %d, want %d", m.LogView.YOffset, offset)
+	}
+
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("G")})
+	if !m.LogView.AtBottom() {
+		t.Error("expected G to jump to the end of the logs")
+	}
+
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyShiftTab})
+	if m.FocusedPane != PaneTasks {
+		t.Errorf("expected shift+tab to focus the task list, got %v", m.FocusedPane)
+	}
+}
+
+func TestRoleAndPriorityCommands(t *testing.T) {
+	tm := task.NewManager(filepath.Join(t.TempDir(), "tasks.json"))
+	if err := tm.AddTask(task.NewTask("task-1", "Fix login", "")); err != nil {
+		t.Fatalf("failed to add task: %v", err)
+	}
+	m := Model{
+		TaskManager:    tm,
+		Roles:          []string{"ba", "dev"},
+		SelectedTaskID: "task-1",
+		Input:          textinput.New(),
+	}
+
+	run := func(cmd string) {
+		t.Helper()
+		m.Err = nil
+		updated, _ := m.executeSlashCommand(cmd)
+		m = updated.(Model)
+	}
+
+	run("/role dev")
+	run("/priority 7")
+	if m.Err != nil {
+		t.Fatalf("unexpected error: %v", m.Err)
+	}
+	got, err := tm.GetByID("task-1")
+	if err != nil {
+		t.Fatalf("failed to get task: %v", err)
+	}
+	if got.Role != "dev" || got.Priority != 7 {
+		t.Errorf("expected role dev and priority 7, got %q and %d", got.Role, got.Priority)
+	}
+
+	for _, cmd := range []string{"/role hacker", "/priority high", "/role"} {
+		run(cmd)
+		if m.Err == nil {
+			t.Errorf("%s: expected an error", cmd)
+		}
+	}
+	if got, _ := tm.GetByID("task-1"); got.Role != "dev" || got.Priority != 7 {
+		t.Errorf("expected rejected commands to leave the task alone, got %q and %d", got.Role, got.Priority)
+	}
+
+	// A task a worker may be running is not changed under it
+	if err := tm.UpdateStatus("task-1", task.StatusInProgress, ""); err != nil {
+		t.Fatalf("failed to update status: %v", err)
+	}
+	for _, cmd := range []string{"/role ba", "/priority 1"} {
+		run(cmd)
+		if m.Err == nil {
+			t.Errorf("%s: expected an error for an in-progress task", cmd)
+		}
+	}
+	if got, _ := tm.GetByID("task-1"); got.Role != "dev" || got.Priority != 7 {
+		t.Errorf("expected the in-progress task to be left alone, got %q and %d", got.Role, got.Priority)
+	}
+}
+
+func TestInspectBranchKey(t *testing.T) {
+	m := Model{
+		Git:            config.GitConfig{Enabled: true, BranchPrefix: "agent/task-"},
+		SelectedTaskID: "task-1",
+	}
+
+	// Without an inspect command the branch shows on the returned model
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("b")})
+	if m.Toast != "branch: agent/task-task-1" {
+		t.Errorf("expected the branch in a toast, got %q", m.Toast)
+	}
+}
+
+func TestCopyLogsWithoutLog(t *testing.T) {
+	dir := t.TempDir()
+	m := Model{LogDir: dir, SelectedTaskID: "task-1"}
+
+	check := func(label string) {
+		t.Helper()
+		if _, err := m.CopyLogs("task-1"); !errors.Is(err, ErrNoLogs) {
+			t.Errorf("%s: expected ErrNoLogs, got %v", label, err)
+		}
+		m = press(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+		if m.Toast != "nothing to copy" || m.Err != nil {
+			t.Errorf("%s: expected a nothing to copy toast, got %q and %v", label, m.Toast, m.Err)
+		}
+	}
+
+	check("missing log")
+	logPath := filepath.Join(dir, "task-1.log")
+	if err := os.WriteFile(logPath, nil, 0644); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+	check("empty log")
+	if err := os.Remove(logPath); err != nil {
+		t.Fatalf("failed to remove log: %v", err)
+	}
+
+	// No placeholder text was written out in place of the log
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("failed to read log dir: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected no log copy, found %d files", len(entries))
+	}
+}
+
+func TestReadOnlyAllowsViewCommands(t *testing.T) {
+	tm := task.NewManager(filepath.Join(t.TempDir(), "tasks.json"))
+	if err := tm.AddTask(task.NewTask("task-1", "Fix login", "")); err != nil {
+		t.Fatalf("failed to add task: %v", err)
+	}
+	m := Model{
+		TaskManager:    tm,
+		TaskList:       list.New(nil, list.NewDefaultDelegate(), 0, 0),
+		SelectedTaskID: "task-1",
+		Input:          textinput.New(),
+		ReadOnly:       true,
+	}
+
+	submit := func(val string) {
+		t.Helper()
+		m.Err = nil
+		m.Input.SetValue(val)
+		updated, _ := m.handleSubmit()
+		m = updated.(Model)
+	}
+
+	// Insert mode opens, since commands are typed there
+	updated, _ := m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("i")})
+	m = updated.(Model)
+	if m.Mode != ModeInsert {
+		t.Fatal("expected i to open the command line in read-only mode")
+	}
+
+	submit("/search login")
+	if m.Err != nil || m.TaskSearch != "login" {
+		t.Errorf("expected /search to work read-only, got search %q and error %v", m.TaskSearch, m.Err)
+	}
+
+	for _, val := range []string{"/priority 3", "/nuke", "New task", "!touch file"} {
+		submit(val)
+		if m.Err == nil {
+			t.Errorf("%s: expected an error in read-only mode", val)
+		}
+	}
+	tasks, err := tm.LoadAll()
+	if err != nil {
+		t.Fatalf("failed to load tasks: %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].Priority != 0 {
+		t.Errorf("expected read-only commands to leave the tasks alone, got %+v", tasks)
+	}
+}
+
+func TestFilteredLogChunks(t *testing.T) {
+	m := Model{
+		LogView:        viewport.New(40, 10),
+		SelectedTaskID: "task-1",
+		LogFilter:      "error",
+	}
+
+	// A match split across chunks, then two matching chunks in a row
+	for _, chunk := range []string{"ok\nfirst er", "ror here\n", "error two\n", "error three\nskip"} {
+		updated, _ := m.handleLogLine(LogLineMsg{TaskID: "task-1", Line: chunk})
+		m = updated.(Model)
+	}
+
+	hl := StyleHighlight.Render("error")
+	want := "first " + hl + " here\n" + hl + " two\n" + hl + " three\n"
+	if m.LogContent != want {
+		t.Errorf("LogContent = %q, want %q", m.LogContent, want)
+	}
+	if m.LogPartial != "skip" {
+		t.Errorf("expected the unfinished line to be held back, got %q", m.LogPartial)
+	}
+}
+
+func TestOrchestratorStoppedFallsBackToClient(t *testing.T) {
+	m := Model{ShowWorkers: true}
+	updated, _ := m.Update(OrchestratorStoppedMsg{Error: errors.New("address in use")})
+	m = updated.(Model)
+
+	if m.Orchestrator != nil || m.ShowWorkers {
+		t.Error("expected the stopped orchestrator to be dropped")
+	}
+	if m.Err == nil || !strings.Contains(m.Err.Error(), "address in use") {
+		t.Errorf("expected the failure to be shown, got %v", m.Err)
+	}
+}
+
+func TestReorderNeedsPriorityOrder(t *testing.T) {
+	tm := task.NewManager(filepath.Join(t.TempDir(), "tasks.json"))
+	for _, id := range []string{"task-1", "task-2"} {
+		if err := tm.AddTask(task.NewTask(id, id, "")); err != nil {
+			t.Fatalf("failed to add task: %v", err)
+		}
+	}
+	m := Model{
+		TaskManager:    tm,
+		DispatchOrder:  task.DispatchFIFO,
+		SelectedTaskID: "task-2",
+	}
+
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("t")})
+	if m.Toast == "" {
+		t.Error("expected a toast explaining why the task did not move")
+	}
+	tasks, err := tm.LoadAll()
+	if err != nil {
+		t.Fatalf("failed to load tasks: %v", err)
+	}
+	if tasks[0].ID != "task-1" {
+		t.Errorf("expected fifo order to leave the file alone, got %s first", tasks[0].ID)
+	}
+}
@@ -3,6 +3,7 @@ package tui
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 )
@@ -18,23 +19,33 @@ func (m Model) View() string {
 
 	mainContent := lipgloss.JoinHorizontal(lipgloss.Top, leftPane, rightPane)
 
-	// Footer with input and help
+	// Footer with input and help, below any orchestrator alerts
 	footer := m.renderFooter()
+	if len(m.Alerts) > 0 {
+		footer = lipgloss.JoinVertical(lipgloss.Left, m.renderAlerts(), footer)
+	}
 
 	return lipgloss.JoinVertical(lipgloss.Left, mainContent, footer)
 }
 
 func (m Model) renderTaskList() string {
-	header := StyleTitle.Render(" TASKS ")
+	title := "TASKS"
+	if m.TaskSearch != "" {
+		title += fmt.Sprintf(" [search: %s]", m.TaskSearch)
+	}
+	header := StyleTitle.Render(" " + title + " ")
 	content := m.TaskList.View()
 
 	border := StyleBorder
+	if m.FocusedPane == PaneTasks {
+		border = StyleBorderFocused
+	}
 	width := m.Width * 30 / 100
 	if width < 30 {
 		width = 30
 	}
 
-	return border.Width(width).Height(m.Height - 3).Render(
+	return border.Width(width).Height(m.Height - 3 - len(m.Alerts)).Render(
 		lipgloss.JoinVertical(lipgloss.Left, header, content),
 	)
 }
@@ -49,22 +60,70 @@ func (m Model) renderLogView() string {
 		}
 		title = fmt.Sprintf("LOGS: %s", shortID)
 	}
+	if m.LogFilter != "" {
+		title += fmt.Sprintf(" [filter: %s]", m.LogFilter)
+	}
 
-	header := StyleTitle.Render(" " + title + " ")
 	content := m.LogView.View()
+	if m.Inspect != nil {
+		title = fmt.Sprintf("BRANCH: %s", m.Inspect.Branch)
+		content = m.viewInspect()
+	}
+	if m.ShowWorkers {
+		title = "WORKERS"
+		content = m.viewWorkers()
+	}
+	if len(m.Plans) > 0 {
+		title = "PLAN REVIEW"
+		content = m.viewPlan()
+	}
+	header := StyleTitle.Render(" " + title + " ")
 
 	if content == "" {
 		content = StyleDimmed.Render("No task selected")
 	}
 
-	border := StyleBorderFocused
+	// Suggestions and pickers show over the log pane
+	if m.SuggestionActive {
+		content = m.viewSuggestions()
+	}
+
+	border := StyleBorder
+	if m.FocusedPane == PaneLogs {
+		border = StyleBorderFocused
+	}
 	width := m.Width * 70 / 100
 
-	return border.Width(width).Height(m.Height - 3).Render(
+	return border.Width(width).Height(m.Height - 3 - len(m.Alerts)).Render(
 		lipgloss.JoinVertical(lipgloss.Left, header, content),
 	)
 }
 
+// queueStatus reports the active and pending tasks with a rough ETA for
+// the backlog: the average completed run time for each pending task,
+// spread over the workers. Without completed tasks the ETA is unknown.
+func (m Model) queueStatus() string {
+	eta := "—"
+	if m.AvgTaskTime > 0 {
+		d := m.AvgTaskTime * time.Duration(m.PendingTasks) / time.Duration(max(m.NumWorkers, 1))
+		eta = "~" + formatETA(d)
+	}
+	return fmt.Sprintf("[%d active · %d pending · ETA %s]", m.ActiveTasks, m.PendingTasks, eta)
+}
+
+// formatETA renders d to the second under a minute and to the minute above.
+func formatETA(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Round(time.Second).Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Round(time.Minute).Minutes()))
+	default:
+		d = d.Round(time.Minute)
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+}
+
 func (m Model) renderFooter() string {
 	// Input line
 	prompt := ">"
@@ -76,11 +135,38 @@ func (m Model) renderFooter() string {
 	// Status/error line (if any)
 	var status string
 	if m.Err != nil {
-		status = StyleError.Render(fmt.Sprintf(" [ERROR: %s]", m.Err.Error()))
+		msg := m.Err.Error()
+		if m.ErrCount > 0 {
+			msg = fmt.Sprintf("%s ERROR: %s", m.ErrTime.Format("15:04:05"), msg)
+			if m.ErrCount > 1 {
+				msg += fmt.Sprintf(" (%d errors)", m.ErrCount)
+			}
+		}
+		status = StyleError.Render(fmt.Sprintf(" [%s]", msg))
+	} else if m.Toast != "" {
+		status = StyleStatus.Render(fmt.Sprintf("[%s]", m.Toast))
+	} else if m.ActiveTasks > 0 || m.PendingTasks > 0 {
+		status = StyleStatus.Render(m.queueStatus())
 	}
 
 	// Help line
-	help := StyleHelp.Render("i=insert j/k=nav d=del r=retry @=file !=shell /=cmd q=quit")
+	branchKey := ""
+	if m.Git.Enabled {
+		branchKey = " b=branch"
+	}
+	help := StyleHelp.Render("i=insert j/k=nav tab=focus d=del r=retry c=clone t/K=move a=ansi y=copy" + branchKey + " @=file !=shell /=cmd q=quit")
+	if m.ReadOnly {
+		help = StyleBadge.Render("READ-ONLY") + StyleHelp.Render("j/k=nav tab=focus a=ansi y=copy"+branchKey+" i=/cmd q=quit")
+	}
+	if m.FallbackPolling {
+		help = StyleError.Render("⚠ polling") + help
+	}
+	if m.latestAlertTask() != "" {
+		help += StyleHelp.Render(" e=alert")
+	}
+	if len(m.Plans) > 0 && !m.ReadOnly {
+		help += StyleHelp.Render(" A/X=plan")
+	}
 
 	// Combine input line
 	inputWithStatus := inputLine
@@ -145,3 +231,45 @@ func (m Model) viewSuggestions() string {
 		Background(ColorBg).
 		Render(lipgloss.JoinVertical(lipgloss.Left, items...))
 }
+
+// viewInspect renders the inspect command output, keeping the first lines
+// that fit in the log pane.
+func (m Model) viewInspect() string {
+	lines := strings.Split(strings.TrimRight(m.Inspect.Output, "\n"), "\n")
+	if limit := m.Height - 6; limit > 0 && len(lines) > limit {
+		lines = append(lines[:limit], StyleDimmed.Render(fmt.Sprintf("... %d more lines", len(lines)-limit)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// viewWorkers renders the workers with their task and elapsed time,
+// arranged by WorkerLayout.
+func (m Model) viewWorkers() string {
+	if len(m.Workers) == 0 {
+		return StyleDimmed.Render("No workers running")
+	}
+
+	busy := 0
+	for _, w := range m.Workers {
+		if w.Busy() {
+			busy++
+		}
+	}
+	summary := fmt.Sprintf("%d/%d busy", busy, len(m.Workers))
+
+	if cols := workerColumns(m.WorkerLayout, len(m.Workers)); cols > 1 {
+		return layoutWorkers(m.Workers, cols, m.Width*70/100-4) + "\n\n" + summary
+	}
+
+	var lines []string
+	for _, w := range m.Workers {
+		if !w.Busy() {
+			lines = append(lines, StyleDimmed.Render(fmt.Sprintf("worker %-3d idle", w.ID)))
+			continue
+		}
+		elapsed := time.Since(w.StartedAt).Round(time.Second)
+		lines = append(lines, fmt.Sprintf("worker %-3d busy  %-10s %s", w.ID, elapsed, w.TaskID))
+	}
+	lines = append(lines, "", summary)
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,23 @@
+package tui
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQueueStatus(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		m    Model
+		want string
+	}{
+		{"no history", Model{ActiveTasks: 1, PendingTasks: 4, NumWorkers: 2}, "[1 active · 4 pending · ETA —]"},
+		{"spread over workers", Model{ActiveTasks: 2, PendingTasks: 6, NumWorkers: 2, AvgTaskTime: 10 * time.Minute}, "[2 active · 6 pending · ETA ~30m]"},
+		{"no workers counted", Model{PendingTasks: 3, AvgTaskTime: 15 * time.Second}, "[0 active · 3 pending · ETA ~45s]"},
+		{"hours", Model{PendingTasks: 5, NumWorkers: 1, AvgTaskTime: 25 * time.Minute}, "[0 active · 5 pending · ETA ~2h05m]"},
+	} {
+		if got := tc.m.queueStatus(); got != tc.want {
+			t.Errorf("%s: queueStatus() = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package tui
 
 import (
+	"context"
+	"fmt"
 	"path/filepath"
 	"strings"
 	"time"
@@ -9,10 +11,27 @@ import (
 	"github.com/fsnotify/fsnotify"
 )
 
+// Names of the file watchers, reported in WatcherErrorMsg.
+const (
+	WatcherTasks = "tasks"
+	WatcherLogs  = "logs"
+)
+
 // WatchConfig holds configuration for the file watcher.
 type WatchConfig struct {
 	TasksFile string
 	LogDir    string
+
+	// Ctx stops the watchers when cancelled; nil never stops them
+	Ctx context.Context
+}
+
+// done returns the channel closed when the watchers must stop.
+func (c WatchConfig) done() <-chan struct{} {
+	if c.Ctx == nil {
+		return nil
+	}
+	return c.Ctx.Done()
 }
 
 // watchTasksFile returns a tea.Cmd that watches the tasks.json file for changes.
@@ -22,21 +41,24 @@ func watchTasksFile(cfg WatchConfig) tea.Cmd {
 	return func() tea.Msg {
 		watcher, err := fsnotify.NewWatcher()
 		if err != nil {
-			return WatcherErrorMsg{Error: err}
+			return WatcherErrorMsg{Watcher: WatcherTasks, Error: err}
 		}
 		defer watcher.Close()
 
 		// Watch the tasks file
 		if err := watcher.Add(cfg.TasksFile); err != nil {
-			return WatcherErrorMsg{Error: err}
+			return WatcherErrorMsg{Watcher: WatcherTasks, Error: err}
 		}
 
-		// Wait for an event
+		// Wait for an event, or stop without one once cancelled
+		done := cfg.done()
 		for {
 			select {
+			case <-done:
+				return nil
 			case event, ok := <-watcher.Events:
 				if !ok {
-					return WatcherErrorMsg{Error: nil}
+					return WatcherErrorMsg{Watcher: WatcherTasks, Error: nil}
 				}
 				// Check for write or create events
 				if event.Op&fsnotify.Write == fsnotify.Write ||
@@ -47,9 +69,9 @@ func watchTasksFile(cfg WatchConfig) tea.Cmd {
 				}
 			case err, ok := <-watcher.Errors:
 				if !ok {
-					return WatcherErrorMsg{Error: nil}
+					return WatcherErrorMsg{Watcher: WatcherTasks, Error: nil}
 				}
-				return WatcherErrorMsg{Error: err}
+				return WatcherErrorMsg{Watcher: WatcherTasks, Error: err}
 			}
 		}
 	}
@@ -61,21 +83,24 @@ func watchLogDirectory(cfg WatchConfig) tea.Cmd {
 	return func() tea.Msg {
 		watcher, err := fsnotify.NewWatcher()
 		if err != nil {
-			return WatcherErrorMsg{Error: err}
+			return WatcherErrorMsg{Watcher: WatcherLogs, Error: err}
 		}
 		defer watcher.Close()
 
 		// Watch the log directory
 		if err := watcher.Add(cfg.LogDir); err != nil {
-			return WatcherErrorMsg{Error: err}
+			return WatcherErrorMsg{Watcher: WatcherLogs, Error: err}
 		}
 
-		// Wait for an event
+		// Wait for an event, or stop without one once cancelled
+		done := cfg.done()
 		for {
 			select {
+			case <-done:
+				return nil
 			case event, ok := <-watcher.Events:
 				if !ok {
-					return WatcherErrorMsg{Error: nil}
+					return WatcherErrorMsg{Watcher: WatcherLogs, Error: nil}
 				}
 				// Check for new log files
 				if event.Op&fsnotify.Create == fsnotify.Create ||
@@ -92,22 +117,53 @@ func watchLogDirectory(cfg WatchConfig) tea.Cmd {
 				}
 			case err, ok := <-watcher.Errors:
 				if !ok {
-					return WatcherErrorMsg{Error: nil}
+					return WatcherErrorMsg{Watcher: WatcherLogs, Error: nil}
 				}
-				return WatcherErrorMsg{Error: err}
+				return WatcherErrorMsg{Watcher: WatcherLogs, Error: err}
 			}
 		}
 	}
 }
 
 // startWatchers returns a batch of commands to start all file watchers.
-func startWatchers(tasksFile, logDir string) tea.Cmd {
-	cfg := WatchConfig{
-		TasksFile: tasksFile,
-		LogDir:    logDir,
-	}
+func startWatchers(cfg WatchConfig) tea.Cmd {
 	return tea.Batch(
 		watchTasksFile(cfg),
 		watchLogDirectory(cfg),
 	)
 }
+
+// watchCmd returns the command running the named watcher.
+func watchCmd(name string, cfg WatchConfig) tea.Cmd {
+	if name == WatcherLogs {
+		return watchLogDirectory(cfg)
+	}
+	return watchTasksFile(cfg)
+}
+
+// retryWatchers checks that the named watchers can be set up again and
+// reports the result as a WatchRetryMsg.
+func retryWatchers(cfg WatchConfig, names []string) tea.Cmd {
+	return func() tea.Msg {
+		for _, name := range names {
+			path := cfg.TasksFile
+			if name == WatcherLogs {
+				path = cfg.LogDir
+			}
+			if err := probeWatch(path); err != nil {
+				return WatchRetryMsg{Error: fmt.Errorf("%s watcher: %w", name, err)}
+			}
+		}
+		return WatchRetryMsg{Watchers: names}
+	}
+}
+
+// probeWatch reports whether path can be watched.
+func probeWatch(path string) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+	return watcher.Add(path)
+}
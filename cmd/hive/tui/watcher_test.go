@@ -0,0 +1,129 @@
+package tui
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestWatchersStopOnCancel(t *testing.T) {
+	dir := t.TempDir()
+	tasksFile := filepath.Join(dir, "tasks.json")
+	if err := os.WriteFile(tasksFile, []byte("{}"), 0644); err != nil {
+		t.Fatalf("failed to write tasks file: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cfg := WatchConfig{TasksFile: tasksFile, LogDir: dir, Ctx: ctx}
+
+	results := make(chan tea.Msg, 2)
+	for _, cmd := range []tea.Cmd{watchTasksFile(cfg), watchLogDirectory(cfg)} {
+		go func() { results <- cmd() }()
+	}
+	cancel()
+
+	for range 2 {
+		select {
+		case msg := <-results:
+			if msg != nil {
+				t.Errorf("expected a cancelled watcher to return no message, got %#v", msg)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("watcher did not stop after cancellation")
+		}
+	}
+}
+
+func TestQuitStopsRearmingWatchers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	m := Model{WatchCtx: ctx, WatchCancel: cancel}
+
+	updated, cmd := m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	m = updated.(Model)
+	if cmd == nil || !m.Quitting {
+		t.Fatal("expected q to quit")
+	}
+	if ctx.Err() == nil {
+		t.Error("expected quitting to cancel the watchers")
+	}
+
+	if _, cmd := m.Update(TasksUpdatedMsg{}); cmd != nil {
+		t.Error("expected no watcher to be re-armed after quitting")
+	}
+	if _, cmd := m.Update(WatchRetryMsg{Watchers: []string{WatcherTasks}}); cmd != nil {
+		t.Error("expected no watcher to be restarted after quitting")
+	}
+}
+
+func TestWatchRetryRestoresFallbackPolling(t *testing.T) {
+	dir := t.TempDir()
+	tasksFile := filepath.Join(dir, "tasks.json")
+	m := Model{TasksFile: tasksFile, LogDir: dir}
+
+	update := func(msg tea.Msg) tea.Cmd {
+		t.Helper()
+		updated, cmd := m.Update(msg)
+		m = updated.(Model)
+		return cmd
+	}
+	retry := func() tea.Msg {
+		t.Helper()
+		updated, cmd := m.executeSlashCommand("/watch-retry")
+		m = updated.(Model)
+		if cmd == nil {
+			t.Fatal("expected /watch-retry to probe the failed watchers")
+		}
+		return cmd()
+	}
+
+	// With every watcher running there is nothing to retry
+	updated, cmd := m.executeSlashCommand("/watch-retry")
+	m = updated.(Model)
+	if cmd != nil || m.Toast != "watchers are running" {
+		t.Errorf("expected a toast and no retry, got %q", m.Toast)
+	}
+
+	// A failed watcher switches to polling once, however often it reports
+	for range 2 {
+		update(WatcherErrorMsg{Watcher: WatcherTasks, Error: os.ErrNotExist})
+	}
+	if !m.FallbackPolling || len(m.DownWatchers) != 1 || m.DownWatchers[0] != WatcherTasks {
+		t.Fatalf("expected polling with the tasks watcher down, got %v and %v", m.FallbackPolling, m.DownWatchers)
+	}
+	if !strings.Contains(m.renderFooter(), "polling") {
+		t.Error("expected the footer to show polling")
+	}
+
+	// The tasks file is still missing, so the retry fails and polling stays
+	msg := retry()
+	if msg.(WatchRetryMsg).Error == nil {
+		t.Fatal("expected the retry to fail while the tasks file is missing")
+	}
+	update(msg)
+	if !m.FallbackPolling || m.Err == nil {
+		t.Errorf("expected polling to stay on with an error, got %v and %v", m.FallbackPolling, m.Err)
+	}
+
+	// Once the file is back the watcher is restarted
+	if err := os.WriteFile(tasksFile, []byte("[]"), 0644); err != nil {
+		t.Fatalf("failed to write tasks file: %v", err)
+	}
+	msg = retry()
+	if got := msg.(WatchRetryMsg); got.Error != nil || len(got.Watchers) != 1 || got.Watchers[0] != WatcherTasks {
+		t.Fatalf("expected the tasks watcher to be restartable, got %+v", got)
+	}
+	if cmd := update(msg); cmd == nil {
+		t.Error("expected the tasks watcher to be restarted")
+	}
+	if m.FallbackPolling || m.DownWatchers != nil || m.Toast != "watchers restored" {
+		t.Errorf("expected polling to end, got %v, %v and %q", m.FallbackPolling, m.DownWatchers, m.Toast)
+	}
+	if strings.Contains(m.renderFooter(), "polling") {
+		t.Error("expected the footer to drop the polling badge")
+	}
+}
@@ -12,7 +12,6 @@ import (
 	"github.com/tuanbt/hive/internal/git"
 	"github.com/tuanbt/hive/internal/logger"
 	"github.com/tuanbt/hive/internal/orchestrator"
-	"github.com/tuanbt/hive/internal/task"
 )
 
 var (
@@ -24,6 +23,7 @@ func main() {
 	configPath := flag.String("config", "config.json", "Path to config file")
 	workers := flag.Int("workers", 0, "Override num_workers (0 = use config)")
 	showVersion := flag.Bool("version", false, "Show version and exit")
+	force := flag.Bool("force", false, "Start even if another orchestrator holds the lock file")
 	flag.Parse()
 
 	// Show version
@@ -39,6 +39,11 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err := cfg.CheckWritable(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Override workers if specified
 	if *workers > 0 {
 		cfg.NumWorkers = *workers
@@ -56,12 +61,15 @@ func main() {
 		"config", *configPath,
 		"workers", cfg.NumWorkers,
 	)
+	for _, warning := range cfg.Warnings {
+		log.Warn("config warning", "warning", warning)
+	}
 
 	// Create git client
 	gitClient := git.NewClient(cfg.WorkDirectory)
 
-	// Create task manager
-	taskMgr := task.NewManager(cfg.TasksFile)
+	// Create task manager; unattended runs pay for durable saves
+	taskMgr := cfg.NewTaskManager().WithFsync(true).WithLogger(log)
 
 	// Create orchestrator
 	orch, err := orchestrator.New(cfg, log, gitClient, taskMgr)
@@ -69,19 +77,29 @@ func main() {
 		log.Error("failed to create orchestrator", "error", err)
 		os.Exit(1)
 	}
+	orch.SetForceLock(*force)
 
 	// Setup context with cancellation
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Setup signal handling
+	// Setup signal handling; SIGHUP reloads the config in place
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 
 	go func() {
-		sig := <-sigChan
-		log.Info("received signal, initiating shutdown", "signal", sig)
-		cancel()
+		for sig := range sigChan {
+			if sig == syscall.SIGHUP {
+				log.Info("received SIGHUP, reloading config", "config", *configPath)
+				if err := orch.Reload(*configPath); err != nil {
+					log.Error("config reload failed", "error", err)
+				}
+				continue
+			}
+			log.Info("received signal, initiating shutdown", "signal", sig)
+			cancel()
+			return
+		}
 	}()
 
 	// Run orchestrator
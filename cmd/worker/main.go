@@ -4,35 +4,65 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"log/slog"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/tuanbt/hive/internal/agent"
 	"github.com/tuanbt/hive/internal/config"
 	"github.com/tuanbt/hive/internal/logger"
+	"github.com/tuanbt/hive/internal/task"
+	"github.com/tuanbt/hive/internal/worker"
 )
 
 func main() {
 	taskInput := flag.String("task", "", "The task description to execute")
+	taskID := flag.String("task-id", "", "Run an existing task from the tasks file by ID")
+	tasksFile := flag.String("tasks-file", "", "Path to the tasks file (default: tasks_file from config)")
+	commitStatus := flag.Bool("commit-status", false, "With -task-id, claim the task and write the result status back to the tasks file")
+	configPath := flag.String("config", "config.json", "Path to config file")
 	flag.Parse()
 
-	if *taskInput == "" {
-		fmt.Println("Error: --task argument is required")
+	if (*taskInput == "") == (*taskID == "") {
+		fmt.Println("Error: exactly one of --task or --task-id is required")
 		os.Exit(1)
 	}
 
-	// Load Config to defaults
-	cfg := config.DefaultConfig()
-	cfg.AgentCommand = []string{"opencode", "run"}
-	cfg.AgentMode = "episodic"
+	// Load config, falling back to defaults if the file doesn't exist
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+	for _, warning := range cfg.Warnings {
+		fmt.Printf("Warning: %s\n", warning)
+	}
 	cfg.MaxRestartAttempts = 0
 
-	// Use Console Logger
-	log := logger.NewConsoleLogger(cfg)
-	log.Info("Worker started", "task", *taskInput)
+	// Use colorized console logger
+	log := logger.NewPrettyConsoleLogger(cfg)
 
 	pwd, _ := os.Getwd()
-	driver := agent.New(cfg, log, pwd)
+	if *taskID != "" {
+		if *tasksFile != "" {
+			cfg.TasksFile = *tasksFile
+		}
+		runTaskByID(cfg, log, pwd, *taskID, *commitStatus)
+	} else {
+		runPrompt(cfg, log, pwd, *taskInput)
+	}
+
+	// Keep terminal open
+	fmt.Println("\nPress Enter to close this worker...")
+	fmt.Scanln()
+}
+
+// runPrompt sends an ad-hoc prompt straight to the agent.
+func runPrompt(cfg *config.Config, log *slog.Logger, workDir, input string) {
+	log.Info("Worker started", "task", input)
+
+	driver := agent.New(cfg, log, workDir)
 
 	// Start Agent
 	if err := driver.Start(); err != nil {
@@ -41,35 +71,131 @@ func main() {
 	}
 	defer driver.Stop()
 
-	// Execution Context
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	// Execution Context, capped like an orchestrated task
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.MaxTaskDurationSeconds)*time.Second)
 	defer cancel()
 
-	fmt.Printf("\n>>> EXECUTING TASK: %s\n\n", *taskInput)
+	fmt.Printf("\n>>> EXECUTING TASK: %s\n\n", input)
 
-	if err := driver.SendInput(*taskInput); err != nil {
+	if err := driver.SendInput(input); err != nil {
 		log.Error("Failed to send input", "error", err)
 		os.Exit(1)
 	}
 
 	// Wait for Result
-	output, success, err := driver.WaitForResponse(ctx, nil)
+	output, reason, err := driver.WaitForResponse(ctx, nil)
 	if err != nil {
 		log.Error("Execution failed", "error", err)
 	}
 
-	fmt.Println("\n>>> AGENT OUTPUT:")
-	fmt.Println("---------------------------------------------------")
-	fmt.Println(output)
-	fmt.Println("---------------------------------------------------")
+	printOutput(output)
 
-	if success {
-		fmt.Println("\n✅ TASK COMPLETED SUCCESSFULLY")
+	if reason.IsSuccess() {
+		fmt.Printf("\n✅ TASK COMPLETED SUCCESSFULLY (%s)\n", reason)
 	} else {
-		fmt.Println("\n❌ TASK FAILED OR TIMED OUT")
+		fmt.Printf("\n❌ TASK FAILED OR TIMED OUT (%s)\n", reason)
+		if limit := timeoutLimit(cfg, reason); limit != "" {
+			fmt.Printf("   limit reached: %s\n", limit)
+		}
 	}
+}
 
-	// Keep terminal open
-	fmt.Println("\nPress Enter to close this worker...")
-	fmt.Scanln()
+// runTaskByID runs a pending task from the registry the way a pool worker
+// would. The registry is only updated when commit is set; the task is then
+// claimed first, so an orchestrator sharing the file won't run it too.
+func runTaskByID(cfg *config.Config, log *slog.Logger, workDir, id string, commit bool) {
+	// Built like the orchestrator's, since with commit it writes the same
+	// registry unattended
+	mgr := cfg.NewTaskManager().WithFsync(true).WithLogger(log)
+	t, err := mgr.GetByID(id)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if t.Status != task.StatusPending {
+		fmt.Printf("Error: task %s is not pending (status: %s)\n", t.ID, t.Status)
+		os.Exit(1)
+	}
+
+	if commit {
+		if err := cfg.CheckWritable(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := mgr.ClaimTask(t.ID, 0); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := mgr.StartTask(t.ID, 0); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	log.Info("Worker started", "task_id", t.ID, "title", t.Title)
+	fmt.Printf("\n>>> EXECUTING TASK %s: %s\n\n", t.ID, t.Title)
+
+	result, err := worker.RunTask(context.Background(), cfg, log, workDir, t)
+	if err != nil {
+		log.Error("Failed to run task", "error", err)
+		if commit {
+			// Hand the claim back so the task can still be picked up
+			mgr.UpdateStatus(t.ID, task.StatusPending, "")
+		}
+		os.Exit(1)
+	}
+
+	printOutput(result.Output)
+
+	if result.Status == task.StatusCompleted {
+		fmt.Printf("\n✅ TASK COMPLETED SUCCESSFULLY (%s, %s)\n", result.Completion, result.Duration.Round(time.Second))
+	} else {
+		fmt.Printf("\n❌ TASK FAILED OR TIMED OUT (%s, %s)\n", result.Completion, result.Duration.Round(time.Second))
+		if result.Error != nil {
+			fmt.Printf("   error: %v\n", result.Error)
+		}
+		if limit := timeoutLimit(cfg, result.Completion); limit != "" {
+			fmt.Printf("   limit reached: %s\n", limit)
+		}
+	}
+	if len(result.NewTasks) > 0 {
+		fmt.Printf("   plan produced %d subtasks (not added)\n", len(result.NewTasks))
+	}
+
+	if !commit {
+		return
+	}
+	reason := ""
+	if result.Error != nil {
+		reason = result.Error.Error()
+	}
+	err = mgr.Modify(t.ID, func(stored *task.Task) error {
+		stored.CompletionReason = string(result.Completion)
+		stored.SetStatus(result.Status, reason)
+		return nil
+	})
+	if err != nil {
+		fmt.Printf("Error updating task status: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("   status written to %s\n", cfg.TasksFile)
+}
+
+// printOutput prints the agent output in a box.
+func printOutput(output string) {
+	fmt.Println()
+	fmt.Println("┌─── AGENT OUTPUT ──────────────────────────────────")
+	for _, line := range strings.Split(strings.TrimRight(output, "\n"), "\n") {
+		fmt.Println("│ " + line)
+	}
+	fmt.Println("└───────────────────────────────────────────────────")
+}
+
+// timeoutLimit describes the config limit behind a timeout reason, or
+// returns "" if the run did not time out.
+func timeoutLimit(cfg *config.Config, reason agent.CompletionReason) string {
+	if reason != agent.CompletionHardTimeout {
+		return ""
+	}
+	return fmt.Sprintf("max_task_duration_seconds=%d (wall clock)", cfg.MaxTaskDurationSeconds)
 }
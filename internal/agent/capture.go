@@ -0,0 +1,100 @@
+package agent
+
+import (
+	"bytes"
+	"strings"
+)
+
+// truncatedNotice is appended to output cut off at the size limit.
+const truncatedNotice = "[output truncated]"
+
+// maxOverflowLine bounds how much of a single line past the limit is held
+// while waiting for its newline. Longer lines keep only their tail.
+const maxOverflowLine = 4096
+
+// outputBuffer collects one output stream of a command up to limit bytes.
+// Output past the limit is dropped but still scanned line by line, and
+// lines that signal completion are kept so detection still works.
+type outputBuffer struct {
+	buf   bytes.Buffer
+	limit int // 0 means unlimited
+	keep  func(line string) bool
+
+	truncated bool
+	partial   []byte   // incomplete line past the limit
+	kept      []string // completion lines seen past the limit
+}
+
+// newOutputBuffer returns a buffer holding at most limit bytes of output.
+// keep reports which lines past the limit must be retained.
+func newOutputBuffer(limit int, keep func(line string) bool) *outputBuffer {
+	return &outputBuffer{limit: limit, keep: keep}
+}
+
+func (b *outputBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	if b.limit <= 0 {
+		return b.buf.Write(p)
+	}
+
+	if !b.truncated {
+		room := b.limit - b.buf.Len()
+		if len(p) <= room {
+			return b.buf.Write(p)
+		}
+		b.buf.Write(p[:room])
+		b.truncated = true
+		p = p[room:]
+
+		// The line cut at the limit continues in the overflow
+		held := b.buf.Bytes()
+		if idx := bytes.LastIndexByte(held, '\n'); idx >= 0 {
+			held = held[idx+1:]
+		}
+		b.partial = append(b.partial, held...)
+	}
+
+	b.partial = append(b.partial, p...)
+	for {
+		idx := bytes.IndexByte(b.partial, '\n')
+		if idx < 0 {
+			break
+		}
+		b.scan(string(b.partial[:idx]))
+		b.partial = b.partial[idx+1:]
+	}
+	if len(b.partial) > maxOverflowLine {
+		b.partial = b.partial[len(b.partial)-maxOverflowLine:]
+	}
+	return n, nil
+}
+
+// scan keeps an overflow line if it signals completion.
+func (b *outputBuffer) scan(line string) {
+	if b.keep != nil && b.keep(line) {
+		b.kept = append(b.kept, line)
+	}
+}
+
+// String returns the captured output. Truncated output ends with the
+// truncation notice followed by any completion lines seen past the limit.
+func (b *outputBuffer) String() string {
+	if !b.truncated {
+		return b.buf.String()
+	}
+	if len(b.partial) > 0 {
+		b.scan(string(b.partial))
+		b.partial = nil
+	}
+
+	var out strings.Builder
+	out.Write(b.buf.Bytes())
+	if b.buf.Len() > 0 && !bytes.HasSuffix(b.buf.Bytes(), []byte("\n")) {
+		out.WriteString("\n")
+	}
+	out.WriteString(truncatedNotice + "\n")
+	for _, line := range b.kept {
+		out.WriteString(line + "\n")
+	}
+	return out.String()
+}
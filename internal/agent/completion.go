@@ -0,0 +1,48 @@
+package agent
+
+// CompletionReason describes why an agent response was considered finished.
+type CompletionReason string
+
+const (
+	// CompletionMarkerFound indicates the output contained the completion marker.
+	CompletionMarkerFound CompletionReason = "marker_found"
+
+	// CompletionFileFound indicates the agent created the configured completion file.
+	CompletionFileFound CompletionReason = "completion_file"
+
+	// CompletionBlocked indicates the output contained the blocked marker:
+	// the agent cannot finish without human input.
+	CompletionBlocked CompletionReason = "blocked"
+
+	// CompletionStopToken indicates the output contained one of the stop tokens.
+	CompletionStopToken CompletionReason = "stop_token"
+
+	// CompletionCleanExit indicates the process exited with a success exit code
+	// without signalling completion.
+	CompletionCleanExit CompletionReason = "clean_exit"
+
+	// CompletionNoOutput indicates the process exited cleanly without
+	// writing any output while RequireOutput is set.
+	CompletionNoOutput CompletionReason = "no_output"
+
+	// CompletionHardTimeout indicates the run hit its wall-clock deadline.
+	CompletionHardTimeout CompletionReason = "hard_timeout"
+
+	// CompletionReviewRejected indicates the review phase used up its
+	// cycles without the agent confirming the work.
+	CompletionReviewRejected CompletionReason = "review_rejected"
+
+	// CompletionProcessError indicates the process exited with a failure exit
+	// code or was cancelled.
+	CompletionProcessError CompletionReason = "process_error"
+)
+
+// IsSuccess returns true if the agent is considered to have finished its work.
+func (r CompletionReason) IsSuccess() bool {
+	return r == CompletionMarkerFound || r == CompletionFileFound || r == CompletionStopToken || r == CompletionCleanExit
+}
+
+// HasMarker returns true if the agent explicitly signalled completion.
+func (r CompletionReason) HasMarker() bool {
+	return r == CompletionMarkerFound || r == CompletionFileFound || r == CompletionStopToken
+}
@@ -2,13 +2,15 @@
 package agent
 
 import (
-	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -26,9 +28,17 @@ type Driver struct {
 	config  *config.Config
 	logger  *slog.Logger
 	workDir string
+	clock   clock
+
+	stdinIdle time.Duration // silence after which interaction rules give up on stdin
 
 	isRunning    atomic.Bool
 	restartCount int
+	lastExitCode int
+	role         string
+	taskID       string       // names the docker container of each run
+	reviewing    bool         // runs use the review agent command
+	events       *slog.Logger // receives each output line when set
 	mu           sync.Mutex
 
 	stopOnce sync.Once
@@ -38,25 +48,34 @@ type Driver struct {
 // New initializes a new agent Driver instance.
 func New(cfg *config.Config, logger *slog.Logger, workDir string) *Driver {
 	return &Driver{
-		config:   cfg,
-		logger:   logger,
-		workDir:  workDir,
-		stopChan: make(chan struct{}),
+		config:    cfg,
+		logger:    logger,
+		workDir:   workDir,
+		clock:     realClock{},
+		stdinIdle: defaultStdinIdle,
+		stopChan:  make(chan struct{}),
 	}
 }
 
-// Start launches the agent logic.
+// Start launches the agent logic. It returns once the configured startup
+// delay has passed, so callers can send input right away.
 func (d *Driver) Start() error {
 	d.mu.Lock()
-	defer d.mu.Unlock()
-
 	if d.isRunning.Load() {
+		d.mu.Unlock()
 		return fmt.Errorf("agent is already running")
 	}
 
 	d.stopChan = make(chan struct{})
 	d.stopOnce = sync.Once{}
 	d.isRunning.Store(true)
+	d.mu.Unlock()
+
+	if delay := d.config.AgentStartupDelayMillis; delay > 0 {
+		d.logger.Debug("waiting for agent startup", "delay_ms", delay)
+		d.clock.Sleep(time.Duration(delay) * time.Millisecond)
+	}
+
 	d.logger.Info("started episodic agent")
 	return nil
 }
@@ -98,7 +117,7 @@ func (d *Driver) Restart() error {
 
 	d.logger.Warn("restarting agent", "attempt", count)
 	d.Stop()
-	time.Sleep(cooldown)
+	d.clock.Sleep(cooldown)
 	return d.Start()
 }
 
@@ -122,6 +141,109 @@ func (d *Driver) ResetRestartCount() {
 	d.mu.Unlock()
 }
 
+// Command returns the command line used to run the agent, without the
+// prompt. In the docker runtime this includes the docker run wrapper.
+// While reviewing it is the review agent command.
+func (d *Driver) Command() []string {
+	d.mu.Lock()
+	reviewing := d.reviewing
+	d.mu.Unlock()
+
+	container := d.containerName()
+	if reviewing {
+		return d.config.ReviewAgentArgv(d.workDir, container)
+	}
+	return d.config.AgentArgv(d.workDir, container)
+}
+
+// containerName returns the name of the container runs use in the docker
+// runtime, or "" when runs are not in a named container.
+func (d *Driver) containerName() string {
+	d.mu.Lock()
+	taskID := d.taskID
+	d.mu.Unlock()
+
+	if d.config.AgentRuntime != config.RuntimeDocker || taskID == "" {
+		return ""
+	}
+	return config.ContainerName(taskID)
+}
+
+// containerRemoveTimeout bounds the docker rm that cleans up a killed run.
+const containerRemoveTimeout = 30 * time.Second
+
+// kill stops a run early. In the docker runtime killing the process only
+// stops the docker client, so the container is removed as well.
+func (d *Driver) kill(cmd *exec.Cmd, container string) {
+	if cmd.Process != nil {
+		cmd.Process.Kill()
+	}
+	if container == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), containerRemoveTimeout)
+	defer cancel()
+	if out, err := exec.CommandContext(ctx, "docker", "rm", "-f", container).CombinedOutput(); err != nil {
+		d.logger.Warn("failed to remove agent container", "container", container, "error", err, "output", strings.TrimSpace(string(out)))
+	}
+}
+
+// LastExitCode returns the exit code of the most recent agent run, or -1 if
+// the process was killed or never exited normally.
+func (d *Driver) LastExitCode() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.lastExitCode
+}
+
+// SetRole sets the role of the task being run, which selects the stop
+// tokens from RoleStopTokens used in completion checks.
+func (d *Driver) SetRole(role string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.role = role
+}
+
+// SetConfig replaces the config used from the next run on. Callers must not
+// swap it while a run is in progress.
+func (d *Driver) SetConfig(cfg *config.Config) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.config = cfg
+}
+
+// SetTaskID sets the ID of the task being run. In the docker runtime it
+// names the container, so a run that is stopped early can be removed.
+func (d *Driver) SetTaskID(taskID string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.taskID = taskID
+}
+
+// SetReviewing switches the following runs to the review agent command,
+// or back to the main agent command.
+func (d *Driver) SetReviewing(reviewing bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.reviewing = reviewing
+}
+
+// SetEventLogger sets a logger that receives every output line of each
+// run, classified as stdout, stderr or marker. nil turns it off.
+func (d *Driver) SetEventLogger(events *slog.Logger) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.events = events
+}
+
+// stopTokens returns the stop tokens for the current task role.
+func (d *Driver) stopTokens() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.config.StopTokensFor(d.role)
+}
+
 // SendInput sends text to the agent.
 func (d *Driver) SendInput(text string) error {
 	if !d.IsAlive() {
@@ -136,47 +258,100 @@ func (d *Driver) SendInput(text string) error {
 }
 
 // WaitForResponse waits for agent output.
-func (d *Driver) WaitForResponse(ctx context.Context, taskLogger io.Writer) (string, bool, error) {
+// The returned CompletionReason explains how the run ended.
+func (d *Driver) WaitForResponse(ctx context.Context, taskLogger io.Writer) (string, CompletionReason, error) {
 	return d.execute(ctx, taskLogger)
 }
 
-func (d *Driver) execute(ctx context.Context, taskLogger io.Writer) (string, bool, error) {
+func (d *Driver) execute(ctx context.Context, taskLogger io.Writer) (string, CompletionReason, error) {
 	d.mu.Lock()
 	input := d.inputBuf.String()
 	d.inputBuf.Reset()
 	d.mu.Unlock()
 
-	args := append([]string{}, d.config.AgentCommand[1:]...)
+	// Enforce a hard wall-clock cap so a runaway agent that keeps producing
+	// output is still bounded, even if the caller's context has no deadline.
+	// A nil channel never fires, leaving the run uncapped.
+	var deadline <-chan time.Time
+	if d.config.MaxTaskDurationSeconds > 0 {
+		deadline = d.clock.After(time.Duration(d.config.MaxTaskDurationSeconds) * time.Second)
+	}
+
+	argv := d.Command()
+	container := d.containerName()
+	args := argv[1:]
 	// Add input as positional arguments for episodic commands (e.g. 'opencode run [message]')
 	if input != "" {
 		args = append(args, input)
 	}
 
-	cmd := exec.Command(d.config.AgentCommand[0], args...)
+	// Clear a stale completion file so only this run can signal completion
+	var sentinel <-chan time.Time
+	if path := d.completionFilePath(); path != "" {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return "", CompletionProcessError, fmt.Errorf("failed to clear completion file: %w", err)
+		}
+		poll := time.NewTicker(completionFilePollInterval)
+		defer poll.Stop()
+		sentinel = poll.C
+	}
+
+	cmd := exec.Command(argv[0], args...)
 	cmd.Dir = d.workDir
 	cmd.Env = os.Environ()
 
-	// Capture combined stdout and stderr
-	var output strings.Builder
-	var stdoutBuf, stderrBuf bytes.Buffer
-	cmd.Stdout = &stdoutBuf
-	cmd.Stderr = &stderrBuf
+	// Capture stdout and stderr, each capped at MaxOutputBytes
+	stdoutBuf := newOutputBuffer(d.config.MaxOutputBytes, d.isCompletionLine)
+	stderrBuf := newOutputBuffer(d.config.MaxOutputBytes, d.isCompletionLine)
+	cmd.Stdout = stdoutBuf
+	cmd.Stderr = stderrBuf
+
+	// Lines are passed on as they arrive rather than once the run ends.
+	// Timestamped task log lines go this way too, so each one carries the
+	// time it was written
+	var outputLog io.Writer = taskLogger
+	if stream := d.newOutputStream(taskLogger); stream != nil {
+		if stream.taskLog != nil {
+			outputLog = nil
+		}
+		stdoutLines, stderrLines := stream.writer("stdout"), stream.writer("stderr")
+		defer stdoutLines.flush()
+		defer stderrLines.flush()
+		cmd.Stdout = io.MultiWriter(cmd.Stdout, stdoutLines)
+		cmd.Stderr = io.MultiWriter(cmd.Stderr, stderrLines)
+	}
 
 	// Create stdin pipe
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
-		return "", false, fmt.Errorf("stdin pipe: %w", err)
+		return "", CompletionProcessError, fmt.Errorf("stdin pipe: %w", err)
+	}
+
+	// With interaction rules stdin stays open so prompts can be answered,
+	// until the marker is seen or the agent goes idle
+	resp := newResponder(d.config, stdin, d.stdinIdle, d.logger)
+	if resp != nil {
+		defer resp.close()
+		cmd.Stdout = io.MultiWriter(cmd.Stdout, resp.watch())
+		cmd.Stderr = io.MultiWriter(cmd.Stderr, resp.watch())
 	}
 
 	d.logger.Info("executing episodic command", "cmd", cmd.String())
+	if d.config.LogPrompt && taskLogger != nil && input != "" {
+		logPrompt(taskLogger, input)
+	}
 
 	if err := cmd.Start(); err != nil {
 		stdin.Close()
-		return "", false, err
+		return "", CompletionProcessError, err
 	}
 
-	// Write input to stdin and close
+	// Write input to stdin and close, unless prompts may need answering
 	go func() {
+		if resp != nil {
+			resp.send(input)
+			return
+		}
 		defer stdin.Close()
 		io.WriteString(stdin, input)
 	}()
@@ -187,40 +362,241 @@ func (d *Driver) execute(ctx context.Context, taskLogger io.Writer) (string, boo
 		done <- cmd.Wait()
 	}()
 
-	// Wait for completion or context cancellation
-	select {
-	case <-ctx.Done():
-		if cmd.Process != nil {
-			cmd.Process.Kill()
+	// Wait for completion, the completion file, the hard deadline or
+	// context cancellation
+	for {
+		select {
+		case <-deadline:
+			d.kill(cmd, container)
+			d.setExitCode(-1)
+			d.logger.Warn("command exceeded hard deadline", "max_seconds", d.config.MaxTaskDurationSeconds)
+			return "", CompletionHardTimeout, context.DeadlineExceeded
+
+		case <-ctx.Done():
+			d.kill(cmd, container)
+			d.setExitCode(-1)
+			if ctx.Err() == context.DeadlineExceeded {
+				d.logger.Warn("command exceeded task deadline")
+				return "", CompletionHardTimeout, ctx.Err()
+			}
+			d.logger.Warn("command cancelled")
+			return "", CompletionProcessError, ctx.Err()
+
+		case <-sentinel:
+			if !d.completionFileExists() {
+				continue
+			}
+			// The agent declared itself done; stop it and keep its output
+			d.logger.Info("completion file detected, stopping agent", "path", d.completionFilePath())
+			d.kill(cmd, container)
+			err := <-done
+			out, reason := d.finish(stdoutBuf, stderrBuf, err, outputLog)
+			return out, reason, nil
+
+		case err := <-done:
+			out, reason := d.finish(stdoutBuf, stderrBuf, err, outputLog)
+			return out, reason, nil
 		}
-		d.logger.Warn("command cancelled")
-		return output.String(), false, ctx.Err()
+	}
+}
+
+// logPrompt writes the prompt to the task log between delimiter lines.
+func logPrompt(taskLogger io.Writer, prompt string) {
+	fmt.Fprintln(taskLogger, "===== PROMPT =====")
+	fmt.Fprintln(taskLogger, strings.TrimRight(prompt, "\n"))
+	fmt.Fprintln(taskLogger, "===== END PROMPT =====")
+}
+
+// finish processes the output of an exited command and classifies the run.
+// The output is written to taskLogger when it is set.
+func (d *Driver) finish(stdoutBuf, stderrBuf *outputBuffer, err error, taskLogger io.Writer) (string, CompletionReason) {
+	stdout, stderr := stdoutBuf.String(), stderrBuf.String()
+	silent := strings.TrimSpace(stdout+stderr) == ""
+	doneEvent := false
+	if d.config.AgentOutputFormat == config.OutputFormatNDJSON {
+		stdout, doneEvent = parseNDJSON(stdout)
+	}
+	finalOutput := stdout + stderr
+
+	code := exitCode(err)
+	d.setExitCode(code)
+	if err != nil {
+		d.logger.Warn("episodic cmd finished with error", "error", err, "exit_code", code)
+	} else {
+		d.logger.Info("episodic cmd finished successfully")
+	}
 
-	case err := <-done:
-		finalOutput := stdoutBuf.String() + stderrBuf.String()
-		output.WriteString(finalOutput)
+	// Classify before stripping so the markers still count
+	reason := d.classify(finalOutput, doneEvent, d.completionFileExists(), code)
+	if reason == CompletionCleanExit && silent && d.config.RequireOutput {
+		d.logger.Warn("agent produced no output", "exit_code", code)
+		reason = CompletionNoOutput
+	}
+	if d.config.StripCompletionMarkers {
+		finalOutput = d.stripMarkers(finalOutput)
+	}
 
-		if taskLogger != nil {
-			fmt.Fprintln(taskLogger, finalOutput)
+	if taskLogger != nil {
+		logOutput := finalOutput
+		if d.config.PrefixStderr && stderr != "" {
+			logOutput = labelStderr(stdout, stderr)
+			if d.config.StripCompletionMarkers {
+				logOutput = d.stripMarkers(logOutput)
+			}
 		}
+		fmt.Fprintln(taskLogger, logOutput)
+	}
+
+	return finalOutput, reason
+}
+
+// stderrPrefix marks stderr lines in the task log.
+const stderrPrefix = "[stderr] "
+
+// labelStderr joins stdout and stderr with each stderr line prefixed, so
+// the two streams can be told apart in the task log.
+func labelStderr(stdout, stderr string) string {
+	var out strings.Builder
+	out.WriteString(stdout)
+	if stdout != "" && !strings.HasSuffix(stdout, "\n") {
+		out.WriteString("\n")
+	}
+	for _, line := range strings.Split(strings.TrimRight(stderr, "\n"), "\n") {
+		out.WriteString(stderrPrefix + line + "\n")
+	}
+	return out.String()
+}
 
-		if err != nil {
-			d.logger.Warn("episodic cmd finished with error", "error", err)
-		} else {
-			d.logger.Info("episodic cmd finished successfully")
+// stripMarkers removes the lines holding the completion marker or a stop
+// token from output.
+func (d *Driver) stripMarkers(output string) string {
+	lines := strings.Split(output, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if d.isMarkerLine(line) {
+			continue
 		}
+		kept = append(kept, line)
+	}
+	return strings.Join(kept, "\n")
+}
 
-		// Check for completion marker
-		markerFound := strings.Contains(finalOutput, d.config.CompletionMarker)
-		for _, token := range d.config.StopTokens {
-			if strings.Contains(finalOutput, token) {
-				markerFound = true
-				break
-			}
+// isCompletionLine reports whether an output line signals completion in the
+// configured output format.
+func (d *Driver) isCompletionLine(line string) bool {
+	if d.config.AgentOutputFormat == config.OutputFormatNDJSON {
+		return isDoneEvent(line)
+	}
+	return d.isMarkerLine(line)
+}
+
+// isMarkerLine reports whether line holds the completion or blocked marker
+// or a stop token.
+func (d *Driver) isMarkerLine(line string) bool {
+	if d.config.CompletionMarker != "" && strings.Contains(line, d.config.CompletionMarker) {
+		return true
+	}
+	if d.config.BlockedMarker != "" && strings.Contains(line, d.config.BlockedMarker) {
+		return true
+	}
+	for _, token := range d.stopTokens() {
+		if token != "" && strings.Contains(line, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultStdinIdle is how long an agent run with interaction rules may stay
+// silent before its stdin is closed.
+const defaultStdinIdle = 5 * time.Second
+
+// startsLine reports whether any line of output begins with marker,
+// ignoring leading whitespace.
+func startsLine(output, marker string) bool {
+	for line := range strings.Lines(output) {
+		if strings.HasPrefix(strings.TrimSpace(line), marker) {
+			return true
 		}
+	}
+	return false
+}
 
-		// Implicit success for episodic if exit code 0 or marker found
-		success := markerFound || (err == nil)
-		return output.String(), success, nil
+// completionFilePollInterval is how often the completion file is checked.
+const completionFilePollInterval = 200 * time.Millisecond
+
+// completionFilePath resolves CompletionFile against the work directory.
+// Returns "" when no completion file is configured.
+func (d *Driver) completionFilePath() string {
+	path := d.config.CompletionFile
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(d.workDir, path)
+}
+
+// completionFileExists reports whether the agent has created the completion file.
+func (d *Driver) completionFileExists() bool {
+	path := d.completionFilePath()
+	if path == "" {
+		return false
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func (d *Driver) setExitCode(code int) {
+	d.mu.Lock()
+	d.lastExitCode = code
+	d.mu.Unlock()
+}
+
+// exitCode extracts the process exit code from the error returned by Wait.
+// It returns -1 when the process did not exit normally.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// classify determines the completion reason for a finished episodic command.
+// The completion file, an explicit marker or a stop token wins over the exit
+// code; otherwise an exit code listed in SuccessExitCodes is still treated as
+// implicit success. In ndjson mode only a done event counts as an explicit
+// marker. The blocked marker wins over everything else; it must start a
+// line, since the prompt quotes it and agents may echo the prompt.
+func (d *Driver) classify(output string, doneEvent, completionFile bool, code int) CompletionReason {
+	if d.config.BlockedMarker != "" && startsLine(output, d.config.BlockedMarker) {
+		return CompletionBlocked
+	}
+	if completionFile {
+		return CompletionFileFound
+	}
+	if d.config.AgentOutputFormat == config.OutputFormatNDJSON {
+		if doneEvent {
+			return CompletionMarkerFound
+		}
+	} else {
+		if strings.Contains(output, d.config.CompletionMarker) {
+			return CompletionMarkerFound
+		}
+		for _, token := range d.stopTokens() {
+			if strings.Contains(output, token) {
+				return CompletionStopToken
+			}
+		}
+	}
+	successCodes := d.config.SuccessExitCodes
+	if len(successCodes) == 0 {
+		successCodes = []int{0}
+	}
+	if slices.Contains(successCodes, code) {
+		return CompletionCleanExit
 	}
+	return CompletionProcessError
 }
@@ -1,9 +1,15 @@
 package agent
 
 import (
+	"bytes"
 	"context"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -25,6 +31,40 @@ func testLogger() *slog.Logger {
 	return slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
 }
 
+// fakeClock records requested sleeps and timers without waiting. Timers
+// returned by After fire whenever the test sends on fire.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	slept  []time.Duration
+	afters []time.Duration
+	fire   chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(0, 0), fire: make(chan time.Time, 1)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.slept = append(c.slept, d)
+	c.now = c.now.Add(d)
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.afters = append(c.afters, d)
+	return c.fire
+}
+
 func TestDriverStartStop(t *testing.T) {
 	cfg := testConfig()
 	cfg.AgentCommand = []string{"cat"} // Will wait for input and can be stopped
@@ -109,13 +149,68 @@ func TestDriverCompletionMarker(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	_, found, err := d.WaitForResponse(ctx, nil)
+	_, reason, err := d.WaitForResponse(ctx, nil)
 	if err != nil {
 		t.Fatalf("wait failed: %v", err)
 	}
 
-	if !found {
-		t.Error("expected completion marker to be found")
+	if reason != CompletionMarkerFound {
+		t.Errorf("expected completion marker to be found, got %s", reason)
+	}
+}
+
+func TestDriverBlockedMarker(t *testing.T) {
+	cfg := testConfig()
+	cfg.BlockedMarker = "### TASK_BLOCKED ###"
+	cfg.StripCompletionMarkers = true
+	cfg.AgentCommand = []string{"echo", "Need the API key\n### TASK_BLOCKED ###\n### TASK_DONE ###"}
+
+	d := New(cfg, testLogger(), t.TempDir())
+	if err := d.Start(); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer d.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	output, reason, err := d.WaitForResponse(ctx, nil)
+	if err != nil {
+		t.Fatalf("wait failed: %v", err)
+	}
+	if reason != CompletionBlocked {
+		t.Errorf("expected blocked marker to win, got %s", reason)
+	}
+	if reason.IsSuccess() {
+		t.Error("blocked run should not count as success")
+	}
+	if strings.Contains(output, "TASK_BLOCKED") {
+		t.Errorf("expected blocked marker to be stripped, got %q", output)
+	}
+}
+
+func TestDriverBlockedMarkerQuotedInPrompt(t *testing.T) {
+	cfg := testConfig()
+	cfg.BlockedMarker = "### TASK_BLOCKED ###"
+	// Echoes the prompt, which quotes the blocked marker mid-sentence
+	cfg.AgentCommand = []string{"echo", "Done\n### TASK_DONE ###\n"}
+
+	d := New(cfg, testLogger(), t.TempDir())
+	if err := d.Start(); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer d.Stop()
+	d.SendInput(cfg.CompletionInstruction())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, reason, err := d.WaitForResponse(ctx, nil)
+	if err != nil {
+		t.Fatalf("wait failed: %v", err)
+	}
+	if reason != CompletionMarkerFound {
+		t.Errorf("expected the quoted blocked marker to be ignored, got %s", reason)
 	}
 }
 
@@ -136,53 +231,72 @@ func TestDriverStopToken(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	_, found, err := d.WaitForResponse(ctx, nil)
+	_, reason, err := d.WaitForResponse(ctx, nil)
 	if err != nil {
 		t.Fatalf("wait failed: %v", err)
 	}
 
-	if !found {
-		t.Error("expected stop token to be found")
+	if reason != CompletionStopToken {
+		t.Errorf("expected stop token to be found, got %s", reason)
 	}
 }
 
 func TestDriverRestart(t *testing.T) {
 	cfg := testConfig()
 	cfg.AgentCommand = []string{"echo", "hello"}
-	cfg.RestartCooldownSeconds = []int{0, 0, 0} // No delay for testing
+	cfg.RestartCooldownSeconds = []int{5, 15, 60}
 	cfg.MaxRestartAttempts = 2
 	logger := testLogger()
 
 	d := New(cfg, logger, ".")
+	clk := newFakeClock()
+	d.clock = clk
 
 	if err := d.Start(); err != nil {
 		t.Fatalf("failed to start: %v", err)
 	}
 
-	// Wait for process to exit
-	time.Sleep(100 * time.Millisecond)
-
 	// Restart should work
 	if err := d.Restart(); err != nil {
 		t.Fatalf("failed to restart: %v", err)
 	}
 
 	// Second restart
-	time.Sleep(100 * time.Millisecond)
 	if err := d.Restart(); err != nil {
 		t.Fatalf("failed second restart: %v", err)
 	}
 
 	// Third restart should fail (exceeded max)
-	time.Sleep(100 * time.Millisecond)
 	err := d.Restart()
 	if err == nil {
 		t.Error("expected error when exceeding max restarts")
 	}
 
+	if len(clk.slept) != 2 || clk.slept[0] != 5*time.Second {
+		t.Errorf("expected two 5s cooldowns, got %v", clk.slept)
+	}
+
 	d.Stop()
 }
 
+func TestDriverStartupDelay(t *testing.T) {
+	cfg := testConfig()
+	cfg.AgentStartupDelayMillis = 250
+
+	d := New(cfg, testLogger(), ".")
+	clk := newFakeClock()
+	d.clock = clk
+
+	if err := d.Start(); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer d.Stop()
+
+	if len(clk.slept) != 1 || clk.slept[0] != 250*time.Millisecond {
+		t.Errorf("expected a single 250ms startup wait, got %v", clk.slept)
+	}
+}
+
 func TestDriverNotRunning(t *testing.T) {
 	cfg := testConfig()
 	logger := testLogger()
@@ -232,12 +346,12 @@ func TestDriverSilenceTimeout(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	_, found, _ := d.WaitForResponse(ctx, nil)
+	_, reason, _ := d.WaitForResponse(ctx, nil)
 
 	elapsed := time.Since(start)
 
 	// Should have timed out due to silence
-	if found {
+	if reason.IsSuccess() {
 		t.Log("marker was found (process exited with output)")
 	}
 
@@ -285,17 +399,16 @@ func TestDriverContextCancellation(t *testing.T) {
 func TestDriverResetRestartCount(t *testing.T) {
 	cfg := testConfig()
 	cfg.AgentCommand = []string{"echo", "test"}
-	cfg.RestartCooldownSeconds = []int{0}
+	cfg.RestartCooldownSeconds = []int{5}
 	cfg.MaxRestartAttempts = 3
 	logger := testLogger()
 
 	d := New(cfg, logger, ".")
+	d.clock = newFakeClock()
 	d.Start()
-	time.Sleep(50 * time.Millisecond)
 
 	// Use up one restart
 	d.Restart()
-	time.Sleep(50 * time.Millisecond)
 
 	// Reset
 	d.ResetRestartCount()
@@ -307,3 +420,887 @@ func TestDriverResetRestartCount(t *testing.T) {
 
 	d.Stop()
 }
+
+func TestDriverHardDeadline(t *testing.T) {
+	cfg := testConfig()
+	// Prints forever, so only the hard deadline can stop it
+	cfg.AgentCommand = []string{"bash", "-c", "while true; do echo tick; sleep 0.05; done"}
+	cfg.MaxTaskDurationSeconds = 1800
+	logger := testLogger()
+
+	d := New(cfg, logger, ".")
+	clk := newFakeClock()
+	d.clock = clk
+
+	if err := d.Start(); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer d.Stop()
+
+	// Fire the deadline as soon as the driver waits on it
+	clk.fire <- clk.Now()
+
+	_, reason, err := d.WaitForResponse(context.Background(), nil)
+
+	if err == nil {
+		t.Error("expected error when hard deadline is exceeded")
+	}
+	if reason != CompletionHardTimeout {
+		t.Errorf("expected hard_timeout, got %s", reason)
+	}
+	if len(clk.afters) != 1 || clk.afters[0] != 30*time.Minute {
+		t.Errorf("expected a single 30m deadline, got %v", clk.afters)
+	}
+}
+
+func TestDriverCompletionReasons(t *testing.T) {
+	tests := []struct {
+		name    string
+		command []string
+		want    CompletionReason
+	}{
+		{
+			name:    "clean exit without marker",
+			command: []string{"echo", "just output"},
+			want:    CompletionCleanExit,
+		},
+		{
+			name:    "non-zero exit without marker",
+			command: []string{"bash", "-c", "echo oops; exit 3"},
+			want:    CompletionProcessError,
+		},
+		{
+			name:    "exit 0 is success by default",
+			command: []string{"bash", "-c", "exit 0"},
+			want:    CompletionCleanExit,
+		},
+		{
+			name:    "exit 2 is failure by default",
+			command: []string{"bash", "-c", "exit 2"},
+			want:    CompletionProcessError,
+		},
+		{
+			name:    "marker wins over non-zero exit",
+			command: []string{"bash", "-c", "echo '### TASK_DONE ###'; exit 3"},
+			want:    CompletionMarkerFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.AgentCommand = tt.command
+
+			d := New(cfg, testLogger(), ".")
+			if err := d.Start(); err != nil {
+				t.Fatalf("failed to start: %v", err)
+			}
+			defer d.Stop()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+			defer cancel()
+
+			_, reason, err := d.WaitForResponse(ctx, nil)
+			if err != nil {
+				t.Fatalf("wait failed: %v", err)
+			}
+			if reason != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, reason)
+			}
+		})
+	}
+}
+
+func TestDriverSuccessExitCodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  []string
+		codes    []int
+		want     CompletionReason
+		wantCode int
+	}{
+		{"exit 0 not listed", []string{"bash", "-c", "exit 0"}, []int{2}, CompletionProcessError, 0},
+		{"exit 2 listed", []string{"bash", "-c", "exit 2"}, []int{0, 2}, CompletionCleanExit, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.AgentCommand = tt.command
+			cfg.SuccessExitCodes = tt.codes
+
+			d := New(cfg, testLogger(), ".")
+			if err := d.Start(); err != nil {
+				t.Fatalf("failed to start: %v", err)
+			}
+			defer d.Stop()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+			defer cancel()
+
+			_, reason, err := d.WaitForResponse(ctx, nil)
+			if err != nil {
+				t.Fatalf("wait failed: %v", err)
+			}
+			if reason != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, reason)
+			}
+			if code := d.LastExitCode(); code != tt.wantCode {
+				t.Errorf("expected exit code %d, got %d", tt.wantCode, code)
+			}
+		})
+	}
+}
+
+func TestDriverNDJSONOutput(t *testing.T) {
+	cfg := testConfig()
+	cfg.AgentOutputFormat = config.OutputFormatNDJSON
+	cfg.AgentCommand = []string{"printf", `{"type":"text","text":"writing file"}\n{"type":"tool","name":"write","text":"main.go"}\nnot json\n{"type":"done"}\n`}
+	logger := testLogger()
+
+	d := New(cfg, logger, ".")
+	if err := d.Start(); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer d.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	output, reason, err := d.WaitForResponse(ctx, nil)
+	if err != nil {
+		t.Fatalf("wait failed: %v", err)
+	}
+
+	if reason != CompletionMarkerFound {
+		t.Errorf("expected done event to signal completion, got %s", reason)
+	}
+	for _, want := range []string{"writing file", "[tool] write: main.go", "not json"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, `"type"`) {
+		t.Errorf("expected JSON events to be decoded, got:\n%s", output)
+	}
+}
+
+func TestDriverNDJSONIgnoresMarkerText(t *testing.T) {
+	cfg := testConfig()
+	cfg.AgentOutputFormat = config.OutputFormatNDJSON
+	cfg.AgentCommand = []string{"bash", "-c", `echo '{"type":"text","text":"### TASK_DONE ###"}'; exit 1`}
+	logger := testLogger()
+
+	d := New(cfg, logger, ".")
+	if err := d.Start(); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer d.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, reason, _ := d.WaitForResponse(ctx, nil)
+	if reason != CompletionProcessError {
+		t.Errorf("expected marker text to be ignored in ndjson mode, got %s", reason)
+	}
+}
+
+func TestDriverCompletionFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		command []string
+		stale   bool
+		want    CompletionReason
+	}{
+		{
+			name:    "agent touches the file and exits",
+			command: []string{"bash", "-c", "echo working; touch DONE; exit 3"},
+			want:    CompletionFileFound,
+		},
+		{
+			name:    "agent touches the file and keeps running",
+			command: []string{"bash", "-c", "echo working; touch DONE; exec sleep 30"},
+			want:    CompletionFileFound,
+		},
+		{
+			name:    "stale file is cleared before the run",
+			command: []string{"bash", "-c", "exit 3"},
+			stale:   true,
+			want:    CompletionProcessError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workDir := t.TempDir()
+			if tt.stale {
+				os.WriteFile(filepath.Join(workDir, "DONE"), nil, 0644)
+			}
+
+			cfg := testConfig()
+			cfg.AgentCommand = tt.command
+			cfg.CompletionFile = "DONE"
+
+			d := New(cfg, testLogger(), workDir)
+			if err := d.Start(); err != nil {
+				t.Fatalf("failed to start: %v", err)
+			}
+			defer d.Stop()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			output, reason, err := d.WaitForResponse(ctx, nil)
+			if err != nil {
+				t.Fatalf("wait failed: %v", err)
+			}
+			if reason != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, reason)
+			}
+			if tt.want == CompletionFileFound && !strings.Contains(output, "working") {
+				t.Errorf("expected agent output to be kept, got %q", output)
+			}
+		})
+	}
+}
+
+func TestDriverInteractionRules(t *testing.T) {
+	script := `echo "Proceed? (yes/no)"; read answer; printf "Name: "; read name; ` +
+		`echo "answer=$answer name=$name"; echo "### TASK_DONE ###"`
+
+	tests := []struct {
+		name  string
+		rules []config.InteractionRule
+		want  string
+	}{
+		{
+			name: "scripted replies",
+			rules: []config.InteractionRule{
+				{Match: `Proceed\?`, Response: "yes"},
+				{Match: `^Name: $`, Response: "hive"},
+			},
+			want: "answer=yes name=hive",
+		},
+		{
+			name: "no rules closes stdin",
+			want: "answer= name=",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.AgentCommand = []string{"bash", "-c", script}
+			cfg.InteractionRules = tt.rules
+
+			d := New(cfg, testLogger(), t.TempDir())
+			if err := d.Start(); err != nil {
+				t.Fatalf("failed to start: %v", err)
+			}
+			defer d.Stop()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			output, reason, err := d.WaitForResponse(ctx, nil)
+			if err != nil {
+				t.Fatalf("wait failed: %v", err)
+			}
+			if reason != CompletionMarkerFound {
+				t.Errorf("expected %s, got %s", CompletionMarkerFound, reason)
+			}
+			if !strings.Contains(output, tt.want) {
+				t.Errorf("expected %q in output, got %q", tt.want, output)
+			}
+		})
+	}
+}
+
+// closeBuffer is a bytes.Buffer standing in for an agent's stdin.
+type closeBuffer struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (b *closeBuffer) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestDriverInteractionRulesCloseIdleStdin(t *testing.T) {
+	// Reads stdin to the end, so it only finishes once stdin is closed
+	cfg := testConfig()
+	cfg.AgentCommand = []string{"bash", "-c", "cat >/dev/null; echo done"}
+	cfg.InteractionRules = []config.InteractionRule{{Match: `Proceed\?`, Response: "yes"}}
+
+	d := New(cfg, testLogger(), t.TempDir())
+	d.stdinIdle = 100 * time.Millisecond
+	if err := d.Start(); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer d.Stop()
+	d.SendInput("do the thing")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	output, _, err := d.WaitForResponse(ctx, nil)
+	if err != nil {
+		t.Fatalf("expected the agent to finish once stdin was closed, got %v", err)
+	}
+	if !strings.Contains(output, "done") {
+		t.Errorf("expected agent output, got %q", output)
+	}
+}
+
+func TestDriverStripCompletionMarkers(t *testing.T) {
+	for _, strip := range []bool{false, true} {
+		cfg := testConfig()
+		cfg.AgentCommand = []string{"bash", "-c", "echo 'did the work'; echo '### TASK_DONE ###'; echo 'TASK_COMPLETED'"}
+		cfg.CompletionMarker = "### TASK_DONE ###"
+		cfg.StopTokens = []string{"TASK_COMPLETED"}
+		cfg.StripCompletionMarkers = strip
+
+		d := New(cfg, testLogger(), t.TempDir())
+		if err := d.Start(); err != nil {
+			t.Fatalf("failed to start: %v", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		var logged bytes.Buffer
+		output, reason, err := d.WaitForResponse(ctx, &logged)
+		cancel()
+		d.Stop()
+		if err != nil {
+			t.Fatalf("wait failed: %v", err)
+		}
+
+		if reason != CompletionMarkerFound {
+			t.Errorf("strip=%v: marker must still be detected, got %s", strip, reason)
+		}
+		if !strings.Contains(output, "did the work") {
+			t.Errorf("strip=%v: expected agent output to be kept, got %q", strip, output)
+		}
+		for _, got := range []string{output, logged.String()} {
+			hasMarker := strings.Contains(got, cfg.CompletionMarker) || strings.Contains(got, "TASK_COMPLETED")
+			if hasMarker == strip {
+				t.Errorf("strip=%v: unexpected marker presence in %q", strip, got)
+			}
+		}
+	}
+}
+
+func TestDriverLogPrompt(t *testing.T) {
+	for _, logPrompt := range []bool{false, true} {
+		cfg := testConfig()
+		cfg.AgentCommand = []string{"bash", "-c", "cat >/dev/null; echo 'did the work'"}
+		cfg.LogPrompt = logPrompt
+
+		d := New(cfg, testLogger(), t.TempDir())
+		if err := d.Start(); err != nil {
+			t.Fatalf("failed to start: %v", err)
+		}
+		if err := d.SendInput("Implement the login page"); err != nil {
+			t.Fatalf("failed to send input: %v", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		var logged bytes.Buffer
+		_, _, err := d.WaitForResponse(ctx, &logged)
+		cancel()
+		d.Stop()
+		if err != nil {
+			t.Fatalf("wait failed: %v", err)
+		}
+
+		got := logged.String()
+		want := "===== PROMPT =====\nImplement the login page\n===== END PROMPT =====\n"
+		if strings.Contains(got, want) != logPrompt {
+			t.Errorf("log_prompt=%v: unexpected task log %q", logPrompt, got)
+		}
+		if logPrompt && strings.Index(got, want) > strings.Index(got, "did the work") {
+			t.Errorf("expected the prompt to be logged before the output, got %q", got)
+		}
+	}
+}
+
+func TestDriverTimestampTaskLogs(t *testing.T) {
+	cfg := testConfig()
+	cfg.AgentCommand = []string{"bash", "-c", "cat >/dev/null; echo 'building'; echo 'boom' >&2"}
+	cfg.TimestampTaskLogs = true
+	cfg.PrefixStderr = true
+
+	d := New(cfg, testLogger(), t.TempDir())
+	if err := d.Start(); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	var logged bytes.Buffer
+	out, _, err := d.WaitForResponse(ctx, &logged)
+	cancel()
+	d.Stop()
+	if err != nil {
+		t.Fatalf("wait failed: %v", err)
+	}
+
+	stamped := regexp.MustCompile(`^(\S+) (building|\[stderr\] boom)$`)
+	lines := strings.Split(strings.TrimRight(logged.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 log lines, got %q", logged.String())
+	}
+	for _, line := range lines {
+		m := stamped.FindStringSubmatch(line)
+		if m == nil {
+			t.Fatalf("unexpected log line %q", line)
+		}
+		if _, err := time.Parse(time.RFC3339, m[1]); err != nil {
+			t.Errorf("expected an RFC3339 timestamp, got %q: %v", m[1], err)
+		}
+	}
+	if out != "building\nboom\n" {
+		t.Errorf("expected the returned output without timestamps, got %q", out)
+	}
+}
+
+func TestDriverTimestampTaskLogsAtArrival(t *testing.T) {
+	cfg := testConfig()
+	cfg.AgentCommand = []string{"bash", "-c", "cat >/dev/null; echo 'first'; sleep 1.2; echo '### TASK_DONE ###'; echo 'second'"}
+	cfg.TimestampTaskLogs = true
+	cfg.StripCompletionMarkers = true
+
+	d := New(cfg, testLogger(), t.TempDir())
+	if err := d.Start(); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	var logged bytes.Buffer
+	_, _, err := d.WaitForResponse(ctx, &logged)
+	cancel()
+	d.Stop()
+	if err != nil {
+		t.Fatalf("wait failed: %v", err)
+	}
+
+	// The marker is stripped, and each line carries the time it was
+	// written rather than the time the run ended
+	lines := strings.Split(strings.TrimRight(logged.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 log lines, got %q", logged.String())
+	}
+	var stamps []time.Time
+	for i, want := range []string{"first", "second"} {
+		stamp, text, _ := strings.Cut(lines[i], " ")
+		at, err := time.Parse(time.RFC3339, stamp)
+		if err != nil || text != want {
+			t.Fatalf("expected a stamped %q line, got %q", want, lines[i])
+		}
+		stamps = append(stamps, at)
+	}
+	if !stamps[1].After(stamps[0]) {
+		t.Errorf("expected the later line to carry a later time, got %v", stamps)
+	}
+}
+
+func TestDriverPrefixStderr(t *testing.T) {
+	for _, prefix := range []bool{false, true} {
+		cfg := testConfig()
+		cfg.AgentCommand = []string{"bash", "-c", "cat >/dev/null; echo 'building'; echo 'boom: missing file' >&2"}
+		cfg.PrefixStderr = prefix
+
+		d := New(cfg, testLogger(), t.TempDir())
+		if err := d.Start(); err != nil {
+			t.Fatalf("failed to start: %v", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		var logged bytes.Buffer
+		out, _, err := d.WaitForResponse(ctx, &logged)
+		cancel()
+		d.Stop()
+		if err != nil {
+			t.Fatalf("wait failed: %v", err)
+		}
+
+		got := logged.String()
+		if !strings.Contains(got, "building\n") {
+			t.Errorf("prefix_stderr=%v: expected stdout unprefixed, got %q", prefix, got)
+		}
+		if strings.Contains(got, "[stderr] boom: missing file\n") != prefix {
+			t.Errorf("prefix_stderr=%v: unexpected task log %q", prefix, got)
+		}
+		if strings.Contains(out, "[stderr]") {
+			t.Errorf("prefix_stderr=%v: expected the returned output untouched, got %q", prefix, out)
+		}
+	}
+}
+
+func TestDriverRoleStopTokens(t *testing.T) {
+	cfg := testConfig()
+	// A non-zero exit keeps a clean exit from passing as success
+	cfg.AgentCommand = []string{"bash", "-c", "echo 'looks good: APPROVED'; exit 1"}
+	cfg.StopTokens = []string{"COMPLETED"}
+	cfg.RoleStopTokens = map[string][]string{"reviewer": {"APPROVED", "REJECTED"}}
+
+	for _, tc := range []struct {
+		role string
+		want CompletionReason
+	}{
+		{"reviewer", CompletionStopToken},
+		{"coder", CompletionProcessError},
+		{"", CompletionProcessError},
+	} {
+		d := New(cfg, testLogger(), t.TempDir())
+		if err := d.Start(); err != nil {
+			t.Fatalf("failed to start: %v", err)
+		}
+		d.SetRole(tc.role)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_, reason, err := d.WaitForResponse(ctx, nil)
+		cancel()
+		d.Stop()
+		if err != nil {
+			t.Fatalf("role %q: wait failed: %v", tc.role, err)
+		}
+		if reason != tc.want {
+			t.Errorf("role %q: expected %s, got %s", tc.role, tc.want, reason)
+		}
+	}
+}
+
+func TestDriverRequireOutput(t *testing.T) {
+	for _, tc := range []struct {
+		require bool
+		want    CompletionReason
+	}{
+		{true, CompletionNoOutput},
+		{false, CompletionCleanExit},
+	} {
+		cfg := testConfig()
+		cfg.AgentCommand = []string{"true"}
+		cfg.RequireOutput = tc.require
+
+		d := New(cfg, testLogger(), t.TempDir())
+		if err := d.Start(); err != nil {
+			t.Fatalf("failed to start: %v", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_, reason, err := d.WaitForResponse(ctx, nil)
+		cancel()
+		d.Stop()
+		if err != nil {
+			t.Fatalf("require=%v: wait failed: %v", tc.require, err)
+		}
+		if reason != tc.want {
+			t.Errorf("require=%v: expected %s, got %s", tc.require, tc.want, reason)
+		}
+		if reason.IsSuccess() == tc.require {
+			t.Errorf("require=%v: unexpected success=%v", tc.require, reason.IsSuccess())
+		}
+	}
+}
+
+func TestDriverDockerRuntime(t *testing.T) {
+	// A stub docker on PATH echoes its argv instead of starting a container
+	binDir := t.TempDir()
+	stub := "#!/bin/sh\nfor arg in \"$@\"; do echo \"arg: $arg\"; done\necho '### TASK_DONE ###'\n"
+	if err := os.WriteFile(filepath.Join(binDir, "docker"), []byte(stub), 0755); err != nil {
+		t.Fatalf("failed to write docker stub: %v", err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	workDir := t.TempDir()
+	cfg := testConfig()
+	cfg.AgentCommand = []string{"opencode", "run"}
+	cfg.AgentRuntime = config.RuntimeDocker
+	cfg.Docker = config.DockerConfig{Image: "hive-agent:latest", Args: []string{"--network", "none"}}
+
+	d := New(cfg, testLogger(), workDir)
+	want := []string{
+		"docker", "run", "--rm", "-i",
+		"-v", workDir + ":/work", "-w", "/work",
+		"--network", "none",
+		"hive-agent:latest", "opencode", "run",
+	}
+	if got := d.Command(); !slices.Equal(got, want) {
+		t.Fatalf("unexpected docker command:\n got %q\nwant %q", got, want)
+	}
+
+	if err := d.Start(); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer d.Stop()
+	d.SendInput("do the thing")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	output, reason, err := d.WaitForResponse(ctx, nil)
+	if err != nil {
+		t.Fatalf("wait failed: %v", err)
+	}
+	if reason != CompletionMarkerFound {
+		t.Errorf("expected marker_found, got %s", reason)
+	}
+	var lines []string
+	for _, arg := range append(want[1:], "do the thing\n") {
+		lines = append(lines, "arg: "+arg)
+	}
+	if !strings.Contains(output, strings.Join(lines, "\n")) {
+		t.Errorf("docker stub got unexpected argv:\n%s", output)
+	}
+}
+
+func TestDriverDockerKillRemovesContainer(t *testing.T) {
+	// A stub docker on PATH records each call; runs hang like a container
+	// that ignores its client being killed
+	binDir := t.TempDir()
+	stub := "#!/bin/sh\necho \"$@\" >> \"$DOCKER_CALLS\"\n" +
+		"if [ \"$1\" = run ]; then [ -n \"$TOUCH_DONE\" ] && touch DONE; exec sleep 30; fi\n"
+	if err := os.WriteFile(filepath.Join(binDir, "docker"), []byte(stub), 0755); err != nil {
+		t.Fatalf("failed to write docker stub: %v", err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	tests := []struct {
+		name string
+		run  func(t *testing.T, d *Driver, clk *fakeClock) CompletionReason
+	}{
+		{
+			name: "hard deadline",
+			run: func(t *testing.T, d *Driver, clk *fakeClock) CompletionReason {
+				// Fire the deadline once the container is running
+				go func() {
+					for {
+						if _, err := os.Stat(os.Getenv("DOCKER_CALLS")); err == nil {
+							clk.fire <- clk.Now()
+							return
+						}
+						time.Sleep(10 * time.Millisecond)
+					}
+				}()
+				_, reason, _ := d.WaitForResponse(context.Background(), nil)
+				return reason
+			},
+		},
+		{
+			name: "cancel",
+			run: func(t *testing.T, d *Driver, clk *fakeClock) CompletionReason {
+				ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+				defer cancel()
+				_, reason, _ := d.WaitForResponse(ctx, nil)
+				return reason
+			},
+		},
+		{
+			name: "completion file",
+			run: func(t *testing.T, d *Driver, clk *fakeClock) CompletionReason {
+				t.Setenv("TOUCH_DONE", "1")
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				_, reason, _ := d.WaitForResponse(ctx, nil)
+				return reason
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := filepath.Join(t.TempDir(), "calls")
+			t.Setenv("DOCKER_CALLS", calls)
+
+			cfg := testConfig()
+			cfg.AgentCommand = []string{"opencode", "run"}
+			cfg.AgentRuntime = config.RuntimeDocker
+			cfg.Docker = config.DockerConfig{Image: "hive-agent:latest"}
+			cfg.CompletionFile = "DONE"
+			cfg.MaxTaskDurationSeconds = 1800
+
+			d := New(cfg, testLogger(), t.TempDir())
+			clk := newFakeClock()
+			d.clock = clk
+			d.SetTaskID("task-1")
+			if err := d.Start(); err != nil {
+				t.Fatalf("failed to start: %v", err)
+			}
+			defer d.Stop()
+
+			if reason := tt.run(t, d, clk); reason == CompletionCleanExit || reason == CompletionMarkerFound {
+				t.Fatalf("expected the run to be stopped, got %s", reason)
+			}
+
+			data, err := os.ReadFile(calls)
+			if err != nil {
+				t.Fatalf("failed to read docker calls: %v", err)
+			}
+			got := strings.Split(strings.TrimSpace(string(data)), "\n")
+			if len(got) != 2 || !strings.Contains(got[0], "run --rm -i") || !strings.Contains(got[0], "--name hive-task-1") {
+				t.Fatalf("expected a named docker run then a cleanup, got %q", got)
+			}
+			if got[1] != "rm -f hive-task-1" {
+				t.Errorf("expected the container to be removed, got %q", got[1])
+			}
+		})
+	}
+}
+
+func TestContainerName(t *testing.T) {
+	if got := config.ContainerName("task-1/fix a:b"); got != "hive-task-1-fix-a-b" {
+		t.Errorf("unexpected container name %q", got)
+	}
+}
+
+func TestDriverMaxOutputBytes(t *testing.T) {
+	cfg := testConfig()
+	cfg.AgentCommand = []string{"bash", "-c", "yes 'filler output line' | head -n 100000; echo '### TASK_DONE ###'"}
+	cfg.MaxOutputBytes = 1024
+
+	d := New(cfg, testLogger(), t.TempDir())
+	if err := d.Start(); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer d.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var logged bytes.Buffer
+	output, reason, err := d.WaitForResponse(ctx, &logged)
+	if err != nil {
+		t.Fatalf("wait failed: %v", err)
+	}
+
+	// The cap plus the truncation notice and the kept marker line
+	if len(output) > 1024+200 {
+		t.Errorf("expected output to be capped, got %d bytes", len(output))
+	}
+	if logged.Len() > 1024+200 {
+		t.Errorf("expected task log to be capped, got %d bytes", logged.Len())
+	}
+	if !strings.Contains(output, "[output truncated]") {
+		t.Error("expected truncation notice in output")
+	}
+	if reason != CompletionMarkerFound {
+		t.Errorf("marker past the cap must still be detected, got %s", reason)
+	}
+}
+
+func TestMarkerSplitAcrossWrites(t *testing.T) {
+	cfg := testConfig()
+	d := New(cfg, testLogger(), ".")
+	marker := "### TASK_DONE ###\n"
+
+	t.Run("output buffer past the cap", func(t *testing.T) {
+		buf := newOutputBuffer(8, d.isCompletionLine)
+		buf.Write([]byte("some long preamble\n"))
+		for i := 0; i < len(marker); i++ {
+			buf.Write([]byte{marker[i]})
+		}
+		if got := d.classify(buf.String(), false, false, 1); got != CompletionMarkerFound {
+			t.Errorf("expected marker fed byte by byte to be found, got %s (%q)", got, buf.String())
+		}
+	})
+
+	t.Run("interaction responder", func(t *testing.T) {
+		var stdin closeBuffer
+		cfg := testConfig()
+		cfg.InteractionRules = []config.InteractionRule{{Match: `TASK_DONE`, Response: "unexpected"}}
+		resp := newResponder(cfg, &stdin, 0, testLogger())
+
+		w := resp.watch()
+		for i := 0; i < len(marker); i++ {
+			w.Write([]byte{marker[i]})
+		}
+		if !resp.finished {
+			t.Error("expected the responder to see the marker fed byte by byte")
+		}
+		if stdin.Len() != 0 {
+			t.Errorf("marker line must not be answered, wrote %q", stdin.String())
+		}
+		if !stdin.closed {
+			t.Error("expected stdin to be closed once the marker is seen")
+		}
+	})
+
+	t.Run("agent process", func(t *testing.T) {
+		cfg := testConfig()
+		cfg.AgentCommand = []string{"bash", "-c", `for c in '#' '#' '#' ' ' T A S K _ D O N E ' ' '#' '#' '#'; do printf '%s' "$c"; sleep 0.01; done; echo; exit 1`}
+		d := New(cfg, testLogger(), t.TempDir())
+		if err := d.Start(); err != nil {
+			t.Fatalf("failed to start: %v", err)
+		}
+		defer d.Stop()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		_, reason, err := d.WaitForResponse(ctx, nil)
+		if err != nil {
+			t.Fatalf("wait failed: %v", err)
+		}
+		if reason != CompletionMarkerFound {
+			t.Errorf("expected marker written in pieces to be found, got %s", reason)
+		}
+	})
+}
+
+// chanWriter sends each write, one log record, on lines.
+type chanWriter struct {
+	lines chan string
+}
+
+func (w chanWriter) Write(p []byte) (int, error) {
+	w.lines <- string(p)
+	return len(p), nil
+}
+
+func TestDriverEventsStreamWhileRunning(t *testing.T) {
+	cfg := testConfig()
+	cfg.AgentCommand = []string{"bash", "-c", "cat >/dev/null; echo 'early'; echo 'oops' >&2; sleep 2; echo 'late'"}
+
+	d := New(cfg, testLogger(), t.TempDir())
+	events := chanWriter{lines: make(chan string, 16)}
+	d.SetEventLogger(slog.New(slog.NewTextHandler(events, nil)))
+	if err := d.Start(); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer d.Stop()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		d.WaitForResponse(ctx, nil)
+	}()
+
+	// Both lines are logged while the agent is still sleeping
+	want := map[string]bool{"type=stdout line=early": false, "type=stderr line=oops": false}
+	for remaining := len(want); remaining > 0; {
+		select {
+		case record := <-events.lines:
+			for event, seen := range want {
+				if !seen && strings.Contains(record, event) {
+					want[event] = true
+					remaining--
+				}
+			}
+		case <-done:
+			t.Fatalf("run finished before its output was logged: %v", want)
+		case <-time.After(time.Second):
+			t.Fatalf("output not logged while the agent ran: %v", want)
+		}
+	}
+
+	<-done
+	var late bool
+	for len(events.lines) > 0 {
+		late = late || strings.Contains(<-events.lines, "line=late")
+	}
+	if !late {
+		t.Error("expected the last line to be logged once the run ended")
+	}
+}
@@ -0,0 +1,201 @@
+package agent
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tuanbt/hive/internal/config"
+)
+
+// interactionRule is a compiled config.InteractionRule.
+type interactionRule struct {
+	match    *regexp.Regexp
+	response string
+}
+
+// responder answers agent output that matches an interaction rule by
+// writing the rule's response to the agent's stdin. It closes stdin once
+// the completion marker has been seen, or once the agent has been silent
+// for the idle period, so agents that read stdin to the end still finish.
+type responder struct {
+	rules  []interactionRule
+	marker string
+	idle   time.Duration
+	logger *slog.Logger
+
+	mu       sync.Mutex
+	stdin    io.WriteCloser
+	finished bool
+	closed   bool
+	timer    *time.Timer
+}
+
+// newResponder compiles the interaction rules. Returns nil if there are none.
+// Patterns are validated when the config is loaded, invalid ones are skipped.
+// An idle period of zero keeps stdin open until the marker is seen.
+func newResponder(cfg *config.Config, stdin io.WriteCloser, idle time.Duration, logger *slog.Logger) *responder {
+	var rules []interactionRule
+	for _, r := range cfg.InteractionRules {
+		re, err := regexp.Compile(r.Match)
+		if err != nil {
+			continue
+		}
+		rules = append(rules, interactionRule{match: re, response: r.Response})
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+	return &responder{
+		rules:  rules,
+		marker: cfg.CompletionMarker,
+		idle:   idle,
+		logger: logger,
+		stdin:  stdin,
+	}
+}
+
+// send writes text to the agent's stdin, unless it has been closed.
+func (r *responder) send(text string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return
+	}
+	if _, err := io.WriteString(r.stdin, text); err != nil {
+		r.logger.Warn("failed to write to agent stdin", "error", err)
+	}
+	r.touchLocked()
+}
+
+// touch restarts the idle period after agent output.
+func (r *responder) touch() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.touchLocked()
+}
+
+func (r *responder) touchLocked() {
+	if r.closed || r.idle <= 0 {
+		return
+	}
+	if r.timer == nil {
+		r.timer = time.AfterFunc(r.idle, r.closeIdle)
+		return
+	}
+	r.timer.Reset(r.idle)
+}
+
+// closeIdle closes stdin after the agent has gone quiet. A prompt would
+// have been answered by now, so the agent is either working or waiting
+// on input no rule provides.
+func (r *responder) closeIdle() {
+	r.logger.Debug("agent idle, closing stdin", "idle", r.idle)
+	r.close()
+}
+
+// close closes the agent's stdin. It is safe to call more than once.
+func (r *responder) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closeLocked()
+}
+
+func (r *responder) closeLocked() {
+	if r.closed {
+		return
+	}
+	r.closed = true
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	r.stdin.Close()
+}
+
+// handle checks one line of output and replies if a rule matches.
+// Returns true if a rule matched.
+func (r *responder) handle(line string) bool {
+	if r.checkMarker(line) {
+		return false
+	}
+
+	for _, rule := range r.rules {
+		if rule.match.MatchString(line) {
+			r.logger.Info("answering agent prompt", "match", rule.match.String())
+			r.send(rule.response + "\n")
+			return true
+		}
+	}
+	return false
+}
+
+// checkMarker records the completion marker if line contains it. Returns
+// true once the responder has finished answering.
+func (r *responder) checkMarker(line string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.finished && r.marker != "" && strings.Contains(line, r.marker) {
+		// No rule fires after the marker, so the agent gets EOF
+		r.finished = true
+		r.closeLocked()
+	}
+	return r.finished
+}
+
+// endsInMarkerPrefix reports whether s ends with the start of the marker,
+// so a partial line may still turn into the marker line.
+func (r *responder) endsInMarkerPrefix(s string) bool {
+	for k := min(len(s), len(r.marker)-1); k > 0; k-- {
+		if strings.HasSuffix(s, r.marker[:k]) {
+			return true
+		}
+	}
+	return false
+}
+
+// watch returns a writer that feeds one output stream to the responder.
+func (r *responder) watch() io.Writer {
+	return &lineWatcher{r: r}
+}
+
+// lineWatcher splits a stream into lines for the responder. A trailing
+// partial line is also checked, since prompts often don't end in a newline.
+// A line stays buffered until its newline even once answered, so output
+// written in pieces is always matched as a whole line.
+type lineWatcher struct {
+	r        *responder
+	partial  []byte
+	answered bool // the buffered partial line has been replied to
+}
+
+func (w *lineWatcher) Write(p []byte) (int, error) {
+	w.r.touch()
+	w.partial = append(w.partial, p...)
+	for {
+		idx := bytes.IndexByte(w.partial, '\n')
+		if idx < 0 {
+			break
+		}
+		line := string(w.partial[:idx])
+		if w.answered {
+			w.r.checkMarker(line)
+		} else {
+			w.r.handle(line)
+		}
+		w.answered = false
+		w.partial = w.partial[idx+1:]
+	}
+
+	// Hold off while the partial line could still become the marker
+	if len(w.partial) > 0 && !w.answered {
+		line := string(w.partial)
+		if !w.r.endsInMarkerPrefix(line) {
+			w.answered = w.r.handle(line)
+		}
+	}
+	return len(p), nil
+}
@@ -0,0 +1,67 @@
+package agent
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Event types understood in ndjson output mode.
+const (
+	eventText = "text"
+	eventTool = "tool"
+	eventDone = "done"
+)
+
+// ndjsonEvent is a single structured line emitted by an agent.
+type ndjsonEvent struct {
+	Type string `json:"type"`
+	Text string `json:"text,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+// parseNDJSON converts newline-delimited JSON events into plain log text.
+// Text events are passed through, tool events are labelled, and a done event
+// signals completion. Lines that are not valid JSON are kept verbatim.
+func parseNDJSON(raw string) (string, bool) {
+	var out strings.Builder
+	done := false
+
+	for _, line := range strings.Split(raw, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+
+		var ev ndjsonEvent
+		if err := json.Unmarshal([]byte(trimmed), &ev); err != nil || ev.Type == "" {
+			out.WriteString(line + "\n")
+			continue
+		}
+
+		switch ev.Type {
+		case eventText:
+			out.WriteString(ev.Text)
+			if !strings.HasSuffix(ev.Text, "\n") {
+				out.WriteString("\n")
+			}
+		case eventTool:
+			out.WriteString("[tool] " + ev.Name)
+			if ev.Text != "" {
+				out.WriteString(": " + ev.Text)
+			}
+			out.WriteString("\n")
+		case eventDone:
+			done = true
+		default:
+			out.WriteString("[" + ev.Type + "] " + ev.Text + "\n")
+		}
+	}
+
+	return out.String(), done
+}
+
+// isDoneEvent reports whether a raw output line is a done event.
+func isDoneEvent(line string) bool {
+	var ev ndjsonEvent
+	return json.Unmarshal([]byte(strings.TrimSpace(line)), &ev) == nil && ev.Type == eventDone
+}
@@ -0,0 +1,153 @@
+package agent
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tuanbt/hive/internal/config"
+)
+
+// maxStreamLine bounds how much of a line without a newline lineWriter
+// holds. Longer lines are passed on in pieces.
+const maxStreamLine = 64 * 1024
+
+// lineWriter passes every line written to it to emit as soon as its
+// newline arrives.
+type lineWriter struct {
+	mu      sync.Mutex
+	partial []byte
+	emit    func(line string)
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.partial = append(w.partial, p...)
+	for {
+		idx := bytes.IndexByte(w.partial, '\n')
+		if idx < 0 {
+			break
+		}
+		w.emit(string(w.partial[:idx]))
+		w.partial = w.partial[idx+1:]
+	}
+	if len(w.partial) > maxStreamLine {
+		w.emit(string(w.partial))
+		w.partial = nil
+	}
+	return len(p), nil
+}
+
+// flush emits a last line left without a newline.
+func (w *lineWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.partial) > 0 {
+		w.emit(string(w.partial))
+		w.partial = nil
+	}
+}
+
+// outputStream passes the output lines of one run on as the agent writes
+// them: to the event logger, classified as stdout, stderr or marker, and
+// with timestamp_task_logs to the task log, stamped with the time they
+// were written.
+type outputStream struct {
+	d       *Driver
+	events  *slog.Logger
+	taskLog io.Writer
+
+	mu        sync.Mutex
+	size      map[string]int  // bytes seen per stream, to honour MaxOutputBytes
+	truncated map[string]bool // streams whose truncation notice was logged
+}
+
+// newOutputStream returns the stream for a run, or nil when nothing
+// consumes output lines as they arrive. taskLogger is only used when task
+// log lines are timestamped.
+func (d *Driver) newOutputStream(taskLogger io.Writer) *outputStream {
+	d.mu.Lock()
+	events := d.events
+	d.mu.Unlock()
+
+	var taskLog io.Writer
+	if d.config.TimestampTaskLogs {
+		taskLog = taskLogger
+	}
+	if events == nil && taskLog == nil {
+		return nil
+	}
+	return &outputStream{
+		d:         d,
+		events:    events,
+		taskLog:   taskLog,
+		size:      make(map[string]int),
+		truncated: make(map[string]bool),
+	}
+}
+
+// writer returns a writer that feeds the named stream, stdout or stderr,
+// into s line by line.
+func (s *outputStream) writer(stream string) *lineWriter {
+	return &lineWriter{emit: func(line string) { s.line(stream, line) }}
+}
+
+// line handles one raw output line of stream.
+func (s *outputStream) line(stream, raw string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Past the size limit only completion lines are kept, as in the
+	// captured output
+	s.size[stream] += len(raw) + 1
+	if limit := s.d.config.MaxOutputBytes; limit > 0 && s.size[stream] > limit {
+		if !s.truncated[stream] {
+			s.truncated[stream] = true
+			s.logLine(stream, truncatedNotice)
+		}
+		if !s.d.isCompletionLine(raw) {
+			return
+		}
+	}
+
+	lines := []string{raw}
+	if stream == "stdout" && s.d.config.AgentOutputFormat == config.OutputFormatNDJSON {
+		text, _ := parseNDJSON(raw)
+		lines = nil
+		if text != "" {
+			lines = strings.Split(strings.TrimRight(text, "\n"), "\n")
+		}
+	}
+	for _, line := range lines {
+		marker := s.d.isMarkerLine(line)
+		if s.events != nil && line != "" {
+			kind := stream
+			if marker {
+				kind = "marker"
+			}
+			s.events.Info("output", "type", kind, "line", line)
+		}
+		if !marker || !s.d.config.StripCompletionMarkers {
+			s.logLine(stream, line)
+		}
+	}
+}
+
+// logLine writes line to the task log, if s feeds it, stamped with the
+// current time.
+func (s *outputStream) logLine(stream, line string) {
+	if s.taskLog == nil {
+		return
+	}
+	if stream == "stderr" && s.d.config.PrefixStderr && line != truncatedNotice {
+		line = stderrPrefix + line
+	}
+	fmt.Fprintln(s.taskLog, s.d.clock.Now().Format(time.RFC3339)+" "+line)
+}
@@ -5,62 +5,280 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/tuanbt/hive/internal/task"
+)
+
+// Supported values for AgentOutputFormat.
+const (
+	OutputFormatText   = "text"
+	OutputFormatNDJSON = "ndjson"
+)
+
+// Supported values for WorkerLayout.
+const (
+	LayoutAuto    = "auto"
+	LayoutRows    = "rows"
+	LayoutColumns = "columns"
+	LayoutGrid    = "grid"
+)
+
+// ValidLayout reports whether layout is a supported WorkerLayout.
+func ValidLayout(layout string) bool {
+	switch layout {
+	case LayoutAuto, LayoutRows, LayoutColumns, LayoutGrid:
+		return true
+	}
+	return false
+}
+
+// Worker count limits. NumWorkers above DefaultMaxWorkers requires
+// AllowHighConcurrency; MaxWorkersHardLimit can never be exceeded.
+const (
+	DefaultMaxWorkers   = 10
+	MaxWorkersHardLimit = 64
 )
 
 // Config represents the orchestrator configuration.
 type Config struct {
 	// AgentCommand is the command to start OpenCode.
 	AgentCommand []string `json:"agent_command"`
+
+	// ReviewAgentCommand runs the review cycles instead of AgentCommand,
+	// e.g. a cheaper or stricter model. Empty reviews with AgentCommand.
+	ReviewAgentCommand []string `json:"review_agent_command"`
 	// AgentMode is the mode in which the agent operates (currently only "episodic" supported).
 	AgentMode string `json:"agent_mode"`
 
+	// AgentOutputFormat is how agent stdout is interpreted ("text" or "ndjson").
+	AgentOutputFormat string `json:"agent_output_format"`
+
+	// AgentRuntime is where the agent command runs: "native" on the host or
+	// "docker" in a container configured by Docker.
+	AgentRuntime string `json:"agent_runtime"`
+
+	// Docker configures the container used when AgentRuntime is "docker".
+	Docker DockerConfig `json:"docker"`
+
+	// AgentStartupDelayMillis is how long the driver waits after starting
+	// the agent before it is considered ready for input.
+	AgentStartupDelayMillis int `json:"agent_startup_delay_millis"`
+
 	// NumWorkers is the number of parallel workers to run.
 	NumWorkers int `json:"num_workers"`
 
+	// AllowHighConcurrency lifts the default worker cap up to MaxWorkersHardLimit.
+	AllowHighConcurrency bool `json:"allow_high_concurrency"`
+
 	// ResponseTimeoutSeconds is the silence timeout for completion detection.
 	ResponseTimeoutSeconds int `json:"response_timeout_seconds"`
 
 	// MaxTaskDurationSeconds is the maximum time allowed for a single task.
 	MaxTaskDurationSeconds int `json:"max_task_duration_seconds"`
 
+	// DispatchIntervalSeconds is how often the dispatcher polls for pending tasks.
+	DispatchIntervalSeconds int `json:"dispatch_interval_seconds"`
+
+	// DispatchMaxIntervalSeconds caps how far the poll interval backs off
+	// while no tasks are pending.
+	DispatchMaxIntervalSeconds int `json:"dispatch_max_interval_seconds"`
+
+	// ShutdownTimeoutSeconds is how long shutdown waits for in-flight tasks
+	// to drain before their agents are killed.
+	ShutdownTimeoutSeconds int `json:"shutdown_timeout_seconds"`
+
 	// MaxReviewCycles is the number of retry attempts for the review phase.
 	MaxReviewCycles int `json:"max_review_cycles"`
 
 	// MaxRestartAttempts is the maximum number of agent restart attempts.
 	MaxRestartAttempts int `json:"max_restart_attempts"`
 
-	// MaxTaskRetries is the maximum number of times to retry a failed task.
-	MaxTaskRetries int `json:"max_task_retries"`
-
 	// RestartCooldownSeconds is the exponential backoff for restarts.
 	RestartCooldownSeconds []int `json:"restart_cooldown_seconds"`
 
 	// CompletionMarker is the string that indicates task completion.
 	CompletionMarker string `json:"completion_marker"`
 
+	// AppendCompletionInstruction adds a closing line to every implementation
+	// prompt telling the agent to end its response with CompletionMarker,
+	// for agents that forget it or templates that don't ask for it.
+	AppendCompletionInstruction bool `json:"append_completion_instruction"`
+
+	// BlockedMarker is the string an agent prints when it cannot finish
+	// without human input. The task ends blocked and is not retried. An
+	// explicitly empty value turns blocked detection off.
+	BlockedMarker string `json:"blocked_marker"`
+
+	// CompletionFile is a sentinel file, relative to the work directory, that
+	// the agent creates when finished. It is cleared before each run.
+	CompletionFile string `json:"completion_file"`
+
 	// StopTokens are additional tokens that indicate completion.
 	StopTokens []string `json:"stop_tokens"`
 
+	// RoleStopTokens adds stop tokens for tasks with a given role, on top
+	// of StopTokens.
+	RoleStopTokens map[string][]string `json:"role_stop_tokens"`
+
+	// MaxOutputBytes caps how much of each agent output stream is kept in
+	// memory and written to the task log. Zero means no limit.
+	MaxOutputBytes int `json:"max_output_bytes"`
+
+	// StripCompletionMarkers omits lines holding the completion marker or a
+	// stop token from the task log and the returned output. They are still
+	// used for completion detection.
+	StripCompletionMarkers bool `json:"strip_completion_markers"`
+
+	// RequireOutput fails a run that exits without writing anything, even
+	// with a success exit code, to catch misconfigured agent commands.
+	RequireOutput bool `json:"require_output"`
+
+	// LogPrompt writes each prompt sent to the agent into the task log
+	// ahead of its output, so the log records what the agent saw.
+	LogPrompt bool `json:"log_prompt"`
+
+	// PrefixStderr marks each line the agent wrote to stderr with
+	// "[stderr] " in the task log, so errors stand apart from normal
+	// output. The returned output is unchanged.
+	PrefixStderr bool `json:"prefix_stderr"`
+
+	// TimestampTaskLogs starts each agent output line in the task log with
+	// the RFC3339 time it arrived, to line it up with orchestrator events
+	// and other tasks' logs.
+	TimestampTaskLogs bool `json:"timestamp_task_logs"`
+
+	// SuccessExitCodes are the agent exit codes treated as success when the
+	// output has no completion marker.
+	SuccessExitCodes []int `json:"success_exit_codes"`
+
+	// InteractionRules answer agent output lines matching a pattern by
+	// writing a response to the agent's stdin, until the completion marker
+	// is seen. With no rules stdin is closed after the prompt is written.
+	InteractionRules []InteractionRule `json:"interaction_rules"`
+
 	// LogDirectory is the directory for log files.
 	LogDirectory string `json:"log_directory"`
 
 	// LogLevel sets the logging verbosity (debug, info, warn, error).
 	LogLevel string `json:"log_level"`
 
+	// HealthAddr is the address, such as ":8081", of the HTTP server
+	// answering the /healthz and /readyz probes. Empty disables it.
+	HealthAddr string `json:"health_addr"`
+
+	// LogToStdout mirrors the system log to stdout as well as the log file.
+	// Turn it off to log to the file only.
+	LogToStdout bool `json:"log_to_stdout"`
+
 	// RecoverInProgressOnStartup resets in_progress tasks to pending on startup.
 	RecoverInProgressOnStartup bool `json:"recover_in_progress_on_startup"`
 
+	// StuckTaskThresholdSeconds limits startup recovery to active tasks whose
+	// heartbeat is older than this. Zero resets every active task.
+	StuckTaskThresholdSeconds int `json:"stuck_task_threshold_seconds"`
+
+	// AgeStepMinutes raises a pending task's priority by one for every
+	// step it has waited when the next task is picked, so low-priority
+	// tasks are not starved by a steady stream of urgent ones. Zero turns
+	// aging off.
+	AgeStepMinutes int `json:"age_step_minutes"`
+
+	// DispatchOrder picks the next pending task: "priority" (the default)
+	// takes the highest priority, "fifo" the oldest task and "lifo" the
+	// newest. Priority aging only applies to "priority".
+	DispatchOrder task.DispatchOrder `json:"dispatch_order"`
+
 	// TasksFile is the path to the tasks JSON file.
 	TasksFile string `json:"tasks_file"`
 
+	// TaskIDFormat is the template for new task IDs. {seq} is a counter
+	// kept in the tasks file, {date} the creation date as YYYYMMDD,
+	// {nanos} the creation time in nanoseconds and {short} six random hex
+	// digits, so "T-{seq}" gives T-1, T-2 and so on.
+	TaskIDFormat string `json:"task_id_format"`
+
+	// AuditFile is an optional NDJSON file recording every task status change.
+	AuditFile string `json:"audit_file"`
+
+	// StripANSI removes terminal escape sequences from agent output shown in the TUI.
+	StripANSI bool `json:"strip_ansi"`
+
+	// StructuredTaskLogs additionally writes each task's phases and agent
+	// output lines as NDJSON to <id>.events.jsonl for automated analysis.
+	StructuredTaskLogs bool `json:"structured_task_logs"`
+
+	// LogPreloadLines is how many trailing log lines the TUI loads when a
+	// task is selected.
+	LogPreloadLines int `json:"log_preload_lines"`
+
 	// WorkDirectory is the working directory for task execution.
 	WorkDirectory string `json:"work_directory"`
 
 	// GitIntegration handles git workflow automation.
 	GitIntegration GitConfig `json:"git_integration"`
 
+	// MaxConcurrentGitOps bounds how many git operations, branching a task
+	// at dispatch or committing, pushing and opening a pull request for a
+	// finished one, run at once. Tasks share one working tree, where
+	// concurrent git commands contend for the index lock and the checked
+	// out branch, so it cannot exceed 1 until tasks get separate worktrees.
+	MaxConcurrentGitOps int `json:"max_concurrent_git_ops"`
+
 	// Instructions defines system prompts and rules.
 	Instructions InstructionConfig `json:"instructions"`
+
+	// PromptTemplate is a text/template for the implementation prompt.
+	// Empty uses DefaultPromptTemplate.
+	PromptTemplate string `json:"prompt_template"`
+
+	// RolePriorities maps a role to the priority given to new tasks with
+	// that role when no explicit priority is set.
+	RolePriorities map[string]int `json:"role_priorities"`
+
+	// PlanningTriggers are title prefixes, matched case-insensitively, that
+	// make the TUI assign PlanningRole to a new task. An empty list turns
+	// the detection off.
+	PlanningTriggers []string `json:"planning_triggers"`
+
+	// PlanningRole is the role given to tasks matching PlanningTriggers.
+	PlanningRole string `json:"planning_role"`
+
+	// MaxPlanTasks caps how many subtasks a single agent plan may add; the
+	// rest are dropped with a warning. Zero means no limit.
+	MaxPlanTasks int `json:"max_plan_tasks"`
+
+	// ReviewPlans holds agent plans in the TUI for approval before their
+	// subtasks are queued. Headless runs always add plans right away, and
+	// on start they add any plans a TUI left held.
+	ReviewPlans bool `json:"review_plans"`
+
+	// AutoRetry decides which failed tasks are dispatched again
+	// automatically and how long each retry waits.
+	AutoRetry AutoRetryConfig `json:"auto_retry"`
+
+	// MaxTaskRetries is the retry limit from before AutoRetry existed.
+	//
+	// Deprecated: use AutoRetry.MaxAutoRetries. Load moves the value there.
+	MaxTaskRetries *int `json:"max_task_retries,omitempty"`
+
+	// Warnings lists problems Load worked around, such as deprecated keys,
+	// for the caller to report.
+	Warnings []string `json:"-"`
+
+	// Webhooks configures HTTP notifications for finished tasks.
+	Webhooks WebhookConfig `json:"webhooks"`
+
+	// Theme sets the TUI colors.
+	Theme ThemeConfig `json:"theme"`
+
+	// WorkerLayout arranges the TUI worker panel: "rows" stacks workers,
+	// "columns" puts them side by side, "grid" tiles them and "auto"
+	// picks one from the worker count.
+	WorkerLayout string `json:"worker_layout"`
 }
 
 // InstructionConfig holds global and role-based instructions.
@@ -69,7 +287,16 @@ type InstructionConfig struct {
 	RoleInstructions map[string]string `json:"role_instructions"`
 }
 
-// GitConfig holds configuration for git integration.
+// InteractionRule is a scripted reply to an agent prompt. Match is a
+// regular expression tested against each output line.
+type InteractionRule struct {
+	Match    string `json:"match"`
+	Response string `json:"response"`
+}
+
+// GitConfig holds configuration for git integration. Tasks share the
+// working tree, so with git integration enabled they run one at a time and
+// each commit holds only its own task's changes.
 type GitConfig struct {
 	Enabled             bool   `json:"enabled"`
 	BaseBranch          string `json:"base_branch"`
@@ -78,26 +305,101 @@ type GitConfig struct {
 	CommitMessageFormat string `json:"commit_message_format"`
 	CreatePR            bool   `json:"create_pr"`
 	PRTitleFormat       string `json:"pr_title_format"`
+
+	// InspectCmd is a shell command template the TUI runs to inspect a
+	// task's branch, e.g. "git log --oneline {{.Branch}}". It may use
+	// {{.TaskID}} and {{.Branch}}.
+	InspectCmd string `json:"inspect_command"`
+}
+
+// DockerConfig holds the container settings for the docker agent runtime.
+type DockerConfig struct {
+	// Image is the image the agent command runs in.
+	Image string `json:"image"`
+
+	// Args are extra arguments passed to docker run before the image,
+	// e.g. ["--network", "none"].
+	Args []string `json:"args"`
+}
+
+// AutoRetryConfig holds the retry policy for failed tasks.
+type AutoRetryConfig struct {
+	// MaxAutoRetries is how many times a task is retried before it stays
+	// failed. Zero turns automatic retries off.
+	MaxAutoRetries int `json:"max_auto_retries"`
+
+	// RetryBackoffSeconds is the wait before each retry; the last entry is
+	// reused for later ones. An empty list retries immediately.
+	RetryBackoffSeconds []int `json:"retry_backoff_seconds"`
+
+	// AutoRetryStatuses are the completion reasons of the failures worth
+	// retrying, e.g. "hard_timeout". Other failures, such as a rejected
+	// review, go straight to failed.
+	AutoRetryStatuses []string `json:"auto_retry_statuses"`
+}
+
+// WebhookConfig holds the URLs notified when a task reaches a terminal state.
+// An empty URL disables the corresponding notification.
+type WebhookConfig struct {
+	OnComplete     string `json:"on_complete"`
+	OnFail         string `json:"on_fail"`
+	TimeoutSeconds int    `json:"timeout_seconds"`
 }
 
+// ThemeConfig holds the TUI palette as hex color strings (#RGB or #RRGGBB).
+type ThemeConfig struct {
+	Foreground string `json:"foreground"`
+	Background string `json:"background"`
+	Primary    string `json:"primary"`
+	Dim        string `json:"dim"`
+	Error      string `json:"error"`
+
+	// StatusIcons maps a task status to the icon shown before its title in
+	// the task list, e.g. "[P]" for terminals without emoji fonts.
+	StatusIcons map[string]string `json:"status_icons"`
+
+	// StatusColors maps a task status to the color of its list entry.
+	// Statuses without a color use the dim color.
+	StatusColors map[string]string `json:"status_colors"`
+}
+
+// hexColorPattern matches #RGB and #RRGGBB colors.
+var hexColorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
 // DefaultConfig returns a Config with sensible defaults.
 func DefaultConfig() *Config {
 	return &Config{
 		AgentCommand:               []string{"opencode", "run"},
 		AgentMode:                  "episodic",
+		AgentOutputFormat:          OutputFormatText,
+		AgentRuntime:               RuntimeNative,
 		NumWorkers:                 1,
+		MaxConcurrentGitOps:        1,
 		ResponseTimeoutSeconds:     60,
 		MaxTaskDurationSeconds:     1800, // 30 minutes
+		DispatchIntervalSeconds:    2,
+		DispatchMaxIntervalSeconds: 10,
+		ShutdownTimeoutSeconds:     30,
 		MaxReviewCycles:            3,
 		MaxRestartAttempts:         3,
-		MaxTaskRetries:             3,
 		RestartCooldownSeconds:     []int{5, 15, 60},
 		CompletionMarker:           "### TASK_DONE ###",
+		BlockedMarker:              "### TASK_BLOCKED ###",
 		StopTokens:                 []string{"TASK_COMPLETED", "### TASK_DONE ###"},
+		SuccessExitCodes:           []int{0},
 		LogDirectory:               "./logs",
 		LogLevel:                   "info",
+		WorkerLayout:               LayoutAuto,
+		LogToStdout:                true,
 		RecoverInProgressOnStartup: true,
+		RequireOutput:              true,
+		PlanningTriggers:           []string{"i want", "build", "create", "plan"},
+		PlanningRole:               "ba",
+		MaxPlanTasks:               20,
+		DispatchOrder:              task.DispatchPriority,
 		TasksFile:                  "tasks.json",
+		TaskIDFormat:               task.DefaultIDFormat,
+		LogPreloadLines:            500,
 
 		WorkDirectory: ".",
 		GitIntegration: GitConfig{
@@ -123,6 +425,21 @@ func DefaultConfig() *Config {
 				"qa":        "You are a QA Engineer. Focus on comprehensive testing strategies, edge cases, and security vulnerabilities.",
 			},
 		},
+		AutoRetry: AutoRetryConfig{
+			MaxAutoRetries:      3,
+			RetryBackoffSeconds: []int{10, 30, 60},
+			AutoRetryStatuses:   []string{"hard_timeout", "process_error", "no_output"},
+		},
+		Webhooks: WebhookConfig{
+			TimeoutSeconds: 10,
+		},
+		Theme: ThemeConfig{
+			Foreground: "#00FF00",
+			Background: "#000000",
+			Primary:    "#00FF00",
+			Dim:        "#006400",
+			Error:      "#FF0000",
+		},
 	}
 }
 
@@ -142,6 +459,9 @@ func Load(path string) (*Config, error) {
 	if err := json.Unmarshal(data, cfg); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
+	if err := cfg.migrateRetries(data); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
 
 	// Apply defaults for zero values
 	cfg.applyDefaults()
@@ -150,6 +470,10 @@ func Load(path string) (*Config, error) {
 		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
 
+	if err := cfg.ValidatePaths(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
 	return cfg, nil
 }
 
@@ -160,15 +484,49 @@ func (c *Config) applyDefaults() {
 	if len(c.AgentCommand) == 0 {
 		c.AgentCommand = defaults.AgentCommand
 	}
+	if c.AgentOutputFormat == "" {
+		c.AgentOutputFormat = defaults.AgentOutputFormat
+	}
+	if c.AgentRuntime == "" {
+		c.AgentRuntime = defaults.AgentRuntime
+	}
+	if c.WorkerLayout == "" {
+		c.WorkerLayout = defaults.WorkerLayout
+	}
+	if c.DispatchOrder == "" {
+		c.DispatchOrder = defaults.DispatchOrder
+	}
+	if c.TaskIDFormat == "" {
+		c.TaskIDFormat = defaults.TaskIDFormat
+	}
+	// Only a missing list gets the defaults, an empty one disables detection
+	if c.PlanningTriggers == nil {
+		c.PlanningTriggers = defaults.PlanningTriggers
+	}
+	if c.PlanningRole == "" {
+		c.PlanningRole = defaults.PlanningRole
+	}
 	if c.NumWorkers <= 0 {
 		c.NumWorkers = defaults.NumWorkers
 	}
+	if c.MaxConcurrentGitOps <= 0 {
+		c.MaxConcurrentGitOps = defaults.MaxConcurrentGitOps
+	}
 	if c.ResponseTimeoutSeconds <= 0 {
 		c.ResponseTimeoutSeconds = defaults.ResponseTimeoutSeconds
 	}
 	if c.MaxTaskDurationSeconds <= 0 {
 		c.MaxTaskDurationSeconds = defaults.MaxTaskDurationSeconds
 	}
+	if c.DispatchIntervalSeconds <= 0 {
+		c.DispatchIntervalSeconds = defaults.DispatchIntervalSeconds
+	}
+	if c.DispatchMaxIntervalSeconds <= 0 {
+		c.DispatchMaxIntervalSeconds = defaults.DispatchMaxIntervalSeconds
+	}
+	if c.ShutdownTimeoutSeconds <= 0 {
+		c.ShutdownTimeoutSeconds = defaults.ShutdownTimeoutSeconds
+	}
 	if c.MaxReviewCycles <= 0 {
 		c.MaxReviewCycles = defaults.MaxReviewCycles
 	}
@@ -184,6 +542,9 @@ func (c *Config) applyDefaults() {
 	if len(c.StopTokens) == 0 {
 		c.StopTokens = defaults.StopTokens
 	}
+	if len(c.SuccessExitCodes) == 0 {
+		c.SuccessExitCodes = defaults.SuccessExitCodes
+	}
 	if c.LogDirectory == "" {
 		c.LogDirectory = defaults.LogDirectory
 	}
@@ -196,6 +557,27 @@ func (c *Config) applyDefaults() {
 	if c.WorkDirectory == "" {
 		c.WorkDirectory = defaults.WorkDirectory
 	}
+	if c.LogPreloadLines <= 0 {
+		c.LogPreloadLines = defaults.LogPreloadLines
+	}
+	if c.Webhooks.TimeoutSeconds <= 0 {
+		c.Webhooks.TimeoutSeconds = defaults.Webhooks.TimeoutSeconds
+	}
+	if c.Theme.Foreground == "" {
+		c.Theme.Foreground = defaults.Theme.Foreground
+	}
+	if c.Theme.Background == "" {
+		c.Theme.Background = defaults.Theme.Background
+	}
+	if c.Theme.Primary == "" {
+		c.Theme.Primary = defaults.Theme.Primary
+	}
+	if c.Theme.Dim == "" {
+		c.Theme.Dim = defaults.Theme.Dim
+	}
+	if c.Theme.Error == "" {
+		c.Theme.Error = defaults.Theme.Error
+	}
 }
 
 // Validate checks that the configuration is valid.
@@ -203,8 +585,11 @@ func (c *Config) Validate() error {
 	if c.NumWorkers < 1 {
 		return fmt.Errorf("num_workers must be at least 1, got %d", c.NumWorkers)
 	}
-	if c.NumWorkers > 10 {
-		return fmt.Errorf("num_workers should not exceed 10, got %d", c.NumWorkers)
+	if c.NumWorkers > MaxWorkersHardLimit {
+		return fmt.Errorf("num_workers cannot exceed %d, got %d", MaxWorkersHardLimit, c.NumWorkers)
+	}
+	if c.NumWorkers > DefaultMaxWorkers && !c.AllowHighConcurrency {
+		return fmt.Errorf("num_workers should not exceed %d, got %d (set allow_high_concurrency to override)", DefaultMaxWorkers, c.NumWorkers)
 	}
 	if c.ResponseTimeoutSeconds < 1 {
 		return fmt.Errorf("response_timeout_seconds must be at least 1, got %d", c.ResponseTimeoutSeconds)
@@ -218,10 +603,74 @@ func (c *Config) Validate() error {
 	if c.MaxRestartAttempts < 1 {
 		return fmt.Errorf("max_restart_attempts must be at least 1, got %d", c.MaxRestartAttempts)
 	}
+	if c.AgentStartupDelayMillis < 0 {
+		return fmt.Errorf("agent_startup_delay_millis cannot be negative, got %d", c.AgentStartupDelayMillis)
+	}
+	if c.MaxOutputBytes < 0 {
+		return fmt.Errorf("max_output_bytes cannot be negative, got %d", c.MaxOutputBytes)
+	}
+	if c.AgeStepMinutes < 0 {
+		return fmt.Errorf("age_step_minutes cannot be negative, got %d", c.AgeStepMinutes)
+	}
+	if !c.DispatchOrder.IsValid() {
+		return fmt.Errorf("dispatch_order must be priority, fifo or lifo, got %q", c.DispatchOrder)
+	}
+	if err := task.ValidateIDFormat(c.TaskIDFormat); err != nil {
+		return fmt.Errorf("invalid task_id_format: %w", err)
+	}
+	if c.MaxConcurrentGitOps > 1 {
+		return fmt.Errorf("max_concurrent_git_ops cannot exceed 1 while tasks share one working tree, got %d", c.MaxConcurrentGitOps)
+	}
+	if c.MaxPlanTasks < 0 {
+		return fmt.Errorf("max_plan_tasks cannot be negative, got %d", c.MaxPlanTasks)
+	}
+	if c.StuckTaskThresholdSeconds < 0 {
+		return fmt.Errorf("stuck_task_threshold_seconds cannot be negative, got %d", c.StuckTaskThresholdSeconds)
+	}
 	if len(c.AgentCommand) == 0 {
 		return fmt.Errorf("agent_command cannot be empty")
 	}
 
+	if err := c.validatePromptTemplate(); err != nil {
+		return err
+	}
+
+	if err := c.Theme.validate(); err != nil {
+		return err
+	}
+
+	if err := c.GitIntegration.validate(); err != nil {
+		return err
+	}
+
+	for i, rule := range c.InteractionRules {
+		if rule.Match == "" {
+			return fmt.Errorf("interaction_rules[%d].match cannot be empty", i)
+		}
+		if _, err := regexp.Compile(rule.Match); err != nil {
+			return fmt.Errorf("invalid interaction_rules[%d].match: %w", i, err)
+		}
+	}
+
+	switch c.AgentOutputFormat {
+	case OutputFormatText, OutputFormatNDJSON:
+		// Valid
+	default:
+		return fmt.Errorf("invalid agent_output_format: %s (must be text or ndjson)", c.AgentOutputFormat)
+	}
+
+	if !ValidLayout(c.WorkerLayout) {
+		return fmt.Errorf("invalid worker_layout: %s (must be auto, rows, columns, or grid)", c.WorkerLayout)
+	}
+
+	if err := c.Docker.validate(c.AgentRuntime); err != nil {
+		return err
+	}
+
+	if err := c.AutoRetry.validate(); err != nil {
+		return err
+	}
+
 	// Validate log level
 	switch c.LogLevel {
 	case "debug", "info", "warn", "error":
@@ -233,6 +682,130 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// validate checks that every theme color is a hex color.
+func (t ThemeConfig) validate() error {
+	colors := []struct {
+		name  string
+		value string
+	}{
+		{"foreground", t.Foreground},
+		{"background", t.Background},
+		{"primary", t.Primary},
+		{"dim", t.Dim},
+		{"error", t.Error},
+	}
+	for _, c := range colors {
+		if !hexColorPattern.MatchString(c.value) {
+			return fmt.Errorf("invalid theme.%s: %q (must be a hex color like #00FF00)", c.name, c.value)
+		}
+	}
+
+	for status := range t.StatusIcons {
+		if !task.Status(status).IsValid() {
+			return fmt.Errorf("invalid theme.status_icons: unknown status %q", status)
+		}
+	}
+	for status, color := range t.StatusColors {
+		if !task.Status(status).IsValid() {
+			return fmt.Errorf("invalid theme.status_colors: unknown status %q", status)
+		}
+		if !hexColorPattern.MatchString(color) {
+			return fmt.Errorf("invalid theme.status_colors.%s: %q (must be a hex color like #00FF00)", status, color)
+		}
+	}
+	return nil
+}
+
+// ValidatePaths checks that the configured directories exist on disk.
+// It is kept separate from Validate so the built-in defaults can be used
+// without touching the filesystem, and only reads, so it is safe for
+// read-only commands. See CheckWritable for commands that write.
+func (c *Config) ValidatePaths() error {
+	info, err := os.Stat(c.WorkDirectory)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("work_directory does not exist: %s", c.WorkDirectory)
+		}
+		return fmt.Errorf("cannot access work_directory %s: %w", c.WorkDirectory, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("work_directory is not a directory: %s", c.WorkDirectory)
+	}
+
+	tasksDir := filepath.Dir(c.TasksFile)
+	info, err = os.Stat(tasksDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("tasks_file directory does not exist: %s", tasksDir)
+		}
+		return fmt.Errorf("cannot access tasks_file directory %s: %w", tasksDir, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("tasks_file directory is not a directory: %s", tasksDir)
+	}
+	return nil
+}
+
+// CheckWritable reports whether the tasks file directory accepts writes by
+// creating and removing a throwaway file in it. Commands that write the
+// tasks file call it at startup to fail before any work is done.
+func (c *Config) CheckWritable() error {
+	tasksDir := filepath.Dir(c.TasksFile)
+	probe, err := os.CreateTemp(tasksDir, ".hive-write-check-*")
+	if err != nil {
+		return fmt.Errorf("tasks_file directory is not writable: %s", tasksDir)
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+
+	return nil
+}
+
+// StopTokensFor returns the stop tokens that apply to a task with the given
+// role: the global StopTokens followed by any tokens listed for the role.
+func (c *Config) StopTokensFor(role string) []string {
+	extra := c.RoleStopTokens[role]
+	if len(extra) == 0 {
+		return c.StopTokens
+	}
+	return append(slices.Clone(c.StopTokens), extra...)
+}
+
+// Roles returns the task roles the config knows about, sorted: those with
+// instructions, a default priority or stop tokens, and PlanningRole.
+func (c *Config) Roles() []string {
+	var roles []string
+	for role := range c.Instructions.RoleInstructions {
+		roles = append(roles, role)
+	}
+	for role := range c.RolePriorities {
+		roles = append(roles, role)
+	}
+	for role := range c.RoleStopTokens {
+		roles = append(roles, role)
+	}
+	if c.PlanningRole != "" {
+		roles = append(roles, c.PlanningRole)
+	}
+	slices.Sort(roles)
+	return slices.Compact(roles)
+}
+
+// NewTaskManager returns a manager for TasksFile with the dispatch order,
+// priority aging, ID format and audit trail this config sets, so every
+// command that works on the registry treats it the same way. Callers
+// choose fsync and a logger to suit the process.
+func (c *Config) NewTaskManager() *task.Manager {
+	m := task.NewManager(c.TasksFile).
+		WithPriorityAging(time.Duration(c.AgeStepMinutes) * time.Minute).
+		WithDispatchOrder(c.DispatchOrder).
+		WithIDFormat(c.TaskIDFormat)
+	if c.AuditFile != "" {
+		m.WithAudit(c.AuditFile)
+	}
+	return m
+}
+
 // Save writes the configuration to a JSON file.
 func (c *Config) Save(path string) error {
 	data, err := json.MarshalIndent(c, "", "  ")
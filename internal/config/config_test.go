@@ -3,7 +3,11 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/tuanbt/hive/internal/task"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -63,6 +67,123 @@ func TestLoadConfigFromFile(t *testing.T) {
 	}
 }
 
+func TestLoadConfigPlanningTriggers(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.json")
+
+	for _, tc := range []struct {
+		json string
+		want int
+	}{
+		{`{}`, 4},
+		{`{"planning_triggers": null}`, 4},
+		{`{"planning_triggers": []}`, 0},
+		{`{"planning_triggers": ["je veux"]}`, 1},
+	} {
+		if err := os.WriteFile(configPath, []byte(tc.json), 0644); err != nil {
+			t.Fatalf("failed to create test config: %v", err)
+		}
+		cfg, err := Load(configPath)
+		if err != nil {
+			t.Fatalf("%s: failed to load config: %v", tc.json, err)
+		}
+		if len(cfg.PlanningTriggers) != tc.want {
+			t.Errorf("%s: expected %d triggers, got %v", tc.json, tc.want, cfg.PlanningTriggers)
+		}
+		if cfg.PlanningRole != "ba" {
+			t.Errorf("%s: expected default planning role ba, got %q", tc.json, cfg.PlanningRole)
+		}
+	}
+}
+
+func TestLoadConfigBlockedMarker(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.json")
+
+	for _, tc := range []struct {
+		json string
+		want string
+	}{
+		{`{}`, "### TASK_BLOCKED ###"},
+		{`{"blocked_marker": "NEED HELP"}`, "NEED HELP"},
+		{`{"blocked_marker": ""}`, ""},
+	} {
+		if err := os.WriteFile(configPath, []byte(tc.json), 0644); err != nil {
+			t.Fatalf("failed to create test config: %v", err)
+		}
+		cfg, err := Load(configPath)
+		if err != nil {
+			t.Fatalf("%s: failed to load config: %v", tc.json, err)
+		}
+		if cfg.BlockedMarker != tc.want {
+			t.Errorf("%s: expected blocked marker %q, got %q", tc.json, tc.want, cfg.BlockedMarker)
+		}
+	}
+}
+
+func TestPromptMentionsBlockedMarker(t *testing.T) {
+	tmpl, err := ParsePromptTemplate("")
+	if err != nil {
+		t.Fatalf("failed to parse default template: %v", err)
+	}
+	render := func(cfg *Config) string {
+		var b strings.Builder
+		if err := tmpl.Execute(&b, cfg.NewPromptData(task.NewTask("task-1", "Title", "Description"))); err != nil {
+			t.Fatalf("failed to render prompt: %v", err)
+		}
+		return b.String()
+	}
+
+	cfg := DefaultConfig()
+	if prompt := render(cfg); !strings.Contains(prompt, cfg.BlockedMarker) {
+		t.Errorf("expected the prompt to mention the blocked marker:\n%s", prompt)
+	}
+	if !strings.Contains(cfg.CompletionInstruction(), cfg.BlockedMarker) {
+		t.Errorf("expected the completion instruction to mention the blocked marker: %s", cfg.CompletionInstruction())
+	}
+
+	cfg.BlockedMarker = ""
+	if prompt := render(cfg); strings.Contains(prompt, "cannot finish") {
+		t.Errorf("expected no blocked instruction with detection off:\n%s", prompt)
+	}
+	if strings.Contains(cfg.CompletionInstruction(), "cannot finish") {
+		t.Errorf("expected no blocked instruction with detection off: %s", cfg.CompletionInstruction())
+	}
+}
+
+func TestLoadConfigMaxTaskRetries(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.json")
+
+	for _, tc := range []struct {
+		json     string
+		want     int
+		warnings int
+	}{
+		{`{}`, 3, 0},
+		{`{"max_task_retries": 5}`, 5, 1},
+		{`{"max_task_retries": 0}`, 0, 1},
+		{`{"max_task_retries": 5, "auto_retry": {"max_auto_retries": 1}}`, 1, 1},
+	} {
+		if err := os.WriteFile(configPath, []byte(tc.json), 0644); err != nil {
+			t.Fatalf("failed to create test config: %v", err)
+		}
+		cfg, err := Load(configPath)
+		if err != nil {
+			t.Fatalf("%s: failed to load config: %v", tc.json, err)
+		}
+		if cfg.AutoRetry.MaxAutoRetries != tc.want {
+			t.Errorf("%s: expected max_auto_retries=%d, got %d", tc.json, tc.want, cfg.AutoRetry.MaxAutoRetries)
+		}
+		if len(cfg.Warnings) != tc.warnings {
+			t.Errorf("%s: expected %d warnings, got %q", tc.json, tc.warnings, cfg.Warnings)
+		}
+		if cfg.MaxTaskRetries != nil {
+			t.Errorf("%s: expected max_task_retries to be cleared after migration", tc.json)
+		}
+	}
+}
+
 func TestLoadConfigMissingFile(t *testing.T) {
 	cfg, err := Load("/nonexistent/config.json")
 	if err != nil {
@@ -110,6 +231,27 @@ func TestValidateConfig(t *testing.T) {
 			modify:  func(c *Config) { c.NumWorkers = 100 },
 			wantErr: true,
 		},
+		{
+			name:    "high concurrency without override",
+			modify:  func(c *Config) { c.NumWorkers = 16 },
+			wantErr: true,
+		},
+		{
+			name: "high concurrency with override",
+			modify: func(c *Config) {
+				c.NumWorkers = 16
+				c.AllowHighConcurrency = true
+			},
+			wantErr: false,
+		},
+		{
+			name: "override does not lift hard limit",
+			modify: func(c *Config) {
+				c.NumWorkers = 100
+				c.AllowHighConcurrency = true
+			},
+			wantErr: true,
+		},
 		{
 			name:    "zero timeout",
 			modify:  func(c *Config) { c.ResponseTimeoutSeconds = 0 },
@@ -120,11 +262,157 @@ func TestValidateConfig(t *testing.T) {
 			modify:  func(c *Config) { c.LogLevel = "verbose" },
 			wantErr: true,
 		},
+		{
+			name:    "negative age step",
+			modify:  func(c *Config) { c.AgeStepMinutes = -5 },
+			wantErr: true,
+		},
+		{
+			name:    "fifo dispatch order",
+			modify:  func(c *Config) { c.DispatchOrder = "fifo" },
+			wantErr: false,
+		},
+		{
+			name:    "invalid dispatch order",
+			modify:  func(c *Config) { c.DispatchOrder = "random" },
+			wantErr: true,
+		},
+		{
+			name:    "sequence task ID format",
+			modify:  func(c *Config) { c.TaskIDFormat = "T-{seq}" },
+			wantErr: false,
+		},
+		{
+			name:    "task ID format without a unique part",
+			modify:  func(c *Config) { c.TaskIDFormat = "{date}" },
+			wantErr: true,
+		},
+		{
+			name:    "concurrent git ops on a shared tree",
+			modify:  func(c *Config) { c.MaxConcurrentGitOps = 2 },
+			wantErr: true,
+		},
+		{
+			name:    "negative max plan tasks",
+			modify:  func(c *Config) { c.MaxPlanTasks = -1 },
+			wantErr: true,
+		},
+		{
+			name:    "grid worker layout",
+			modify:  func(c *Config) { c.WorkerLayout = LayoutGrid },
+			wantErr: false,
+		},
+		{
+			name:    "invalid worker layout",
+			modify:  func(c *Config) { c.WorkerLayout = "diagonal" },
+			wantErr: true,
+		},
 		{
 			name:    "empty agent command",
 			modify:  func(c *Config) { c.AgentCommand = []string{} },
 			wantErr: true,
 		},
+		{
+			name:    "custom prompt template",
+			modify:  func(c *Config) { c.PromptTemplate = "{{.Task.Title}}: {{.Task.Description}}" },
+			wantErr: false,
+		},
+		{
+			name:    "unparseable prompt template",
+			modify:  func(c *Config) { c.PromptTemplate = "{{.Task.Title" },
+			wantErr: true,
+		},
+		{
+			name:    "prompt template with unknown field",
+			modify:  func(c *Config) { c.PromptTemplate = "{{.Task.Nope}}" },
+			wantErr: true,
+		},
+		{
+			name:    "short hex theme color",
+			modify:  func(c *Config) { c.Theme.Primary = "#0f0" },
+			wantErr: false,
+		},
+		{
+			name:    "invalid theme color",
+			modify:  func(c *Config) { c.Theme.Background = "black" },
+			wantErr: true,
+		},
+		{
+			name: "ascii status icons and colors",
+			modify: func(c *Config) {
+				c.Theme.StatusIcons = map[string]string{"pending": "[P]", "failed": "[F]"}
+				c.Theme.StatusColors = map[string]string{"failed": "#FF0000"}
+			},
+			wantErr: false,
+		},
+		{
+			name:    "status icon for unknown status",
+			modify:  func(c *Config) { c.Theme.StatusIcons = map[string]string{"done": "[D]"} },
+			wantErr: true,
+		},
+		{
+			name:    "invalid status color",
+			modify:  func(c *Config) { c.Theme.StatusColors = map[string]string{"failed": "red"} },
+			wantErr: true,
+		},
+		{
+			name: "docker runtime",
+			modify: func(c *Config) {
+				c.AgentRuntime = RuntimeDocker
+				c.Docker.Image = "hive-agent:latest"
+			},
+			wantErr: false,
+		},
+		{
+			name:    "docker runtime without image",
+			modify:  func(c *Config) { c.AgentRuntime = RuntimeDocker },
+			wantErr: true,
+		},
+		{
+			name:    "unknown runtime",
+			modify:  func(c *Config) { c.AgentRuntime = "podman" },
+			wantErr: true,
+		},
+		{
+			name:    "interaction rule",
+			modify:  func(c *Config) { c.InteractionRules = []InteractionRule{{Match: `Proceed\?`, Response: "yes"}} },
+			wantErr: false,
+		},
+		{
+			name:    "interaction rule with bad pattern",
+			modify:  func(c *Config) { c.InteractionRules = []InteractionRule{{Match: "(", Response: "yes"}} },
+			wantErr: true,
+		},
+		{
+			name:    "git inspect command",
+			modify:  func(c *Config) { c.GitIntegration.InspectCmd = "git log --oneline {{.Branch}}" },
+			wantErr: false,
+		},
+		{
+			name:    "git inspect command with unknown field",
+			modify:  func(c *Config) { c.GitIntegration.InspectCmd = "git log {{.Worktree}}" },
+			wantErr: true,
+		},
+		{
+			name:    "auto retry disabled",
+			modify:  func(c *Config) { c.AutoRetry.MaxAutoRetries = 0 },
+			wantErr: false,
+		},
+		{
+			name:    "negative auto retries",
+			modify:  func(c *Config) { c.AutoRetry.MaxAutoRetries = -1 },
+			wantErr: true,
+		},
+		{
+			name:    "negative retry backoff",
+			modify:  func(c *Config) { c.AutoRetry.RetryBackoffSeconds = []int{5, -1} },
+			wantErr: true,
+		},
+		{
+			name:    "unknown auto retry status",
+			modify:  func(c *Config) { c.AutoRetry.AutoRetryStatuses = []string{"hard_timeout", "timeout"} },
+			wantErr: true,
+		},
 		{
 			name:    "valid 5 workers",
 			modify:  func(c *Config) { c.NumWorkers = 5 },
@@ -148,6 +436,50 @@ func TestValidateConfig(t *testing.T) {
 	}
 }
 
+func TestAutoRetryPolicy(t *testing.T) {
+	retry := AutoRetryConfig{
+		MaxAutoRetries:      2,
+		RetryBackoffSeconds: []int{1, 5},
+		AutoRetryStatuses:   []string{"hard_timeout"},
+	}
+
+	if !retry.Retryable("hard_timeout") {
+		t.Error("expected hard_timeout to be retryable")
+	}
+	if retry.Retryable("review_rejected") {
+		t.Error("expected review_rejected not to be retryable")
+	}
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 5 * time.Second, 3: 5 * time.Second} {
+		if got := retry.Backoff(attempt); got != want {
+			t.Errorf("Backoff(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+
+	retry.RetryBackoffSeconds = nil
+	if got := retry.Backoff(1); got != 0 {
+		t.Errorf("expected an immediate retry without backoffs, got %v", got)
+	}
+
+	retry.MaxAutoRetries = 0
+	if retry.Retryable("hard_timeout") {
+		t.Error("expected no retries when max_auto_retries is zero")
+	}
+}
+
+func TestRoles(t *testing.T) {
+	cfg := &Config{
+		Instructions:   InstructionConfig{RoleInstructions: map[string]string{"dev": "", "qa": ""}},
+		RolePriorities: map[string]int{"dev": 2, "ops": 1},
+		RoleStopTokens: map[string][]string{"reviewer": {"APPROVED"}},
+		PlanningRole:   "ba",
+	}
+
+	got := strings.Join(cfg.Roles(), ",")
+	if want := "ba,dev,ops,qa,reviewer"; got != want {
+		t.Errorf("Roles() = %s, want %s", got, want)
+	}
+}
+
 func TestSaveConfig(t *testing.T) {
 	tmpDir := t.TempDir()
 	configPath := filepath.Join(tmpDir, "config.json")
@@ -173,3 +505,108 @@ func TestSaveConfig(t *testing.T) {
 		t.Errorf("expected LogLevel=debug, got %s", loaded.LogLevel)
 	}
 }
+
+func TestCheckWritable(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := DefaultConfig()
+	cfg.TasksFile = filepath.Join(tmpDir, "tasks.json")
+	if err := cfg.CheckWritable(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if entries, _ := os.ReadDir(tmpDir); len(entries) != 0 {
+		t.Errorf("expected the probe to be removed, found %d entries", len(entries))
+	}
+
+	cfg.TasksFile = filepath.Join(tmpDir, "missing", "tasks.json")
+	if err := cfg.CheckWritable(); err == nil || !strings.Contains(err.Error(), "not writable") {
+		t.Errorf("expected a not writable error, got %v", err)
+	}
+}
+
+func TestValidatePaths(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "file.txt")
+	if err := os.WriteFile(filePath, []byte("x"), 0644); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr string
+	}{
+		{
+			name:   "valid paths",
+			modify: func(c *Config) {},
+		},
+		{
+			name:    "missing work directory",
+			modify:  func(c *Config) { c.WorkDirectory = filepath.Join(tmpDir, "missing") },
+			wantErr: "work_directory does not exist",
+		},
+		{
+			name:    "work directory is a file",
+			modify:  func(c *Config) { c.WorkDirectory = filePath },
+			wantErr: "work_directory is not a directory",
+		},
+		{
+			name:    "missing tasks file directory",
+			modify:  func(c *Config) { c.TasksFile = filepath.Join(tmpDir, "missing", "tasks.json") },
+			wantErr: "tasks_file directory does not exist",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.WorkDirectory = tmpDir
+			cfg.TasksFile = filepath.Join(tmpDir, "tasks.json")
+			tt.modify(cfg)
+
+			err := cfg.ValidatePaths()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLoadConfigValidatesPaths(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.json")
+
+	configJSON := `{"work_directory": "/nonexistent/hive/workspace"}`
+	if err := os.WriteFile(configPath, []byte(configJSON), 0644); err != nil {
+		t.Fatalf("failed to create test config: %v", err)
+	}
+
+	if _, err := Load(configPath); err == nil {
+		t.Error("expected error for missing work_directory")
+	}
+}
+
+func TestNewTaskManager(t *testing.T) {
+	dir := t.TempDir()
+	cfg := DefaultConfig()
+	cfg.TasksFile = filepath.Join(dir, "tasks.json")
+	cfg.AuditFile = filepath.Join(dir, "audit.ndjson")
+	cfg.TaskIDFormat = "T-{seq}"
+
+	tm := cfg.NewTaskManager()
+	created := task.NewTask("", "Build it", "")
+	if err := tm.Create(created); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if created.ID != "T-1" {
+		t.Errorf("expected the configured ID format, got %s", created.ID)
+	}
+	if _, err := os.Stat(cfg.AuditFile); err != nil {
+		t.Errorf("expected the configured audit trail to be written: %v", err)
+	}
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+// InspectData is the value git_integration.inspect_command is rendered over.
+type InspectData struct {
+	TaskID string
+	Branch string
+}
+
+// BranchName returns the git branch the integration uses for a task.
+func (g GitConfig) BranchName(taskID string) string {
+	return g.BranchPrefix + taskID
+}
+
+// InspectCommand renders the inspect command for a task. Returns "" if no
+// inspect command is configured.
+func (g GitConfig) InspectCommand(taskID string) (string, error) {
+	if g.InspectCmd == "" {
+		return "", nil
+	}
+	tmpl, err := template.New("inspect").Parse(g.InspectCmd)
+	if err != nil {
+		return "", fmt.Errorf("invalid inspect_command: %w", err)
+	}
+	var buf bytes.Buffer
+	data := InspectData{TaskID: taskID, Branch: g.BranchName(taskID)}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("invalid inspect_command: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// validate renders the inspect command for a sample task so template
+// errors are reported at load time.
+func (g GitConfig) validate() error {
+	_, err := g.InspectCommand("task-sample")
+	return err
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/tuanbt/hive/internal/task"
+)
+
+// PromptData is the value prompt templates are rendered over.
+type PromptData struct {
+	Task               *task.Task
+	GlobalRules        []string
+	RoleInstruction    string
+	HasRoleInstruction bool
+	CompletionMarker   string
+	BlockedMarker      string // empty when blocked detection is off
+}
+
+// DefaultPromptTemplate renders the global rules, the role instruction,
+// retry context, any extra instructions and the task itself.
+const DefaultPromptTemplate = `=== SYSTEM INSTRUCTIONS ===
+{{range .GlobalRules}}- {{.}}
+{{end}}{{if .HasRoleInstruction}}
+=== ROLE: {{upper .Task.Role}} ===
+{{.RoleInstruction}}
+{{end}}{{if gt .Task.RetryCount 0}}
+=== RETRY ===
+{{if .Task.LastError}}This is retry attempt {{.Task.RetryCount}}; the prior attempt failed because: {{.Task.LastError}}
+{{else}}This is retry attempt {{.Task.RetryCount}}; the prior attempt failed.
+{{end}}Avoid repeating the same mistake.
+{{end}}{{if .Task.ExtraInstructions}}
+=== ADDITIONAL INSTRUCTIONS ===
+{{.Task.ExtraInstructions}}
+{{end}}
+=== TASK ===
+Task: {{.Task.Title}}
+Description: {{.Task.Description}}
+Please implement this now. When you are finished, output '{{.CompletionMarker}}'.{{if .BlockedMarker}}
+If you cannot finish without input from a human, explain what you need and output '{{.BlockedMarker}}' on its own line instead.{{end}}`
+
+// promptFuncs are the helper functions available to prompt templates.
+var promptFuncs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"join":  strings.Join,
+}
+
+// ParsePromptTemplate parses a prompt template with the prompt helper
+// functions available. An empty text parses DefaultPromptTemplate.
+func ParsePromptTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		text = DefaultPromptTemplate
+	}
+	tmpl, err := template.New("prompt").Funcs(promptFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid prompt_template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// NewPromptData collects the instructions that apply to t.
+func (c *Config) NewPromptData(t *task.Task) PromptData {
+	data := PromptData{
+		Task:             t,
+		GlobalRules:      c.Instructions.GlobalRules,
+		CompletionMarker: c.CompletionMarker,
+		BlockedMarker:    c.BlockedMarker,
+	}
+	if t.Role != "" {
+		data.RoleInstruction, data.HasRoleInstruction = c.Instructions.RoleInstructions[t.Role]
+	}
+	return data
+}
+
+// CompletionInstruction is the text appended to implementation prompts
+// when AppendCompletionInstruction is set. It also tells the agent about
+// BlockedMarker unless blocked detection is off.
+func (c *Config) CompletionInstruction() string {
+	text := fmt.Sprintf("IMPORTANT: when you are finished, end your response with '%s' on its own line. Without it the task is not considered done.", c.CompletionMarker)
+	if c.BlockedMarker != "" {
+		text += fmt.Sprintf(" If you cannot finish without input from a human, explain what you need and end your response with '%s' on its own line instead.", c.BlockedMarker)
+	}
+	return text
+}
+
+// validatePromptTemplate parses the prompt template and renders it over a
+// sample task so unknown fields are reported at load time.
+func (c *Config) validatePromptTemplate() error {
+	tmpl, err := ParsePromptTemplate(c.PromptTemplate)
+	if err != nil {
+		return err
+	}
+	sample := task.NewTask("task-sample", "Sample", "Sample description")
+	sample.Role = "sample"
+	sample.RetryCount = 1
+	sample.ExtraInstructions = "Sample instructions"
+	if err := tmpl.Execute(io.Discard, c.NewPromptData(sample)); err != nil {
+		return fmt.Errorf("invalid prompt_template: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// failureReasons are the completion reasons a failed task can end with,
+// matching the failure values of agent.CompletionReason.
+var failureReasons = []string{"no_output", "hard_timeout", "review_rejected", "process_error"}
+
+// migrateRetries moves the deprecated max_task_retries into
+// auto_retry.max_auto_retries, unless data sets the latter itself, and
+// records a warning. data is the raw config file c was decoded from.
+func (c *Config) migrateRetries(data []byte) error {
+	if c.MaxTaskRetries == nil {
+		return nil
+	}
+	var explicit struct {
+		AutoRetry struct {
+			MaxAutoRetries *int `json:"max_auto_retries"`
+		} `json:"auto_retry"`
+	}
+	if err := json.Unmarshal(data, &explicit); err != nil {
+		return err
+	}
+
+	if explicit.AutoRetry.MaxAutoRetries == nil {
+		c.AutoRetry.MaxAutoRetries = *c.MaxTaskRetries
+		c.Warnings = append(c.Warnings, "max_task_retries is deprecated, use auto_retry.max_auto_retries instead")
+	} else {
+		c.Warnings = append(c.Warnings, "max_task_retries is deprecated and ignored since auto_retry.max_auto_retries is set")
+	}
+	c.MaxTaskRetries = nil
+	return nil
+}
+
+// Retryable reports whether a failure with the given completion reason may
+// be retried automatically.
+func (r AutoRetryConfig) Retryable(reason string) bool {
+	return r.MaxAutoRetries > 0 && slices.Contains(r.AutoRetryStatuses, reason)
+}
+
+// Backoff returns how long to wait before the given retry, counting from 1.
+func (r AutoRetryConfig) Backoff(attempt int) time.Duration {
+	if len(r.RetryBackoffSeconds) == 0 {
+		return 0
+	}
+	idx := min(max(attempt-1, 0), len(r.RetryBackoffSeconds)-1)
+	return time.Duration(r.RetryBackoffSeconds[idx]) * time.Second
+}
+
+// validate rejects negative retry counts and backoffs, and statuses that
+// are not completion reasons a failed task can have.
+func (r AutoRetryConfig) validate() error {
+	if r.MaxAutoRetries < 0 {
+		return fmt.Errorf("auto_retry.max_auto_retries cannot be negative, got %d", r.MaxAutoRetries)
+	}
+	for i, seconds := range r.RetryBackoffSeconds {
+		if seconds < 0 {
+			return fmt.Errorf("auto_retry.retry_backoff_seconds[%d] cannot be negative, got %d", i, seconds)
+		}
+	}
+	for i, status := range r.AutoRetryStatuses {
+		if !slices.Contains(failureReasons, status) {
+			return fmt.Errorf("auto_retry.auto_retry_statuses[%d]: unknown completion reason %q (must be one of %s)",
+				i, status, strings.Join(failureReasons, ", "))
+		}
+	}
+	return nil
+}
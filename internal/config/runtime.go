@@ -0,0 +1,86 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Supported values for AgentRuntime.
+const (
+	RuntimeNative = "native"
+	RuntimeDocker = "docker"
+)
+
+// DockerWorkDir is where the work directory is mounted inside the container.
+const DockerWorkDir = "/work"
+
+// AgentArgv returns the command line that runs the agent for workDir. In
+// docker mode AgentCommand runs inside a throwaway container with workDir
+// mounted at DockerWorkDir, named container unless that is empty; otherwise
+// it is AgentCommand itself.
+func (c *Config) AgentArgv(workDir, container string) []string {
+	return c.agentArgv(workDir, container, c.AgentCommand)
+}
+
+// ReviewAgentArgv returns the command line that runs review cycles for
+// workDir, wrapped like AgentArgv. It is ReviewAgentCommand when set and
+// AgentCommand otherwise.
+func (c *Config) ReviewAgentArgv(workDir, container string) []string {
+	if len(c.ReviewAgentCommand) == 0 {
+		return c.AgentArgv(workDir, container)
+	}
+	return c.agentArgv(workDir, container, c.ReviewAgentCommand)
+}
+
+// ContainerName returns the docker container name used for runs of the
+// task with the given ID. Characters docker does not allow in names are
+// replaced with dashes.
+func ContainerName(taskID string) string {
+	clean := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '.', r == '-':
+			return r
+		}
+		return '-'
+	}, taskID)
+	return "hive-" + clean
+}
+
+// agentArgv wraps command for the configured runtime.
+func (c *Config) agentArgv(workDir, container string, command []string) []string {
+	if c.AgentRuntime != RuntimeDocker {
+		return append([]string{}, command...)
+	}
+
+	// Docker needs an absolute host path for the bind mount
+	if abs, err := filepath.Abs(workDir); err == nil {
+		workDir = abs
+	}
+	argv := []string{
+		"docker", "run", "--rm", "-i",
+		"-v", workDir + ":" + DockerWorkDir,
+		"-w", DockerWorkDir,
+	}
+	if container != "" {
+		argv = append(argv, "--name", container)
+	}
+	argv = append(argv, c.Docker.Args...)
+	argv = append(argv, c.Docker.Image)
+	return append(argv, command...)
+}
+
+// validate checks the runtime and, in docker mode, that an image is set.
+func (d DockerConfig) validate(runtime string) error {
+	switch runtime {
+	case "", RuntimeNative:
+		return nil
+	case RuntimeDocker:
+		if d.Image == "" {
+			return fmt.Errorf("docker.image is required when agent_runtime is docker")
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid agent_runtime: %s (must be native or docker)", runtime)
+	}
+}
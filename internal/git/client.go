@@ -13,6 +13,8 @@ type Client interface {
 	IsClean() (bool, error)
 	CheckoutNewBranch(branch, base string) error
 	AddAll() error
+	AddPaths(paths []string) error
+	ChangedPaths() ([]string, error)
 	Commit(message string) error
 	Push(remote, branch string) error
 	CreatePR(title, body string) error
@@ -30,8 +32,22 @@ func NewClient(workDir string) *OSClient {
 
 // Run executes a git command.
 func (c *OSClient) Run(args ...string) (string, error) {
+	out, err := c.runRaw(args...)
+	return strings.TrimSpace(out), err
+}
+
+// runRaw executes a git command and returns its output untrimmed, for
+// formats where leading whitespace is significant.
+func (c *OSClient) runRaw(args ...string) (string, error) {
+	return c.runInput("", args...)
+}
+
+// runInput executes a git command with input on its stdin and returns its
+// output untrimmed.
+func (c *OSClient) runInput(input string, args ...string) (string, error) {
 	cmd := exec.Command("git", args...)
 	cmd.Dir = c.workDir
+	cmd.Stdin = strings.NewReader(input)
 	var stderr bytes.Buffer
 	var stdout bytes.Buffer
 	cmd.Stderr = &stderr
@@ -40,7 +56,7 @@ func (c *OSClient) Run(args ...string) (string, error) {
 	if err := cmd.Run(); err != nil {
 		return "", fmt.Errorf("git %s failed: %w (stderr: %s)", args[0], err, stderr.String())
 	}
-	return strings.TrimSpace(stdout.String()), nil
+	return stdout.String(), nil
 }
 
 // IsInstalled checks if git is available.
@@ -74,6 +90,54 @@ func (c *OSClient) AddAll() error {
 	return err
 }
 
+// AddPaths stages the given paths, including deletions. Paths are taken
+// literally, so names with glob characters stage only themselves, and are
+// passed on stdin, so any number fits. It does nothing when paths is empty.
+func (c *OSClient) AddPaths(paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	var spec strings.Builder
+	for _, p := range paths {
+		spec.WriteString(":(literal)" + p + "\x00")
+	}
+	_, err := c.runInput(spec.String(), "add", "-A", "--pathspec-from-file=-", "--pathspec-file-nul")
+	return err
+}
+
+// ChangedPaths lists every modified, added, deleted or untracked path in
+// the working tree, as reported by git status --porcelain. Renames yield
+// both the old and the new path.
+func (c *OSClient) ChangedPaths() ([]string, error) {
+	out, err := c.runRaw("status", "--porcelain", "-z", "--untracked-files=all")
+	if err != nil {
+		return nil, err
+	}
+	return parsePorcelainZ(out), nil
+}
+
+// parsePorcelainZ extracts the paths from git status --porcelain -z
+// output. Each entry is "XY path", and a rename or copy is followed by an
+// extra entry holding the original path.
+func parsePorcelainZ(out string) []string {
+	var paths []string
+	entries := strings.Split(out, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		paths = append(paths, entry[3:])
+		if entry[0] == 'R' || entry[0] == 'C' {
+			if i+1 < len(entries) && entries[i+1] != "" {
+				paths = append(paths, entries[i+1])
+			}
+			i++
+		}
+	}
+	return paths
+}
+
 // Commit creates a commit.
 func (c *OSClient) Commit(message string) error {
 	_, err := c.Run("commit", "-m", message)
@@ -0,0 +1,100 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestParsePorcelainZ(t *testing.T) {
+	out := " M edited.go\x00?? new file.txt\x00R  renamed.go\x00original.go\x00 D removed.go\x00"
+	got := parsePorcelainZ(out)
+	want := []string{"edited.go", "new file.txt", "renamed.go", "original.go", "removed.go"}
+	if !slices.Equal(got, want) {
+		t.Errorf("parsePorcelainZ() = %q, want %q", got, want)
+	}
+}
+
+func TestAddPathsStagesOnlyGivenPaths(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	dir := t.TempDir()
+	c := NewClient(dir)
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "user.name", "Test"},
+	} {
+		if _, err := c.Run(args...); err != nil {
+			t.Fatalf("git setup failed: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "base.go"), []byte("package x\n"), 0644); err != nil {
+		t.Fatalf("failed to write base.go: %v", err)
+	}
+	if _, err := c.Run("add", "base.go"); err != nil {
+		t.Fatalf("git add failed: %v", err)
+	}
+	if err := c.Commit("base"); err != nil {
+		t.Fatalf("git commit failed: %v", err)
+	}
+	// A modified tracked file sorts first, so its leading space must survive
+	for _, name := range []string{"base.go", "task.go", "other.go"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("package y\n"), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	changed, err := c.ChangedPaths()
+	if err != nil {
+		t.Fatalf("ChangedPaths() failed: %v", err)
+	}
+	slices.Sort(changed)
+	if !slices.Equal(changed, []string{"base.go", "other.go", "task.go"}) {
+		t.Fatalf("unexpected changed paths: %q", changed)
+	}
+
+	if err := c.AddPaths([]string{"task.go"}); err != nil {
+		t.Fatalf("AddPaths() failed: %v", err)
+	}
+	staged, err := c.Run("diff", "--cached", "--name-only")
+	if err != nil {
+		t.Fatalf("git diff failed: %v", err)
+	}
+	if staged != "task.go" {
+		t.Errorf("expected only task.go staged, got %q", staged)
+	}
+}
+
+func TestAddPathsLiteral(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	dir := t.TempDir()
+	c := NewClient(dir)
+	if _, err := c.Run("init", "-q"); err != nil {
+		t.Fatalf("git init failed: %v", err)
+	}
+	// A name with glob characters would match every .go file as a pathspec
+	for _, name := range []string{"*.go", "a.go", "b.go"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("package x\n"), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	if err := c.AddPaths([]string{"*.go"}); err != nil {
+		t.Fatalf("AddPaths() failed: %v", err)
+	}
+	staged, err := c.Run("diff", "--cached", "--name-only")
+	if err != nil {
+		t.Fatalf("git diff failed: %v", err)
+	}
+	if staged != "*.go" {
+		t.Errorf("expected only *.go staged, got %q", staged)
+	}
+}
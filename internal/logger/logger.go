@@ -2,6 +2,8 @@
 package logger
 
 import (
+	"context"
+	"fmt"
 	"io"
 	"log/slog"
 	"os"
@@ -10,7 +12,11 @@ import (
 	"github.com/tuanbt/hive/internal/config"
 )
 
-// NewSystemLogger creates the main orchestrator logger.
+// OrchestratorLogFile is the name of the orchestrator log in the log directory.
+const OrchestratorLogFile = "orchestrator.log"
+
+// NewSystemLogger creates the main orchestrator logger. It writes to the
+// orchestrator log and, when LogToStdout is set, to stdout as well.
 func NewSystemLogger(cfg *config.Config) (*slog.Logger, error) {
 	level := ParseLevel(cfg.LogLevel)
 
@@ -20,17 +26,20 @@ func NewSystemLogger(cfg *config.Config) (*slog.Logger, error) {
 	}
 
 	// Create log file
-	logPath := filepath.Join(cfg.LogDirectory, "orchestrator.log")
+	logPath := filepath.Join(cfg.LogDirectory, OrchestratorLogFile)
 	file, err := os.OpenFile(logPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
 	if err != nil {
 		return nil, err
 	}
 
-	// Multi-writer: file + stdout
-	multiWriter := io.MultiWriter(os.Stdout, file)
+	// Multi-writer: file + stdout, unless stdout is turned off
+	var w io.Writer = file
+	if cfg.LogToStdout {
+		w = io.MultiWriter(os.Stdout, file)
+	}
 
 	// JSON handler for structured logs
-	handler := slog.NewJSONHandler(multiWriter, &slog.HandlerOptions{
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{
 		Level: level,
 	})
 
@@ -47,7 +56,7 @@ func NewEmbeddedLogger(cfg *config.Config) (*slog.Logger, error) {
 	}
 
 	// Create log file
-	logPath := filepath.Join(cfg.LogDirectory, "orchestrator.log")
+	logPath := filepath.Join(cfg.LogDirectory, OrchestratorLogFile)
 	file, err := os.OpenFile(logPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
 	if err != nil {
 		return nil, err
@@ -88,6 +97,115 @@ func NewTaskLogger(cfg *config.Config, taskID string) (*slog.Logger, func(), err
 	return logger, cleanup, nil
 }
 
+// TaskEventsSuffix is appended to a task ID to name its NDJSON event log.
+const TaskEventsSuffix = ".events.jsonl"
+
+// NewTaskEventLogger creates a logger writing one JSON object per line to
+// <id>.events.jsonl, for tooling that needs task phases and agent output
+// without scraping the plain task log. Every event is recorded regardless
+// of the configured log level.
+// Returns the logger and a cleanup function to close the file.
+func NewTaskEventLogger(cfg *config.Config, taskID string) (*slog.Logger, func(), error) {
+	// Ensure log directory exists
+	if err := os.MkdirAll(cfg.LogDirectory, 0755); err != nil {
+		return nil, nil, err
+	}
+
+	// Create task event file
+	logPath := filepath.Join(cfg.LogDirectory, taskID+TaskEventsSuffix)
+	file, err := os.OpenFile(logPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	handler := slog.NewJSONHandler(file, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	})
+
+	logger := slog.New(handler).With("task_id", taskID)
+	cleanup := func() { file.Close() }
+
+	return logger, cleanup, nil
+}
+
+// NewWorkerLogger creates a logger for a specific worker, writing to
+// worker-<id>.log so a worker can be followed across all of its tasks.
+// Returns the logger and a cleanup function to close the file.
+func NewWorkerLogger(cfg *config.Config, workerID int) (*slog.Logger, func(), error) {
+	level := ParseLevel(cfg.LogLevel)
+
+	// Ensure log directory exists
+	if err := os.MkdirAll(cfg.LogDirectory, 0755); err != nil {
+		return nil, nil, err
+	}
+
+	// Create worker log file
+	logPath := filepath.Join(cfg.LogDirectory, fmt.Sprintf("worker-%d.log", workerID))
+	file, err := os.OpenFile(logPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	handler := slog.NewJSONHandler(file, &slog.HandlerOptions{
+		Level: level,
+	})
+
+	logger := slog.New(handler).With("worker_id", workerID)
+	cleanup := func() { file.Close() }
+
+	return logger, cleanup, nil
+}
+
+// Tee returns a logger that writes every record to all of the given loggers.
+func Tee(loggers ...*slog.Logger) *slog.Logger {
+	handlers := make([]slog.Handler, len(loggers))
+	for i, l := range loggers {
+		handlers[i] = l.Handler()
+	}
+	return slog.New(teeHandler(handlers))
+}
+
+// teeHandler fans records out to several handlers.
+type teeHandler []slog.Handler
+
+func (t teeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range t {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t teeHandler) Handle(ctx context.Context, r slog.Record) error {
+	var firstErr error
+	for _, h := range t {
+		if !h.Enabled(ctx, r.Level) {
+			continue
+		}
+		if err := h.Handle(ctx, r.Clone()); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (t teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := make(teeHandler, len(t))
+	for i, h := range t {
+		next[i] = h.WithAttrs(attrs)
+	}
+	return next
+}
+
+func (t teeHandler) WithGroup(name string) slog.Handler {
+	next := make(teeHandler, len(t))
+	for i, h := range t {
+		next[i] = h.WithGroup(name)
+	}
+	return next
+}
+
 // NewConsoleLogger creates a simple console-only logger.
 func NewConsoleLogger(cfg *config.Config) *slog.Logger {
 	level := ParseLevel(cfg.LogLevel)
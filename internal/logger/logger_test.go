@@ -0,0 +1,61 @@
+package logger
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tuanbt/hive/internal/config"
+)
+
+// captureStdout runs fn with os.Stdout redirected and returns what it wrote.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create pipe: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	fn()
+	w.Close()
+
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("failed to read stdout: %v", err)
+	}
+	return string(out)
+}
+
+func TestSystemLoggerStdout(t *testing.T) {
+	for _, toStdout := range []bool{true, false} {
+		cfg := config.DefaultConfig()
+		cfg.LogDirectory = t.TempDir()
+		cfg.LogToStdout = toStdout
+
+		out := captureStdout(t, func() {
+			log, err := NewSystemLogger(cfg)
+			if err != nil {
+				t.Fatalf("NewSystemLogger() failed: %v", err)
+			}
+			log.Info("dispatcher started")
+		})
+
+		if got := strings.Contains(out, "dispatcher started"); got != toStdout {
+			t.Errorf("log_to_stdout=%v: unexpected stdout %q", toStdout, out)
+		}
+
+		data, err := os.ReadFile(filepath.Join(cfg.LogDirectory, OrchestratorLogFile))
+		if err != nil {
+			t.Fatalf("failed to read log file: %v", err)
+		}
+		if !strings.Contains(string(data), "dispatcher started") {
+			t.Errorf("log_to_stdout=%v: expected the log file to get the record, got %q", toStdout, data)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/tuanbt/hive/internal/config"
+)
+
+// ANSI color codes used by the pretty console handler.
+const (
+	colorReset  = "\033[0m"
+	colorDim    = "\033[2m"
+	colorGray   = "\033[90m"
+	colorYellow = "\033[33m"
+	colorRed    = "\033[31m"
+	colorCyan   = "\033[36m"
+)
+
+// NewPrettyConsoleLogger creates a colorized, human-friendly console logger.
+// Errors are printed in red, warnings in yellow, and info/debug dimmed.
+func NewPrettyConsoleLogger(cfg *config.Config) *slog.Logger {
+	return slog.New(NewPrettyHandler(os.Stdout, ParseLevel(cfg.LogLevel)))
+}
+
+// PrettyHandler is a slog.Handler that writes leveled, colorized lines.
+type PrettyHandler struct {
+	w      io.Writer
+	level  slog.Leveler
+	attrs  []slog.Attr
+	groups []string
+	mu     *sync.Mutex
+}
+
+// NewPrettyHandler returns a PrettyHandler writing to w at the given level.
+func NewPrettyHandler(w io.Writer, level slog.Leveler) *PrettyHandler {
+	return &PrettyHandler{
+		w:     w,
+		level: level,
+		mu:    &sync.Mutex{},
+	}
+}
+
+// Enabled reports whether the handler handles records at the given level.
+func (h *PrettyHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+// Handle formats and writes a single log record.
+func (h *PrettyHandler) Handle(_ context.Context, r slog.Record) error {
+	color := levelColor(r.Level)
+
+	var b strings.Builder
+	b.WriteString(colorGray + r.Time.Format("15:04:05") + colorReset + " ")
+	b.WriteString(color + fmt.Sprintf("%-5s", r.Level.String()) + colorReset + " ")
+	if r.Level >= slog.LevelWarn {
+		b.WriteString(color + r.Message + colorReset)
+	} else {
+		b.WriteString(r.Message)
+	}
+
+	writeAttr := func(a slog.Attr) {
+		b.WriteString(" " + colorCyan + a.Key + colorReset + "=" + colorDim + a.Value.String() + colorReset)
+	}
+	for _, a := range h.attrs {
+		writeAttr(a)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		writeAttr(h.qualify(a))
+		return true
+	})
+	b.WriteString("\n")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+// WithAttrs returns a handler that includes the given attributes on every record.
+func (h *PrettyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append([]slog.Attr{}, h.attrs...)
+	for _, a := range attrs {
+		clone.attrs = append(clone.attrs, h.qualify(a))
+	}
+	return &clone
+}
+
+// WithGroup returns a handler that qualifies subsequent attribute keys with name.
+func (h *PrettyHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.groups = append(append([]string{}, h.groups...), name)
+	return &clone
+}
+
+// qualify prefixes an attribute key with the handler's open groups.
+func (h *PrettyHandler) qualify(a slog.Attr) slog.Attr {
+	if len(h.groups) == 0 {
+		return a
+	}
+	a.Key = strings.Join(h.groups, ".") + "." + a.Key
+	return a
+}
+
+// levelColor maps a log level to its display color.
+func levelColor(level slog.Level) string {
+	switch {
+	case level >= slog.LevelError:
+		return colorRed
+	case level >= slog.LevelWarn:
+		return colorYellow
+	case level >= slog.LevelInfo:
+		return colorDim
+	default:
+		return colorGray
+	}
+}
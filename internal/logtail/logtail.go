@@ -0,0 +1,132 @@
+// Package logtail reads the end of a log file and follows it as it grows.
+package logtail
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"time"
+)
+
+// PollInterval is how often Next checks the file for new content.
+const PollInterval = 50 * time.Millisecond
+
+// tailChunkSize is how much of the file ReadTail reads per step backwards.
+const tailChunkSize = 64 * 1024
+
+// ReadTail returns at most the last n lines of a file along with the file
+// size, reading backwards from the end so large logs aren't loaded whole.
+// A non-positive n returns the entire file.
+func ReadTail(path string, n int) (string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", 0, err
+	}
+	size := info.Size()
+
+	if n <= 0 {
+		content, err := io.ReadAll(file)
+		return string(content), size, err
+	}
+
+	var buf []byte
+	offset := size
+	for offset > 0 {
+		chunk := int64(tailChunkSize)
+		if chunk > offset {
+			chunk = offset
+		}
+		offset -= chunk
+
+		part := make([]byte, chunk)
+		if _, err := file.ReadAt(part, offset); err != nil && err != io.EOF {
+			return "", 0, err
+		}
+		buf = append(part, buf...)
+
+		// One extra newline marks the start of the first wanted line,
+		// ignoring the trailing newline that ends the file
+		if bytes.Count(bytes.TrimSuffix(buf, []byte("\n")), []byte("\n")) >= n {
+			break
+		}
+	}
+
+	trimmed := bytes.TrimSuffix(buf, []byte("\n"))
+	for i := 0; i < n; i++ {
+		idx := bytes.LastIndexByte(trimmed, '\n')
+		if idx < 0 {
+			return string(buf), size, nil
+		}
+		trimmed = trimmed[:idx]
+	}
+	return string(buf[len(trimmed)+1:]), size, nil
+}
+
+// ReadFrom returns the content appended to path after offset and the new
+// offset. It returns no content if the file hasn't grown or doesn't exist
+// yet.
+func ReadFrom(path string, offset int64) (string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", offset, nil
+		}
+		return "", offset, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil || info.Size() <= offset {
+		return "", offset, nil
+	}
+
+	content := make([]byte, info.Size()-offset)
+	n, err := file.ReadAt(content, offset)
+	if err != nil && err != io.EOF {
+		return "", offset, err
+	}
+	return string(content[:n]), offset + int64(n), nil
+}
+
+// Next waits until path grows past offset and returns the appended content
+// and the new offset. A missing file is waited for. Returns ctx.Err() once
+// ctx is done.
+func Next(ctx context.Context, path string, offset int64) (string, int64, error) {
+	for {
+		content, next, err := ReadFrom(path, offset)
+		if err != nil || content != "" {
+			return content, next, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", offset, ctx.Err()
+		case <-time.After(PollInterval):
+		}
+	}
+}
+
+// Follow writes everything appended to path after offset to w, like
+// tail -f, until ctx is done. Returns nil when ctx is cancelled.
+func Follow(ctx context.Context, path string, offset int64, w io.Writer) error {
+	for {
+		content, next, err := Next(ctx, path, offset)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if _, err := io.WriteString(w, content); err != nil {
+			return err
+		}
+		offset = next
+	}
+}
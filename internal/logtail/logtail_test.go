@@ -0,0 +1,101 @@
+package logtail
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReadTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "task-1.log")
+	if err := os.WriteFile(path, []byte("one\ntwo\nthree\n"), 0644); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+
+	for _, tc := range []struct {
+		n    int
+		want string
+	}{
+		{0, "one\ntwo\nthree\n"},
+		{2, "two\nthree\n"},
+		{10, "one\ntwo\nthree\n"},
+	} {
+		got, size, err := ReadTail(path, tc.n)
+		if err != nil {
+			t.Fatalf("ReadTail(%d) failed: %v", tc.n, err)
+		}
+		if got != tc.want || size != 14 {
+			t.Errorf("ReadTail(%d) = %q, %d; want %q, 14", tc.n, got, size, tc.want)
+		}
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe to read while Follow writes to it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestFollowWaitsForFileAndStopsOnCancel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "task-1.log")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var out syncBuffer
+	done := make(chan error, 1)
+	go func() {
+		done <- Follow(ctx, path, 0, &out)
+	}()
+
+	// The log doesn't exist yet, Follow keeps waiting for it
+	time.Sleep(3 * PollInterval)
+	if err := os.WriteFile(path, []byte("first\n"), 0644); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+	waitFor(t, &out, "first\n")
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("failed to open log: %v", err)
+	}
+	f.WriteString("second\n")
+	f.Close()
+	waitFor(t, &out, "first\nsecond\n")
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("expected nil on cancel, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Follow did not return after cancel")
+	}
+}
+
+func waitFor(t *testing.T, out *syncBuffer, want string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if out.String() == want {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("expected output %q, got %q", want, out.String())
+}
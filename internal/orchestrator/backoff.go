@@ -0,0 +1,25 @@
+package orchestrator
+
+import "time"
+
+// idleBackoff stretches the dispatcher's polling interval while the queue
+// stays empty, doubling it up to a cap, and snaps back to the base interval
+// as soon as work is found.
+type idleBackoff struct {
+	current time.Duration
+	jitter  func() float64 // returns a value in [0, 1)
+}
+
+// next returns how long to wait before polling again. found reports
+// whether the last poll dispatched anything.
+func (b *idleBackoff) next(found bool, base, limit time.Duration) time.Duration {
+	if found || b.current < base {
+		b.current = base
+		return base
+	}
+	b.current = min(b.current*2, max(limit, base))
+
+	// Shave off up to a fifth so idle orchestrators don't poll in lockstep
+	wait := b.current - time.Duration(b.jitter()*float64(b.current)/5)
+	return max(wait, base)
+}
@@ -0,0 +1,44 @@
+package orchestrator
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIdleBackoff(t *testing.T) {
+	b := &idleBackoff{jitter: func() float64 { return 0 }}
+	base, limit := 2*time.Second, 10*time.Second
+
+	var got []time.Duration
+	for i := 0; i < 5; i++ {
+		got = append(got, b.next(false, base, limit))
+	}
+	want := []time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("idle intervals = %v, want %v", got, want)
+		}
+	}
+
+	if d := b.next(true, base, limit); d != base {
+		t.Errorf("expected reset to %v on new work, got %v", base, d)
+	}
+	if d := b.next(false, base, limit); d != 4*time.Second {
+		t.Errorf("expected backoff to start over after a reset, got %v", d)
+	}
+}
+
+func TestIdleBackoffJitter(t *testing.T) {
+	b := &idleBackoff{jitter: func() float64 { return 0.99 }}
+	base, limit := 2*time.Second, 10*time.Second
+
+	for i := 0; i < 6; i++ {
+		d := b.next(false, base, limit)
+		if d < base || d > limit {
+			t.Fatalf("jittered interval %v outside [%v, %v]", d, base, limit)
+		}
+	}
+	if d := b.next(false, base, limit); d >= limit {
+		t.Errorf("expected jitter to shorten the capped interval, got %v", d)
+	}
+}
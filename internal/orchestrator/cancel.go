@@ -0,0 +1,66 @@
+package orchestrator
+
+import (
+	"github.com/tuanbt/hive/internal/task"
+)
+
+// cancelReason is recorded as the fail reason of user-cancelled tasks.
+const cancelReason = "cancelled by user"
+
+// CancelTask stops a task and marks it failed without retrying it.
+// Active tasks have their agent process killed; pending tasks are simply
+// marked failed so they are never dispatched.
+func (o *Orchestrator) CancelTask(id string) error {
+	t, err := o.taskManager.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if t.Status.IsTerminal() {
+		return nil
+	}
+
+	if t.Status.IsActive() {
+		o.cancelMu.Lock()
+		o.cancelled[id] = true
+		o.cancelMu.Unlock()
+
+		o.workerPool.CancelTask(id)
+	}
+
+	o.logger.Info("task cancelled", "task_id", id, "status", t.Status)
+	return o.taskManager.UpdateStatus(id, task.StatusFailed, cancelReason)
+}
+
+// CancelAll cancels every task that has not yet reached a terminal state.
+// Returns the number of tasks cancelled.
+func (o *Orchestrator) CancelAll() (int, error) {
+	tasks, err := o.taskManager.LoadAll()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, t := range tasks {
+		if t.Status.IsTerminal() {
+			continue
+		}
+		if err := o.CancelTask(t.ID); err != nil {
+			o.logger.Error("failed to cancel task", "task_id", t.ID, "error", err)
+			continue
+		}
+		count++
+	}
+	return count, nil
+}
+
+// consumeCancelled reports whether the task was cancelled and clears the flag.
+func (o *Orchestrator) consumeCancelled(id string) bool {
+	o.cancelMu.Lock()
+	defer o.cancelMu.Unlock()
+
+	if !o.cancelled[id] {
+		return false
+	}
+	delete(o.cancelled, id)
+	return true
+}
@@ -0,0 +1,122 @@
+package orchestrator_test
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tuanbt/hive/internal/orchestrator"
+	"github.com/tuanbt/hive/internal/task"
+)
+
+func TestCancelTaskKillsRunningAgent(t *testing.T) {
+	cfg, tmpDir := setupTest(t)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	cfg.AgentMode = "episodic"
+	cfg.AgentCommand = []string{"bash", "-c", "exec sleep 30"}
+
+	tasksPath := filepath.Join(tmpDir, "tasks.json")
+	testTask := task.Task{
+		ID:        "long-task",
+		Title:     "Long Task",
+		Status:    task.StatusPending,
+		CreatedAt: time.Now(),
+	}
+	data, _ := json.Marshal([]task.Task{testTask})
+	os.WriteFile(tasksPath, data, 0644)
+
+	mgr := task.NewManager(tasksPath)
+	o, err := orchestrator.New(cfg, logger, &MockGitClient{}, mgr)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		o.Run(ctx)
+	}()
+
+	// Wait for the task to be picked up
+	running := false
+	for i := 0; i < 50; i++ {
+		time.Sleep(100 * time.Millisecond)
+		current, _ := mgr.GetByID("long-task")
+		if current != nil && current.Status == task.StatusInProgress {
+			running = true
+			break
+		}
+	}
+	if !running {
+		cancel()
+		wg.Wait()
+		t.Fatal("task was never dispatched")
+	}
+
+	// Let the worker start the agent process
+	time.Sleep(1 * time.Second)
+
+	if err := o.CancelTask("long-task"); err != nil {
+		t.Fatalf("CancelTask() failed: %v", err)
+	}
+
+	// Shutdown waits for workers, so it only returns promptly if the agent was killed
+	start := time.Now()
+	cancel()
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("agent process was not killed, shutdown took %v", elapsed)
+	}
+
+	final, _ := mgr.GetByID("long-task")
+	if final.Status != task.StatusFailed {
+		t.Errorf("expected status failed, got %s", final.Status)
+	}
+	if final.FailReason != "cancelled by user" {
+		t.Errorf("expected cancel reason, got %q", final.FailReason)
+	}
+}
+
+func TestCancelAll(t *testing.T) {
+	cfg, tmpDir := setupTest(t)
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+	tasksPath := filepath.Join(tmpDir, "tasks.json")
+	tasks := []task.Task{
+		{ID: "a", Title: "A", Status: task.StatusPending},
+		{ID: "b", Title: "B", Status: task.StatusCompleted},
+		{ID: "c", Title: "C", Status: task.StatusPending},
+	}
+	data, _ := json.Marshal(tasks)
+	os.WriteFile(tasksPath, data, 0644)
+
+	mgr := task.NewManager(tasksPath)
+	o, err := orchestrator.New(cfg, logger, &MockGitClient{}, mgr)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	count, err := o.CancelAll()
+	if err != nil {
+		t.Fatalf("CancelAll() failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("expected 2 cancelled tasks, got %d", count)
+	}
+
+	loaded, _ := mgr.LoadAll()
+	if loaded[0].Status != task.StatusFailed || loaded[2].Status != task.StatusFailed {
+		t.Error("expected pending tasks to be failed")
+	}
+	if loaded[1].Status != task.StatusCompleted {
+		t.Error("completed task should be untouched")
+	}
+}
@@ -0,0 +1,79 @@
+package orchestrator_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tuanbt/hive/internal/orchestrator"
+	"github.com/tuanbt/hive/internal/task"
+)
+
+func TestDispatchBackpressureKeepsClaims(t *testing.T) {
+	cfg, tmpDir := setupTest(t)
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+	cfg.AgentMode = "episodic"
+	cfg.AgentCommand = []string{"bash", "-c", "sleep 0.3; echo '### TASK_DONE ###'"}
+
+	// One worker buffers two tasks, so five tasks over-subscribe the pool
+	tasksPath := filepath.Join(tmpDir, "tasks.json")
+	var tasks []task.Task
+	for i := 1; i <= 5; i++ {
+		tasks = append(tasks, task.Task{
+			ID:        fmt.Sprintf("task-%d", i),
+			Title:     fmt.Sprintf("Task %d", i),
+			Status:    task.StatusPending,
+			CreatedAt: time.Now(),
+		})
+	}
+	data, _ := json.Marshal(tasks)
+	os.WriteFile(tasksPath, data, 0644)
+
+	auditPath := filepath.Join(tmpDir, "audit.ndjson")
+	mgr := task.NewManager(tasksPath).WithAudit(auditPath)
+	o, err := orchestrator.New(cfg, logger, &MockGitClient{}, mgr)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		o.Run(ctx)
+	}()
+
+	done := false
+	for i := 0; i < 150 && !done; i++ {
+		time.Sleep(100 * time.Millisecond)
+		counts, _ := mgr.CountByStatus()
+		done = counts[task.StatusCompleted] == len(tasks)
+	}
+
+	cancel()
+	wg.Wait()
+
+	if !done {
+		t.Fatal("not all tasks completed")
+	}
+
+	for _, tk := range tasks {
+		entries, err := task.ReadAudit(auditPath, tk.ID)
+		if err != nil {
+			t.Fatalf("failed to read audit: %v", err)
+		}
+		for _, e := range entries {
+			if e.NewStatus == task.StatusPending && e.OldStatus != "" {
+				t.Errorf("task %s bounced back to pending from %s", tk.ID, e.OldStatus)
+			}
+		}
+	}
+}
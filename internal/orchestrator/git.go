@@ -0,0 +1,107 @@
+package orchestrator
+
+import (
+	"fmt"
+
+	"github.com/tuanbt/hive/internal/task"
+)
+
+// prepareGit checks out a new branch for t before it is dispatched and
+// hands it the working tree. Tasks share that tree, so only one holds it
+// at a time, see gitBusy: every change made until its result is handled is
+// then the task's own, and its commit stages nothing from other tasks. ok
+// is false when t can't be dispatched; it is then put back or failed and
+// next reports whether the dispatcher should try another task.
+func (o *Orchestrator) prepareGit(t *task.Task) (ok, next bool) {
+	o.gitOps <- struct{}{}
+	defer func() { <-o.gitOps }()
+
+	// Ensure workspace is clean
+	if clean, err := o.gitClient.IsClean(); err != nil || !clean {
+		o.logger.Warn("cannot dispatch task: git working directory not clean", "task_id", t.ID)
+		o.taskManager.UpdateStatus(t.ID, task.StatusPending, "")
+		return false, false
+	}
+
+	// Create and checkout feature branch
+	gitCfg := o.Config().GitIntegration
+	branchName := gitCfg.BranchName(t.ID)
+	if err := o.gitClient.CheckoutNewBranch(branchName, gitCfg.BaseBranch); err != nil {
+		o.logger.Error("failed to create git branch", "task_id", t.ID, "error", err)
+		o.taskManager.UpdateStatus(t.ID, task.StatusFailed, fmt.Sprintf("git branch failed: %v", err))
+		return false, true
+	}
+	o.logger.Info("created git branch", "branch", branchName)
+	o.gitTask = t.ID
+	return true, false
+}
+
+// gitBusy reports whether a dispatched task still holds the working tree.
+func (o *Orchestrator) gitBusy() bool {
+	o.gitOps <- struct{}{}
+	defer func() { <-o.gitOps }()
+
+	return o.gitTask != ""
+}
+
+// releaseGit frees the working tree if taskID holds it, so the next task
+// can be dispatched.
+func (o *Orchestrator) releaseGit(taskID string) {
+	o.gitOps <- struct{}{}
+	defer func() { <-o.gitOps }()
+
+	if o.gitTask == taskID {
+		o.gitTask = ""
+	}
+}
+
+// commitTask commits, pushes and optionally opens a pull request for the
+// changes of a completed task. It holds the same semaphore as prepareGit,
+// so no branch is checked out for another task while this one's commit
+// and pull request are made. Failures are logged and leave the task
+// completed.
+//
+// The task was dispatched on a clean tree it held alone, so every path
+// changed now is its own.
+func (o *Orchestrator) commitTask(t *task.Task) {
+	o.gitOps <- struct{}{}
+	defer func() { <-o.gitOps }()
+
+	o.logger.Info("committing changes to git", "task_id", t.ID, "phase", task.PhaseCommit)
+
+	paths, err := o.gitClient.ChangedPaths()
+	if err != nil {
+		o.logger.Error("git status failed", "task_id", t.ID, "error", err)
+		return
+	}
+	if len(paths) == 0 {
+		o.logger.Info("no changes to commit", "task_id", t.ID)
+		return
+	}
+	if err := o.gitClient.AddPaths(paths); err != nil {
+		o.logger.Error("git add failed", "task_id", t.ID, "error", err)
+		return
+	}
+
+	gitCfg := o.Config().GitIntegration
+	msg := fmt.Sprintf(gitCfg.CommitMessageFormat, t.Title, t.ID)
+	if err := o.gitClient.Commit(msg); err != nil {
+		o.logger.Error("git commit failed", "task_id", t.ID, "error", err)
+		return
+	}
+
+	branchName := gitCfg.BranchName(t.ID)
+	if err := o.gitClient.Push(gitCfg.Remote, branchName); err != nil {
+		// Don't fail the task, just log error
+		o.logger.Error("git push failed", "task_id", t.ID, "error", err)
+		return
+	}
+	if !gitCfg.CreatePR {
+		return
+	}
+	if err := o.gitClient.CreatePR(t.Title, t.Description); err != nil {
+		o.logger.Error("git pr create failed", "task_id", t.ID, "error", err)
+		return
+	}
+	o.logger.Info("git pr created successfully", "task_id", t.ID)
+}
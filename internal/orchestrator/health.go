@@ -0,0 +1,100 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os/exec"
+	"time"
+)
+
+// healthShutdownTimeout bounds how long the health server waits for open
+// probe requests when the orchestrator stops.
+const healthShutdownTimeout = 5 * time.Second
+
+// HealthAddr returns the address the health server listens on, or "" if it
+// is disabled or not started yet.
+func (o *Orchestrator) HealthAddr() string {
+	if addr := o.healthAddr.Load(); addr != nil {
+		return *addr
+	}
+	return ""
+}
+
+// healthHandler serves the liveness probe at /healthz, answering 200 while
+// the process runs, and the readiness probe at /readyz, answering 200 only
+// once the worker pool is started and the agent check passed.
+func (o *Orchestrator) healthHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if !o.ready.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprintln(w, "not ready")
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintln(w, "ready")
+	})
+	return mux
+}
+
+// startHealthServer listens on HealthAddr and serves the probes in the
+// background. It does nothing when HealthAddr is empty.
+func (o *Orchestrator) startHealthServer() error {
+	healthAddr := o.Config().HealthAddr
+	if healthAddr == "" {
+		return nil
+	}
+
+	ln, err := net.Listen("tcp", healthAddr)
+	if err != nil {
+		return fmt.Errorf("failed to start health server: %w", err)
+	}
+	addr := ln.Addr().String()
+	o.healthAddr.Store(&addr)
+	o.healthServer = &http.Server{
+		Handler:           o.healthHandler(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		if err := o.healthServer.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			o.logger.Error("health server stopped", "error", err)
+		}
+	}()
+	o.logger.Info("health server listening", "addr", addr)
+	return nil
+}
+
+// stopHealthServer shuts the health server down, letting open probe
+// requests finish.
+func (o *Orchestrator) stopHealthServer() {
+	if o.healthServer == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), healthShutdownTimeout)
+	defer cancel()
+	if err := o.healthServer.Shutdown(ctx); err != nil {
+		o.logger.Warn("health server shutdown failed", "error", err)
+	}
+}
+
+// checkAgent verifies that the agent command can be found, so the
+// orchestrator does not report ready while every task would fail to start.
+func (o *Orchestrator) checkAgent() error {
+	cfg := o.Config()
+	argv := cfg.AgentArgv(cfg.WorkDirectory, "")
+	if len(argv) == 0 {
+		return fmt.Errorf("agent command is empty")
+	}
+	if _, err := exec.LookPath(argv[0]); err != nil {
+		return fmt.Errorf("agent command not found: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package orchestrator_test
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tuanbt/hive/internal/orchestrator"
+	"github.com/tuanbt/hive/internal/task"
+)
+
+// probe returns the status code of a GET on the health server.
+func probe(t *testing.T, addr, path string) int {
+	t.Helper()
+	resp, err := http.Get("http://" + addr + path)
+	if err != nil {
+		t.Fatalf("GET %s failed: %v", path, err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		agent     string
+		wantReady int
+	}{
+		{"agent found", "echo", http.StatusOK},
+		{"agent missing", "hive-test-missing-agent", http.StatusServiceUnavailable},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, _ := setupTest(t)
+			cfg.AgentCommand = []string{tc.agent}
+			cfg.HealthAddr = "127.0.0.1:0"
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+			o, err := orchestrator.New(cfg, logger, &MockGitClient{}, task.NewManager(cfg.TasksFile))
+			if err != nil {
+				t.Fatalf("New() failed: %v", err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				o.Run(ctx)
+			}()
+
+			var addr string
+			for i := 0; i < 50 && addr == ""; i++ {
+				time.Sleep(20 * time.Millisecond)
+				addr = o.HealthAddr()
+			}
+			if addr == "" {
+				cancel()
+				wg.Wait()
+				t.Fatal("health server did not start")
+			}
+
+			if code := probe(t, addr, "/healthz"); code != http.StatusOK {
+				t.Errorf("expected /healthz to answer 200, got %d", code)
+			}
+
+			// Readiness is decided right after the pool starts
+			var ready int
+			for i := 0; i < 50; i++ {
+				ready = probe(t, addr, "/readyz")
+				if ready == tc.wantReady {
+					break
+				}
+				time.Sleep(20 * time.Millisecond)
+			}
+			if ready != tc.wantReady {
+				t.Errorf("expected /readyz to answer %d, got %d", tc.wantReady, ready)
+			}
+
+			cancel()
+			wg.Wait()
+
+			if _, err := http.Get("http://" + addr + "/healthz"); err == nil {
+				t.Error("expected the health server to stop with the orchestrator")
+			}
+		})
+	}
+}
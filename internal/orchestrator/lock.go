@@ -0,0 +1,108 @@
+package orchestrator
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// LockFileName is the name of the lock file created in the log directory
+// while an orchestrator is running.
+const LockFileName = "orchestrator.lock"
+
+// ErrAlreadyRunning is returned by Run when another live orchestrator
+// holds the lock file.
+var ErrAlreadyRunning = errors.New("another orchestrator is already running")
+
+// acquireLock creates the lock file at path holding our PID. An existing
+// lock is taken over if its owner is no longer alive or if force is set.
+// Returns ErrAlreadyRunning if a live process holds it.
+func acquireLock(path string, force bool) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create lock directory: %w", err)
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		err := createLock(path)
+		if err == nil {
+			return nil
+		}
+		if !os.IsExist(err) {
+			return fmt.Errorf("failed to create lock file: %w", err)
+		}
+
+		// A lock never appears without its PID, so an empty or
+		// unreadable one was left by a crash and is stale
+		pid := readLockPID(path)
+		if !force && pid > 0 && processAlive(pid) {
+			return fmt.Errorf("%w (pid %d, lock %s); use -force to override", ErrAlreadyRunning, pid, path)
+		}
+
+		// Stale or forced, clear it and try again
+		if err := takeOverLock(path, pid, force); err != nil {
+			return err
+		}
+	}
+
+	return fmt.Errorf("%w (lock %s)", ErrAlreadyRunning, path)
+}
+
+// takeOverLock moves the stale lock at path out of the way. The lock is
+// renamed to a name only we use before it is checked again, so a starter
+// that read the same stale PID can't remove a lock another one has just
+// created in its place. A lock that turns out to be live is put back.
+func takeOverLock(path string, stalePID int, force bool) error {
+	moved := fmt.Sprintf("%s.%d.stale", path, os.Getpid())
+	if err := os.Rename(path, moved); err != nil {
+		if os.IsNotExist(err) {
+			// Someone else already moved it, race them for the new one
+			return nil
+		}
+		return fmt.Errorf("failed to remove stale lock file: %w", err)
+	}
+	defer os.Remove(moved)
+
+	if !force && readLockPID(moved) != stalePID {
+		// Another starter took over between our read and the rename
+		os.Link(moved, path)
+		return fmt.Errorf("%w (lock %s)", ErrAlreadyRunning, path)
+	}
+	return nil
+}
+
+// createLock creates the lock file at path holding our PID, failing with an
+// error satisfying os.IsExist if it already exists. The PID is written to a
+// temporary file first and then linked into place, so another starter
+// never sees the lock half written.
+func createLock(path string) error {
+	tmp := fmt.Sprintf("%s.%d.tmp", path, os.Getpid())
+	if err := os.WriteFile(tmp, []byte(strconv.Itoa(os.Getpid())), 0644); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	defer os.Remove(tmp)
+	return os.Link(tmp, path)
+}
+
+// releaseLock removes the lock file at path if it is still ours.
+func releaseLock(path string) {
+	if readLockPID(path) == os.Getpid() {
+		os.Remove(path)
+	}
+}
+
+// readLockPID returns the PID stored in the lock file, or 0 if unreadable.
+func readLockPID(path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0
+	}
+	return pid
+}
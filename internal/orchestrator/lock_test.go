@@ -0,0 +1,119 @@
+package orchestrator_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/tuanbt/hive/internal/orchestrator"
+	"github.com/tuanbt/hive/internal/task"
+)
+
+func TestRunRefusesWhenLocked(t *testing.T) {
+	cfg, _ := setupTest(t)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	// Our own PID is guaranteed to be alive
+	lockPath := filepath.Join(cfg.LogDirectory, orchestrator.LockFileName)
+	os.WriteFile(lockPath, []byte(strconv.Itoa(os.Getpid())), 0644)
+
+	o, err := orchestrator.New(cfg, logger, &MockGitClient{}, task.NewManager(cfg.TasksFile))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = o.Run(ctx)
+	if !errors.Is(err, orchestrator.ErrAlreadyRunning) {
+		t.Fatalf("expected ErrAlreadyRunning, got %v", err)
+	}
+	if _, statErr := os.Stat(lockPath); statErr != nil {
+		t.Errorf("refused start must not remove the other instance's lock: %v", statErr)
+	}
+}
+
+func TestRunTakesOverLock(t *testing.T) {
+	// A PID from a process that has already exited
+	dead := exec.Command("true")
+	if err := dead.Run(); err != nil {
+		t.Fatalf("failed to run helper process: %v", err)
+	}
+
+	// A live process that isn't us
+	live := exec.Command("sleep", "30")
+	if err := live.Start(); err != nil {
+		t.Fatalf("failed to start helper process: %v", err)
+	}
+	defer func() {
+		live.Process.Kill()
+		live.Wait()
+	}()
+
+	tests := []struct {
+		name  string
+		lock  string
+		force bool
+	}{
+		{name: "stale lock from dead process", lock: strconv.Itoa(dead.Process.Pid)},
+		{name: "empty lock left by a crash", lock: ""},
+		{name: "forced over live process", lock: strconv.Itoa(live.Process.Pid), force: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, _ := setupTest(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+			lockPath := filepath.Join(cfg.LogDirectory, orchestrator.LockFileName)
+			os.WriteFile(lockPath, []byte(tt.lock), 0644)
+
+			o, err := orchestrator.New(cfg, logger, &MockGitClient{}, task.NewManager(cfg.TasksFile))
+			if err != nil {
+				t.Fatalf("New() failed: %v", err)
+			}
+			o.SetForceLock(tt.force)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error, 1)
+			go func() {
+				done <- o.Run(ctx)
+			}()
+
+			// Wait for the lock to carry our PID
+			owned := false
+			for i := 0; i < 50; i++ {
+				time.Sleep(50 * time.Millisecond)
+				data, _ := os.ReadFile(lockPath)
+				if string(data) == strconv.Itoa(os.Getpid()) {
+					owned = true
+					break
+				}
+			}
+			if !owned {
+				cancel()
+				<-done
+				t.Fatal("orchestrator never took over the lock")
+			}
+			if leftovers, _ := filepath.Glob(lockPath + ".*"); len(leftovers) > 0 {
+				t.Errorf("expected no temporary lock files, found %v", leftovers)
+			}
+
+			cancel()
+			if err := <-done; err != nil && err != context.Canceled {
+				t.Fatalf("Run() failed: %v", err)
+			}
+
+			if _, err := os.Stat(lockPath); !os.IsNotExist(err) {
+				t.Errorf("expected lock file to be released on shutdown, stat err: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+//go:build windows
+
+package orchestrator
+
+import (
+	"errors"
+	"syscall"
+)
+
+// stillActive is the exit code GetExitCodeProcess reports for a process
+// that hasn't exited yet.
+const stillActive = 259
+
+// processAlive reports whether a process with the given PID exists.
+// Signal 0 isn't supported on Windows, so the process is opened and its
+// exit code checked instead.
+func processAlive(pid int) bool {
+	h, err := syscall.OpenProcess(syscall.PROCESS_QUERY_INFORMATION, false, uint32(pid))
+	if err != nil {
+		// The process exists but belongs to someone we can't query
+		return errors.Is(err, syscall.ERROR_ACCESS_DENIED)
+	}
+	defer syscall.CloseHandle(h)
+
+	var code uint32
+	if err := syscall.GetExitCodeProcess(h, &code); err != nil {
+		return true
+	}
+	return code == stillActive
+}
@@ -3,9 +3,12 @@ package orchestrator
 
 import (
 	"context"
-	"fmt"
 	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/tuanbt/hive/internal/config"
@@ -18,18 +21,38 @@ import (
 // It coordinates between the task manager (registry), the worker pool,
 // and optional git integration for automated pull requests.
 type Orchestrator struct {
-	config      *config.Config
+	config      atomic.Pointer[config.Config] // replaced as a whole by Reload
 	taskManager *task.Manager
 	workerPool  *worker.Pool
 	logger      *slog.Logger
 	gitClient   git.Client
 
-	wg       sync.WaitGroup
-	stopChan chan struct{}
+	wg           sync.WaitGroup
+	stopChan     chan struct{}
+	dispatchDone chan struct{}
+	poolCancel   context.CancelFunc
+
+	lockPath  string
+	forceLock bool
+	lockHeld  bool // taken by AcquireLock ahead of Run
+
+	reloadMu            sync.Mutex
+	dispatchInterval    atomic.Int64
+	dispatchMaxInterval atomic.Int64
+
+	cancelMu  sync.Mutex
+	cancelled map[string]bool
+
+	gitOps  chan struct{} // semaphore bounding concurrent git operations
+	gitTask string        // task the working tree belongs to, guarded by gitOps
+
+	healthServer *http.Server
+	healthAddr   atomic.Pointer[string]
+	ready        atomic.Bool // pool started and agent found
+
+	reviewPlans atomic.Bool
 }
 
-// New initializes a new Orchestrator instance with the provided dependencies.
-// It ensures the task registry file exists before returning.
 // New initializes a new Orchestrator instance with the provided dependencies.
 // It ensures the task registry file exists before returning.
 func New(cfg *config.Config, logger *slog.Logger, gitClient git.Client, taskMgr *task.Manager) (*Orchestrator, error) {
@@ -38,27 +61,108 @@ func New(cfg *config.Config, logger *slog.Logger, gitClient git.Client, taskMgr
 	}
 
 	pool := worker.NewPool(cfg, logger, cfg.WorkDirectory)
+	pool.SetHeartbeat(taskMgr.Heartbeat)
+	pool.SetCommandRecorder(taskMgr.SetAgentCmd)
+	pool.SetStartNotifier(taskMgr.StartTask)
+
+	o := &Orchestrator{
+		taskManager:  taskMgr,
+		workerPool:   pool,
+		logger:       logger,
+		gitClient:    gitClient,
+		stopChan:     make(chan struct{}),
+		dispatchDone: make(chan struct{}),
+		poolCancel:   func() {},
+		lockPath:     filepath.Join(cfg.LogDirectory, LockFileName),
+		cancelled:    make(map[string]bool),
+		gitOps:       make(chan struct{}, max(cfg.MaxConcurrentGitOps, 1)),
+	}
+	o.config.Store(cfg)
+	o.dispatchInterval.Store(int64(time.Duration(cfg.DispatchIntervalSeconds) * time.Second))
+	o.dispatchMaxInterval.Store(int64(time.Duration(cfg.DispatchMaxIntervalSeconds) * time.Second))
+	return o, nil
+}
+
+// SetForceLock makes Run take over the lock file even if another live
+// orchestrator holds it.
+func (o *Orchestrator) SetForceLock(force bool) {
+	o.forceLock = force
+}
+
+// AcquireLock takes the lock file ahead of Run, so a caller learns whether
+// it may lead before starting anything else. Run keeps the lock and releases
+// it on shutdown. Returns ErrAlreadyRunning if another live orchestrator
+// holds it.
+func (o *Orchestrator) AcquireLock() error {
+	if err := acquireLock(o.lockPath, o.forceLock); err != nil {
+		return err
+	}
+	o.lockHeld = true
+	return nil
+}
+
+// Config returns the current config. Reload publishes a new value rather
+// than changing the old one, so callers may keep reading the returned config
+// for the rest of an operation.
+func (o *Orchestrator) Config() *config.Config {
+	return o.config.Load()
+}
+
+// ActiveWorkers returns the number of running workers in the pool.
+func (o *Orchestrator) ActiveWorkers() int {
+	return o.workerPool.ActiveWorkers()
+}
 
-	return &Orchestrator{
-		config:      cfg,
-		taskManager: taskMgr,
-		workerPool:  pool,
-		logger:      logger,
-		gitClient:   gitClient,
-		stopChan:    make(chan struct{}),
-	}, nil
+// WorkerStatus returns what each worker in the pool is doing.
+func (o *Orchestrator) WorkerStatus() []worker.WorkerInfo {
+	return o.workerPool.Status()
 }
 
 // Run starts the orchestrator and blocks until context is cancelled.
+// Returns ErrAlreadyRunning if another orchestrator holds the lock file.
 func (o *Orchestrator) Run(ctx context.Context) error {
+	// Refuse to share the tasks file with another live instance
+	if !o.lockHeld {
+		if err := o.AcquireLock(); err != nil {
+			return err
+		}
+	}
+
+	// Probes answer from the start; readiness follows the pool and agent
+	if err := o.startHealthServer(); err != nil {
+		releaseLock(o.lockPath)
+		return err
+	}
+
+	cfg := o.Config()
 	o.logger.Info("orchestrator starting",
-		"num_workers", o.config.NumWorkers,
-		"tasks_file", o.config.TasksFile,
+		"num_workers", cfg.NumWorkers,
+		"tasks_file", cfg.TasksFile,
 	)
 
+	if cfg.GitIntegration.Enabled && cfg.NumWorkers > 1 {
+		o.logger.Warn("git integration runs one task at a time on the shared working tree",
+			"num_workers", cfg.NumWorkers,
+		)
+	}
+
+	if cfg.NumWorkers > config.DefaultMaxWorkers {
+		o.logger.Warn("running with high concurrency",
+			"num_workers", cfg.NumWorkers,
+			"default_max", config.DefaultMaxWorkers,
+		)
+	}
+
 	// Recover stuck tasks
-	if o.config.RecoverInProgressOnStartup {
-		recovered, err := o.taskManager.RecoverInProgress()
+	if cfg.RecoverInProgressOnStartup {
+		var recovered int
+		var err error
+		if cfg.StuckTaskThresholdSeconds > 0 {
+			threshold := time.Duration(cfg.StuckTaskThresholdSeconds) * time.Second
+			recovered, err = o.taskManager.RecoverStuck(threshold)
+		} else {
+			recovered, err = o.taskManager.RecoverInProgress()
+		}
 		if err != nil {
 			o.logger.Error("failed to recover in-progress tasks", "error", err)
 		} else if recovered > 0 {
@@ -66,20 +170,42 @@ func (o *Orchestrator) Run(ctx context.Context) error {
 		}
 	}
 
+	// Nobody reviews plans in this run, add those left held by one that did
+	if !o.reviewPlans.Load() {
+		o.addHeldPlans()
+	}
+
 	// Log initial task counts
-	counts, _ := o.taskManager.CountByStatus()
+	stats, _ := o.taskManager.Stats()
 	o.logger.Info("task status summary",
-		"pending", counts[task.StatusPending],
-		"in_progress", counts[task.StatusInProgress],
-		"completed", counts[task.StatusCompleted],
-		"failed", counts[task.StatusFailed],
+		"total", stats.Total,
+		"pending", stats.Counts[task.StatusPending],
+		"queued", stats.Counts[task.StatusQueued],
+		"in_progress", stats.Counts[task.StatusInProgress],
+		"completed", stats.Counts[task.StatusCompleted],
+		"failed", stats.Counts[task.StatusFailed],
+		"blocked", stats.Counts[task.StatusBlocked],
+		"oldest_pending", stats.OldestPendingAge.Round(time.Second),
+		"longest_running", stats.LongestRunning.Round(time.Second),
 	)
 
-	// Start worker pool
-	if err := o.workerPool.Start(ctx); err != nil {
+	// Start worker pool on its own context so in-flight tasks can drain
+	// during shutdown instead of being killed as soon as ctx is cancelled
+	poolCtx, poolCancel := context.WithCancel(context.Background())
+	o.poolCancel = poolCancel
+	if err := o.workerPool.Start(poolCtx); err != nil {
+		poolCancel()
+		o.stopHealthServer()
+		releaseLock(o.lockPath)
 		return err
 	}
 
+	if err := o.checkAgent(); err != nil {
+		o.logger.Error("agent health check failed, not ready", "error", err)
+	} else {
+		o.ready.Store(true)
+	}
+
 	// Start dispatcher goroutine
 	o.wg.Add(1)
 	go o.dispatchTasks(ctx)
@@ -98,11 +224,13 @@ func (o *Orchestrator) Run(ctx context.Context) error {
 // dispatchTasks polls for pending tasks and submits them to the pool.
 func (o *Orchestrator) dispatchTasks(ctx context.Context) {
 	defer o.wg.Done()
+	defer close(o.dispatchDone)
 
 	o.logger.Info("task dispatcher started")
 
-	ticker := time.NewTicker(2 * time.Second)
-	defer ticker.Stop()
+	backoff := &idleBackoff{jitter: rand.Float64}
+	timer := time.NewTimer(time.Duration(o.dispatchInterval.Load()))
+	defer timer.Stop()
 
 	for {
 		select {
@@ -110,61 +238,62 @@ func (o *Orchestrator) dispatchTasks(ctx context.Context) {
 			o.logger.Info("task dispatcher stopping")
 			return
 
-		case <-ticker.C:
-			// Check if pool can accept tasks
-			if o.workerPool.IsFull() {
-				continue
-			}
-
-			// Get next pending task
-			t, err := o.taskManager.GetNextPending()
-			if err != nil {
-				o.logger.Error("failed to get next task", "error", err)
-				continue
+		case <-timer.C:
+			// Drain pending tasks; once the pool is saturated SubmitBlocking
+			// holds the dispatcher until a worker frees a slot
+			found := false
+			for o.dispatchNext(ctx) {
+				found = true
 			}
 
-			if t == nil {
-				// No pending tasks
-				continue
-			}
+			// Poll less often while idle; intervals changed by Reload
+			// apply from the next poll
+			base := time.Duration(o.dispatchInterval.Load())
+			limit := time.Duration(o.dispatchMaxInterval.Load())
+			timer.Reset(backoff.next(found, base, limit))
+		}
+	}
+}
 
-			// Try to claim the task
-			workerID := 0 // Will be set by worker
-			if err := o.taskManager.ClaimTask(t.ID, workerID); err != nil {
-				o.logger.Warn("failed to claim task", "task_id", t.ID, "error", err)
-				continue
-			}
+// dispatchNext claims the next pending task and hands it to the pool.
+// Returns true if the dispatcher should immediately try another task.
+func (o *Orchestrator) dispatchNext(ctx context.Context) bool {
+	// Under git integration the working tree takes one task at a time
+	if o.Config().GitIntegration.Enabled && o.gitBusy() {
+		return false
+	}
 
-			// Handle Git Integration
-			if o.config.GitIntegration.Enabled {
-				// Ensure workspace is clean
-				if clean, err := o.gitClient.IsClean(); err != nil || !clean {
-					o.logger.Warn("cannot dispatch task: git working directory not clean", "task_id", t.ID)
-					o.taskManager.UpdateStatus(t.ID, task.StatusPending, "")
-					continue
-				}
-
-				// Create and checkout feature branch
-				branchName := fmt.Sprintf("%s%s", o.config.GitIntegration.BranchPrefix, t.ID)
-				if err := o.gitClient.CheckoutNewBranch(branchName, o.config.GitIntegration.BaseBranch); err != nil {
-					o.logger.Error("failed to create git branch", "task_id", t.ID, "error", err)
-					o.taskManager.UpdateStatus(t.ID, task.StatusFailed, fmt.Sprintf("git branch failed: %v", err))
-					continue
-				}
-				o.logger.Info("created git branch", "branch", branchName)
-			}
+	// Claim the next pending task, the worker marks it in_progress on start
+	workerID := 0 // Will be set by worker
+	t, err := o.taskManager.ClaimNext(workerID)
+	if err != nil {
+		o.logger.Error("failed to claim next task", "error", err)
+		return false
+	}
 
-			// Submit to pool
-			if !o.workerPool.Submit(t) {
-				// Failed to submit, reset task status
-				o.taskManager.UpdateStatus(t.ID, task.StatusPending, "")
-				o.logger.Warn("failed to submit task to pool", "task_id", t.ID)
-				continue
-			}
+	if t == nil {
+		// No pending tasks
+		return false
+	}
 
-			o.logger.Info("task dispatched", "task_id", t.ID, "title", t.Title)
+	// Handle Git Integration
+	if o.Config().GitIntegration.Enabled {
+		if ok, next := o.prepareGit(t); !ok {
+			return next
 		}
 	}
+
+	// Submit to pool, blocking until a slot frees so the claim holds
+	if err := o.workerPool.SubmitBlocking(ctx, t); err != nil {
+		// Shutting down before the task was handed over
+		o.releaseGit(t.ID)
+		o.taskManager.UpdateStatus(t.ID, task.StatusPending, "")
+		o.logger.Info("requeued undispatched task on shutdown", "task_id", t.ID)
+		return false
+	}
+
+	o.logger.Info("task dispatched", "task_id", t.ID, "title", t.Title)
+	return true
 }
 
 // handleResults processes results from the worker pool.
@@ -183,6 +312,7 @@ func (o *Orchestrator) handleResults(ctx context.Context) {
 // processResult handles a single task result.
 func (o *Orchestrator) processResult(result *worker.TaskResult) {
 	t := result.Task
+	defer o.releaseGit(t.ID)
 
 	o.logger.Info("task completed",
 		"task_id", t.ID,
@@ -190,110 +320,146 @@ func (o *Orchestrator) processResult(result *worker.TaskResult) {
 		"status", result.Status,
 		"worker_id", result.WorkerID,
 		"duration", result.Duration,
+		"completion", result.Completion,
+		"exit_code", result.ExitCode,
 	)
 
 	// Update task status
 	reason := ""
 	if result.Error != nil {
 		reason = result.Error.Error()
-		o.logger.Error("task failed", "task_id", t.ID, "error", reason)
+		if result.Status == task.StatusBlocked {
+			o.logger.Warn("task blocked, needs human input", "task_id", t.ID, "reason", reason)
+		} else {
+			o.logger.Error("task failed", "task_id", t.ID, "error", reason)
+		}
 	}
 
-	if err := o.taskManager.UpdateStatus(t.ID, result.Status, reason); err != nil {
-		o.logger.Error("failed to update task status", "task_id", t.ID, "error", err)
+	// Cancelled tasks stay failed and are never retried
+	cancelled := o.consumeCancelled(t.ID)
+	o.workerPool.ForgetCancel(t.ID)
+	if cancelled {
+		result.Status = task.StatusFailed
+		reason = cancelReason
 	}
 
-	// Autopilot: Auto-Retry Logic
-	if result.Status == task.StatusFailed || result.Error != nil {
-		if t.RetryCount < o.config.MaxTaskRetries {
-			newCount := t.IncrementRetry()
-			t.ResetForRetry()
-			if err := o.taskManager.UpdateTask(t); err != nil {
-				o.logger.Error("failed to reset task for retry", "task_id", t.ID, "error", err)
-			} else {
-				o.logger.Info("autopilot: retrying task", "task_id", t.ID, "attempt", newCount, "reason", reason)
-				return // Skip finding new tasks / git commit, just let it be picked up again
+	// Keep how the agent run ended; a cancellation is its own reason
+	completion := string(result.Completion)
+	if cancelled {
+		completion = ""
+	}
+
+	// Autopilot: retry transient failures after a backoff. Blocked tasks
+	// wait for a human and other failures, like a rejected review, stay failed.
+	blocked := result.Status == task.StatusBlocked
+	failed := (result.Status == task.StatusFailed || result.Error != nil) && !blocked && !cancelled
+	retry := o.Config().AutoRetry
+
+	// The whole outcome is saved in one write, so no reader sees a task
+	// that is failed but not yet reset for its retry, and no field set
+	// elsewhere meanwhile is overwritten
+	var retried bool
+	var attempt int
+	var backoff time.Duration
+	err := o.taskManager.Modify(t.ID, func(stored *task.Task) error {
+		stored.CompletionReason = completion
+		stored.SetStatus(result.Status, reason)
+
+		// Extra retry instructions only apply until the task succeeds
+		if result.Status == task.StatusCompleted {
+			stored.ExtraInstructions = ""
+		}
+
+		if failed && retry.Retryable(completion) && stored.AutoRetries < retry.MaxAutoRetries {
+			retried = true
+			attempt = stored.IncrementAutoRetry()
+			backoff = retry.Backoff(attempt)
+			stored.FailReason = reason
+			stored.ResetForRetry()
+			if backoff > 0 {
+				stored.RetryAfter = time.Now().Add(backoff)
 			}
 		}
+		*t = *stored.Copy()
+		return nil
+	})
+	if err != nil {
+		o.logger.Error("failed to record task result", "task_id", t.ID, "error", err)
+	} else if retried {
+		o.logger.Info("autopilot: retrying task", "task_id", t.ID, "attempt", attempt, "backoff", backoff, "reason", reason)
+		return // Skip finding new tasks / git commit, just let it be picked up again
+	} else if failed {
+		o.logger.Info("autopilot: not retrying task", "task_id", t.ID, "completion", completion, "retries", t.RetryCount)
 	}
 
+	// Notify webhooks now that the task has reached a terminal state
+	o.notifyWebhook(result, reason)
+
 	// Add new tasks if any (auto-planning)
 	if len(result.NewTasks) > 0 {
-		o.logger.Info("adding new tasks from agent plan", "count", len(result.NewTasks))
-		for _, nt := range result.NewTasks {
-			if err := o.taskManager.AddTask(nt); err != nil {
-				o.logger.Error("failed to add new task", "title", nt.Title, "error", err)
-			}
-		}
+		o.handlePlan(t, result.NewTasks)
 	}
 
 	// Handle Git Integration (Commit/Push)
-	if result.Status == task.StatusCompleted && o.config.GitIntegration.Enabled {
-		o.logger.Info("committing changes to git", "task_id", t.ID)
-
-		if err := o.gitClient.AddAll(); err != nil {
-			o.logger.Error("git add failed", "task_id", t.ID, "error", err)
-		} else {
-			msg := fmt.Sprintf(o.config.GitIntegration.CommitMessageFormat, t.Title, t.ID)
-			if err := o.gitClient.Commit(msg); err != nil {
-				o.logger.Error("git commit failed", "task_id", t.ID, "error", err)
-			} else {
-				branchName := fmt.Sprintf("%s%s", o.config.GitIntegration.BranchPrefix, t.ID)
-				if err := o.gitClient.Push(o.config.GitIntegration.Remote, branchName); err != nil {
-					// Don't fail the task, just log error
-					o.logger.Error("git push failed", "task_id", t.ID, "error", err)
-				} else if o.config.GitIntegration.CreatePR {
-					if err := o.gitClient.CreatePR(t.Title, t.Description); err != nil {
-						o.logger.Error("git pr create failed", "task_id", t.ID, "error", err)
-					} else {
-						o.logger.Info("git pr created successfully", "task_id", t.ID)
-					}
-				}
-			}
-		}
+	if result.Status == task.StatusCompleted && o.Config().GitIntegration.Enabled {
+		o.commitTask(t)
 	}
 
 	// Log current counts
 	counts, _ := o.taskManager.CountByStatus()
 	o.logger.Debug("task status summary",
 		"pending", counts[task.StatusPending],
+		"queued", counts[task.StatusQueued],
 		"in_progress", counts[task.StatusInProgress],
 		"completed", counts[task.StatusCompleted],
 		"failed", counts[task.StatusFailed],
+		"blocked", counts[task.StatusBlocked],
 	)
 }
 
 // Shutdown gracefully stops the orchestrator.
 func (o *Orchestrator) Shutdown(ctx context.Context) error {
 	o.logger.Info("shutting down orchestrator")
+	o.ready.Store(false)
 
 	// Signal stop
 	close(o.stopChan)
 
-	// Stop worker pool (waits for in-flight tasks)
-	o.workerPool.Stop()
+	// Make sure nothing else is submitted before the task channel closes
+	<-o.dispatchDone
 
-	// Wait for goroutines with timeout
-	done := make(chan struct{})
+	// Stop worker pool, letting in-flight tasks drain until the timeout
+	timeout := time.Duration(o.Config().ShutdownTimeoutSeconds) * time.Second
+	stopped := make(chan struct{})
 	go func() {
-		o.wg.Wait()
-		close(done)
+		o.workerPool.Stop()
+		close(stopped)
 	}()
 
 	select {
-	case <-done:
-		o.logger.Info("orchestrator shutdown complete")
-	case <-time.After(30 * time.Second):
-		o.logger.Warn("shutdown timeout, forcing exit")
+	case <-stopped:
+	case <-time.After(timeout):
+		o.logger.Warn("shutdown timeout, killing in-flight agents", "timeout", timeout)
+		o.poolCancel()
+		<-stopped
 	}
+	o.poolCancel()
+
+	// Wait for the result handler to drain remaining results
+	o.wg.Wait()
+	o.stopHealthServer()
+	releaseLock(o.lockPath)
+	o.logger.Info("orchestrator shutdown complete")
 
 	// Final status report
 	counts, _ := o.taskManager.CountByStatus()
 	o.logger.Info("final task status",
 		"pending", counts[task.StatusPending],
+		"queued", counts[task.StatusQueued],
 		"in_progress", counts[task.StatusInProgress],
 		"completed", counts[task.StatusCompleted],
 		"failed", counts[task.StatusFailed],
+		"blocked", counts[task.StatusBlocked],
 	)
 
 	return nil
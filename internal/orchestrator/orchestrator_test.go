@@ -3,9 +3,13 @@ package orchestrator_test
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -20,6 +24,8 @@ type MockGitClient struct {
 	IsCleanFunc           func() (bool, error)
 	CheckoutNewBranchFunc func(branch, base string) error
 	AddAllFunc            func() error
+	AddPathsFunc          func(paths []string) error
+	ChangedPathsFunc      func() ([]string, error)
 	CommitFunc            func(message string) error
 	PushFunc              func(remote, branch string) error
 	CreatePRFunc          func(title, body string) error
@@ -44,6 +50,18 @@ func (m *MockGitClient) AddAll() error {
 	}
 	return nil
 }
+func (m *MockGitClient) AddPaths(paths []string) error {
+	if m.AddPathsFunc != nil {
+		return m.AddPathsFunc(paths)
+	}
+	return nil
+}
+func (m *MockGitClient) ChangedPaths() ([]string, error) {
+	if m.ChangedPathsFunc != nil {
+		return m.ChangedPathsFunc()
+	}
+	return nil, nil
+}
 func (m *MockGitClient) Commit(message string) error {
 	if m.CommitFunc != nil {
 		return m.CommitFunc(message)
@@ -158,10 +176,11 @@ func TestRun_TaskProcessing(t *testing.T) {
 
 	tasksPath := filepath.Join(tmpDir, "tasks.json")
 	testTask := task.Task{
-		ID:        "test-task-1",
-		Title:     "Unit Test Task",
-		Status:    task.StatusPending,
-		CreatedAt: time.Now(),
+		ID:                "test-task-1",
+		Title:             "Unit Test Task",
+		Status:            task.StatusPending,
+		ExtraInstructions: "Also handle nulls",
+		CreatedAt:         time.Now(),
 	}
 
 	tasks := []task.Task{testTask}
@@ -203,6 +222,280 @@ func TestRun_TaskProcessing(t *testing.T) {
 	if !success {
 		t.Fatal("Task did not transition to 'completed' within timeout")
 	}
+
+	final, _ := task.NewManager(tasksPath).GetByID("test-task-1")
+	if final.ExtraInstructions != "" {
+		t.Errorf("expected extra instructions to be cleared after success, got %q", final.ExtraInstructions)
+	}
+	if final.CompletionReason != "marker_found" {
+		t.Errorf("expected completion reason to be persisted, got %q", final.CompletionReason)
+	}
+}
+
+func TestRun_BlockedTaskNotRetried(t *testing.T) {
+	cfg, tmpDir := setupTest(t)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	cfg.AgentMode = "episodic"
+	cfg.AgentCommand = []string{"echo", "Which database should I use?\n### TASK_BLOCKED ###"}
+	cfg.AutoRetry.MaxAutoRetries = 3
+
+	tasksPath := filepath.Join(tmpDir, "tasks.json")
+	data, _ := json.Marshal([]task.Task{{
+		ID:        "blocked-task",
+		Title:     "Needs input",
+		Status:    task.StatusPending,
+		CreatedAt: time.Now(),
+	}})
+	os.WriteFile(tasksPath, data, 0644)
+
+	o, err := orchestrator.New(cfg, logger, &MockGitClient{}, task.NewManager(tasksPath))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		o.Run(ctx)
+	}()
+
+	var final *task.Task
+	for i := 0; i < 50; i++ {
+		time.Sleep(100 * time.Millisecond)
+		got, err := task.NewManager(tasksPath).GetByID("blocked-task")
+		if err == nil && got.Status.IsTerminal() {
+			final = got
+			break
+		}
+	}
+
+	cancel()
+	wg.Wait()
+
+	if final == nil {
+		t.Fatal("task did not reach a terminal status within timeout")
+	}
+	if final.Status != task.StatusBlocked {
+		t.Fatalf("expected task to be blocked, got %s (%s)", final.Status, final.FailReason)
+	}
+	if final.RetryCount != 0 {
+		t.Errorf("expected blocked task not to be retried, got %d retries", final.RetryCount)
+	}
+	if final.CompletionReason != "blocked" {
+		t.Errorf("expected completion reason blocked, got %q", final.CompletionReason)
+	}
+}
+
+// runUntil runs an orchestrator over a single pending task until done
+// reports true for it or five seconds pass, and returns the task's final
+// state.
+func runUntil(t *testing.T, cfg *config.Config, tasksPath, id string, done func(*task.Task) bool) *task.Task {
+	t.Helper()
+
+	return runTaskUntil(t, cfg, tasksPath, task.Task{
+		ID:        id,
+		Title:     "Retry policy task",
+		Status:    task.StatusPending,
+		CreatedAt: time.Now(),
+	}, done)
+}
+
+// runTaskUntil is runUntil for a task the caller sets up.
+func runTaskUntil(t *testing.T, cfg *config.Config, tasksPath string, seed task.Task, done func(*task.Task) bool) *task.Task {
+	t.Helper()
+
+	id := seed.ID
+	data, _ := json.Marshal([]task.Task{seed})
+	os.WriteFile(tasksPath, data, 0644)
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	o, err := orchestrator.New(cfg, logger, &MockGitClient{}, task.NewManager(tasksPath))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		o.Run(ctx)
+	}()
+
+	var final *task.Task
+	for i := 0; i < 50; i++ {
+		time.Sleep(100 * time.Millisecond)
+		got, err := task.NewManager(tasksPath).GetByID(id)
+		if err == nil && done(got) {
+			final = got
+			break
+		}
+	}
+
+	cancel()
+	wg.Wait()
+
+	if final == nil {
+		final, _ = task.NewManager(tasksPath).GetByID(id)
+		t.Fatalf("task did not reach the expected state within timeout, last: %+v", final)
+	}
+	return final
+}
+
+func TestAutoRetryExhausted(t *testing.T) {
+	cfg, tmpDir := setupTest(t)
+	cfg.AgentMode = "episodic"
+	cfg.AgentCommand = []string{"true"} // exits without output: a retryable no_output failure
+	cfg.DispatchIntervalSeconds = 1
+	cfg.AutoRetry = config.AutoRetryConfig{
+		MaxAutoRetries:    2,
+		AutoRetryStatuses: []string{"no_output"},
+	}
+
+	final := runUntil(t, cfg, filepath.Join(tmpDir, "tasks.json"), "flaky-task", func(got *task.Task) bool {
+		return got.Status == task.StatusFailed && got.RetryCount == 2
+	})
+
+	if final.CompletionReason != "no_output" {
+		t.Errorf("expected completion reason no_output, got %q", final.CompletionReason)
+	}
+	if final.LastError == "" {
+		t.Error("expected the previous attempt's error to be kept")
+	}
+}
+
+func TestAutoRetrySkipsNonRetryableFailure(t *testing.T) {
+	cfg, tmpDir := setupTest(t)
+	cfg.AgentMode = "episodic"
+	cfg.AgentCommand = []string{"sh", "-c", "echo tests failing; exit 1"} // never passes the review
+	cfg.MaxReviewCycles = 1
+	cfg.AutoRetry.MaxAutoRetries = 3
+	cfg.AutoRetry.AutoRetryStatuses = []string{"process_error"}
+
+	final := runUntil(t, cfg, filepath.Join(tmpDir, "tasks.json"), "rejected-task", func(got *task.Task) bool {
+		return got.Status == task.StatusFailed
+	})
+
+	// Give a wrongly scheduled retry the chance to show up
+	time.Sleep(300 * time.Millisecond)
+	final, _ = task.NewManager(filepath.Join(tmpDir, "tasks.json")).GetByID("rejected-task")
+
+	if final.Status != task.StatusFailed {
+		t.Errorf("expected task to stay failed, got %s", final.Status)
+	}
+	if final.RetryCount != 0 {
+		t.Errorf("expected a rejected review not to be retried, got %d retries", final.RetryCount)
+	}
+	if final.CompletionReason != "review_rejected" {
+		t.Errorf("expected completion reason review_rejected, got %q", final.CompletionReason)
+	}
+}
+
+func TestAutoRetryKeepsFieldsEditedDuringRun(t *testing.T) {
+	cfg, tmpDir := setupTest(t)
+	cfg.AgentMode = "episodic"
+	cfg.AgentCommand = []string{"sh", "-c", "cat >/dev/null; sleep 1"} // no output: a retryable failure
+	cfg.DispatchIntervalSeconds = 1
+	cfg.AutoRetry = config.AutoRetryConfig{
+		MaxAutoRetries:    1,
+		AutoRetryStatuses: []string{"no_output"},
+	}
+	tasksPath := filepath.Join(tmpDir, "tasks.json")
+
+	// Edit the task while its agent runs, as the TUI or CLI would
+	edited := false
+	final := runUntil(t, cfg, tasksPath, "edited-task", func(got *task.Task) bool {
+		if got.Status == task.StatusInProgress && !edited {
+			edited = true
+			err := task.NewManager(tasksPath).Modify(got.ID, func(stored *task.Task) error {
+				stored.Priority = 7
+				return nil
+			})
+			if err != nil {
+				t.Errorf("failed to edit the running task: %v", err)
+			}
+		}
+		return got.Status == task.StatusFailed && got.RetryCount == 1
+	})
+
+	if !edited {
+		t.Fatal("task was never seen in progress")
+	}
+	if final.Priority != 7 {
+		t.Errorf("expected the edit made during the run to survive the retry, got priority %d", final.Priority)
+	}
+	if !slices.Equal(final.AgentCmd, cfg.AgentCommand) {
+		t.Errorf("expected the agent command to be recorded, got %q", final.AgentCmd)
+	}
+}
+
+func TestExtraInstructionsKeptAcrossAutoRetry(t *testing.T) {
+	cfg, tmpDir := setupTest(t)
+	prompts := filepath.Join(tmpDir, "prompts.txt")
+	cfg.AgentMode = "episodic"
+	cfg.AgentCommand = []string{"sh", "-c", "cat >> " + prompts} // no output: a retryable failure
+	cfg.DispatchIntervalSeconds = 1
+	cfg.AutoRetry = config.AutoRetryConfig{
+		MaxAutoRetries:    1,
+		AutoRetryStatuses: []string{"no_output"},
+	}
+
+	final := runTaskUntil(t, cfg, filepath.Join(tmpDir, "tasks.json"), task.Task{
+		ID:                "appended-task",
+		Title:             "Retried with instructions",
+		Status:            task.StatusPending,
+		ExtraInstructions: "Also handle nulls",
+		CreatedAt:         time.Now(),
+	}, func(got *task.Task) bool {
+		return got.Status == task.StatusFailed && got.RetryCount == 1
+	})
+
+	// Only a success clears them, so both attempts were told
+	if final.ExtraInstructions != "Also handle nulls" {
+		t.Errorf("expected extra instructions to survive the failures, got %q", final.ExtraInstructions)
+	}
+	data, err := os.ReadFile(prompts)
+	if err != nil {
+		t.Fatalf("failed to read prompts: %v", err)
+	}
+	if n := strings.Count(string(data), "Also handle nulls"); n != 2 {
+		t.Errorf("expected the instructions in both prompts, found them %d times", n)
+	}
+}
+
+func TestCompletionReasonFollowsLastRun(t *testing.T) {
+	cfg, tmpDir := setupTest(t)
+	marker := filepath.Join(tmpDir, "ran-once")
+	cfg.AgentMode = "episodic"
+	// Silent on the first run, done on every later one
+	cfg.AgentCommand = []string{"sh", "-c", "cat >/dev/null; if [ -f " + marker + " ]; then echo done; echo '### TASK_DONE ###'; else touch " + marker + "; fi"}
+	cfg.DispatchIntervalSeconds = 1
+	cfg.AutoRetry = config.AutoRetryConfig{
+		MaxAutoRetries:    1,
+		AutoRetryStatuses: []string{"no_output"},
+	}
+	tasksPath := filepath.Join(tmpDir, "tasks.json")
+
+	var reasons []string
+	final := runUntil(t, cfg, tasksPath, "second-run-task", func(got *task.Task) bool {
+		if got.CompletionReason != "" && (len(reasons) == 0 || reasons[len(reasons)-1] != got.CompletionReason) {
+			reasons = append(reasons, got.CompletionReason)
+		}
+		return got.Status == task.StatusCompleted
+	})
+
+	if final.CompletionReason != "marker_found" {
+		t.Errorf("expected the successful run's reason, got %q", final.CompletionReason)
+	}
+	if final.RetryCount != 1 {
+		t.Errorf("expected one retry, got %d", final.RetryCount)
+	}
+	if len(reasons) == 0 || reasons[0] != "no_output" {
+		t.Errorf("expected the failed run's reason to be recorded first, saw %v", reasons)
+	}
 }
 
 func TestRecoverInProgressOnStartup(t *testing.T) {
@@ -259,12 +552,22 @@ func TestGitIntegration(t *testing.T) {
 
 	var checkoutCalled, commitCalled, pushCalled bool
 	var checkoutBranch string
+	var added []string
 
 	mockGit.CheckoutNewBranchFunc = func(branch, base string) error {
 		checkoutCalled = true
 		checkoutBranch = branch
 		return nil
 	}
+	// The tree is clean at dispatch, the agent then edits README.md and
+	// adds main.go
+	mockGit.ChangedPathsFunc = func() ([]string, error) {
+		return []string{"README.md", "main.go"}, nil
+	}
+	mockGit.AddPathsFunc = func(paths []string) error {
+		added = paths
+		return nil
+	}
 	mockGit.CommitFunc = func(msg string) error {
 		commitCalled = true
 		return nil
@@ -321,6 +624,9 @@ func TestGitIntegration(t *testing.T) {
 	if checkoutBranch != "agent/task-git-task" {
 		t.Errorf("Expected branch 'agent/task-git-task', got '%s'", checkoutBranch)
 	}
+	if !slices.Equal(added, []string{"README.md", "main.go"}) {
+		t.Errorf("Expected the changed paths to be staged, got %v", added)
+	}
 	if !commitCalled {
 		t.Error("Git commit not called")
 	}
@@ -328,3 +634,170 @@ func TestGitIntegration(t *testing.T) {
 		t.Error("Git push not called")
 	}
 }
+
+func TestGitOpsConcurrencyLimit(t *testing.T) {
+	cfg, tmpDir := setupTest(t)
+	cfg.AgentCommand = []string{"echo", "Done\n### TASK_DONE ###"}
+	cfg.NumWorkers = 4
+	cfg.GitIntegration.Enabled = true
+	cfg.MaxConcurrentGitOps = 1
+
+	// Branching at dispatch and committing at completion each count as one
+	// git operation; a slow push gives the others a chance to overlap
+	var mu sync.Mutex
+	var statusCalls, inFlight, maxInFlight, pushes int
+	begin := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		inFlight++
+		maxInFlight = max(maxInFlight, inFlight)
+	}
+	end := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		inFlight--
+	}
+	mockGit := &MockGitClient{
+		IsCleanFunc: func() (bool, error) {
+			begin()
+			return true, nil
+		},
+		CheckoutNewBranchFunc: func(branch, base string) error {
+			end()
+			return nil
+		},
+		// Every status check reports a new file, so each task has a change
+		ChangedPathsFunc: func() ([]string, error) {
+			begin()
+			mu.Lock()
+			defer mu.Unlock()
+			statusCalls++
+			return []string{fmt.Sprintf("file-%d.go", statusCalls)}, nil
+		},
+		PushFunc: func(remote, branch string) error {
+			time.Sleep(100 * time.Millisecond)
+			end()
+			mu.Lock()
+			defer mu.Unlock()
+			pushes++
+			return nil
+		},
+	}
+
+	tasksPath := filepath.Join(tmpDir, "tasks.json")
+	tm := task.NewManager(tasksPath)
+	for i := range 4 {
+		if err := tm.AddTask(task.NewTask(fmt.Sprintf("task-%d", i), "Task", "")); err != nil {
+			t.Fatalf("failed to add task: %v", err)
+		}
+	}
+
+	o, err := orchestrator.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)), mockGit, tm)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		o.Run(ctx)
+	}()
+
+	deadline := time.Now().Add(15 * time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		n := pushes
+		mu.Unlock()
+		if n == 4 {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	mu.Lock()
+	defer mu.Unlock()
+	if pushes != 4 {
+		t.Fatalf("expected 4 pushes, got %d", pushes)
+	}
+	if maxInFlight > 1 {
+		t.Errorf("expected one git operation at a time, got %d", maxInFlight)
+	}
+}
+
+func TestGitTasksHoldTheTreeInTurn(t *testing.T) {
+	cfg, tmpDir := setupTest(t)
+	cfg.AgentCommand = []string{"sh", "-c", "sleep 0.3; echo '### TASK_DONE ###'"}
+	cfg.NumWorkers = 2
+	cfg.GitIntegration.Enabled = true
+
+	// Each task's commit must see only its own file
+	var mu sync.Mutex
+	var events []string
+	var written string
+	mockGit := &MockGitClient{
+		CheckoutNewBranchFunc: func(branch, base string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, "checkout "+branch)
+			written = branch + ".go"
+			return nil
+		},
+		ChangedPathsFunc: func() ([]string, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			return []string{written}, nil
+		},
+		AddPathsFunc: func(paths []string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, "add "+strings.Join(paths, ","))
+			return nil
+		},
+	}
+
+	tasksPath := filepath.Join(tmpDir, "tasks.json")
+	tm := task.NewManager(tasksPath)
+	for _, id := range []string{"a", "b"} {
+		if err := tm.AddTask(task.NewTask(id, "Task "+id, "")); err != nil {
+			t.Fatalf("failed to add task: %v", err)
+		}
+	}
+
+	o, err := orchestrator.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)), mockGit, tm)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		o.Run(ctx)
+	}()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		n := len(events)
+		mu.Unlock()
+		if n == 4 {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{
+		"checkout agent/task-a", "add agent/task-a.go",
+		"checkout agent/task-b", "add agent/task-b.go",
+	}
+	if !slices.Equal(events, want) {
+		t.Errorf("expected each task to branch and commit before the next starts, got %v", events)
+	}
+}
@@ -0,0 +1,144 @@
+package orchestrator
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tuanbt/hive/internal/task"
+)
+
+// ErrPlanNotFound is returned when no held plan belongs to the given task.
+var ErrPlanNotFound = errors.New("plan not found")
+
+// PendingPlan is an agent plan held for approval before its subtasks are
+// added to the registry.
+type PendingPlan struct {
+	TaskID string       // the planning task
+	Title  string       // the planning task's title
+	Tasks  []*task.Task // the subtasks that accepting the plan adds
+}
+
+// SetPlanReview makes agent plans wait for AcceptPlan or DiscardPlan
+// instead of being added right away. Only enable it when someone is there
+// to review them, such as the TUI; headless runs keep adding plans
+// automatically, including those a reviewing run left held.
+func (o *Orchestrator) SetPlanReview(enabled bool) {
+	o.reviewPlans.Store(enabled)
+}
+
+// Plans returns the plans waiting for approval, in registry order. Plans
+// are kept in the tasks file, so they outlive the orchestrator that held
+// them.
+func (o *Orchestrator) Plans() []PendingPlan {
+	tasks, err := o.taskManager.LoadAll()
+	if err != nil {
+		o.logger.Error("failed to load held plans", "error", err)
+		return nil
+	}
+
+	var plans []PendingPlan
+	for i := range tasks {
+		t := &tasks[i]
+		if len(t.HeldPlan) == 0 {
+			continue
+		}
+		plan := PendingPlan{TaskID: t.ID, Title: t.Title, Tasks: make([]*task.Task, len(t.HeldPlan))}
+		for j := range t.HeldPlan {
+			plan.Tasks[j] = &t.HeldPlan[j]
+		}
+		plans = append(plans, plan)
+	}
+	return plans
+}
+
+// AcceptPlan adds the subtasks of the plan held for taskID to the registry
+// and returns how many were added. The subtasks are added in a single save
+// that also clears the plan, so on error the whole plan stays held.
+func (o *Orchestrator) AcceptPlan(taskID string) (int, error) {
+	added, err := o.taskManager.AcceptPlan(taskID)
+	if errors.Is(err, task.ErrTaskNotFound) || (err == nil && added == nil) {
+		return 0, fmt.Errorf("%w: %s", ErrPlanNotFound, taskID)
+	}
+	if err != nil {
+		return 0, err
+	}
+	o.logger.Info("agent plan accepted", "task_id", taskID, "count", len(added))
+	return len(added), nil
+}
+
+// DiscardPlan drops the plan held for taskID without adding its subtasks.
+func (o *Orchestrator) DiscardPlan(taskID string) error {
+	if _, err := o.takePlan(taskID); err != nil {
+		return err
+	}
+	o.logger.Info("agent plan discarded", "task_id", taskID)
+	return nil
+}
+
+// addHeldPlans adds the subtasks of every plan still held for review. A run
+// without plan review calls it on start, so plans held by a TUI that has
+// since quit are not stranded.
+func (o *Orchestrator) addHeldPlans() {
+	for _, plan := range o.Plans() {
+		if _, err := o.AcceptPlan(plan.TaskID); err != nil {
+			o.logger.Error("failed to add held agent plan", "task_id", plan.TaskID, "error", err)
+		}
+	}
+}
+
+// takePlan removes and returns the subtasks of the plan held for taskID.
+func (o *Orchestrator) takePlan(taskID string) ([]*task.Task, error) {
+	planned, err := o.taskManager.TakePlan(taskID)
+	if errors.Is(err, task.ErrTaskNotFound) || (err == nil && planned == nil) {
+		return nil, fmt.Errorf("%w: %s", ErrPlanNotFound, taskID)
+	}
+	return planned, err
+}
+
+// handlePlan adds the subtasks from parent's agent plan to the registry,
+// or holds them for approval when plan review is on.
+func (o *Orchestrator) handlePlan(parent *task.Task, planned []*task.Task) {
+	accepted := o.acceptablePlanTasks(parent, planned)
+	if len(accepted) == 0 {
+		return
+	}
+
+	if o.reviewPlans.Load() {
+		if err := o.taskManager.HoldPlan(parent.ID, accepted); err != nil {
+			o.logger.Error("failed to hold agent plan", "task_id", parent.ID, "error", err)
+			return
+		}
+		o.logger.Info("agent plan held for review", "task_id", parent.ID, "count", len(accepted))
+		return
+	}
+
+	o.logger.Info("adding new tasks from agent plan", "task_id", parent.ID, "count", len(accepted))
+	for _, nt := range accepted {
+		if err := o.taskManager.Create(nt); err != nil {
+			o.logger.Error("failed to add new task", "title", nt.Title, "error", err)
+		}
+	}
+}
+
+// acceptablePlanTasks returns the planned subtasks of parent that may be
+// added. Tasks without a title are skipped and at most MaxPlanTasks are
+// kept, so a runaway plan cannot flood the registry.
+func (o *Orchestrator) acceptablePlanTasks(parent *task.Task, planned []*task.Task) []*task.Task {
+	limit := o.Config().MaxPlanTasks
+	var accepted []*task.Task
+	for i, nt := range planned {
+		if limit > 0 && len(accepted) == limit {
+			o.logger.Warn("agent plan exceeds max_plan_tasks, dropping the rest",
+				"task_id", parent.ID, "max_plan_tasks", limit, "dropped", len(planned)-i)
+			break
+		}
+		if strings.TrimSpace(nt.Title) == "" {
+			o.logger.Warn("skipping planned task without a title", "task_id", parent.ID, "index", i)
+			continue
+		}
+		nt.ParentID = parent.ID
+		accepted = append(accepted, nt)
+	}
+	return accepted
+}
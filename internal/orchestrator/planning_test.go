@@ -3,6 +3,7 @@ package orchestrator_test
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"os"
 	"path/filepath"
@@ -72,7 +73,8 @@ func TestAutoPlanning(t *testing.T) {
 				sub2 := currentTasks[2]
 
 				if sub1.Title == "Subtask 1" && sub1.Role == "backend" &&
-					sub2.Title == "Subtask 2" && sub2.Role == "frontend" {
+					sub2.Title == "Subtask 2" && sub2.Role == "frontend" &&
+					sub1.ParentID == "planning-task" && sub2.ParentID == "planning-task" {
 					success = true
 					break
 				}
@@ -88,3 +90,187 @@ func TestAutoPlanning(t *testing.T) {
 		t.Fatalf("Auto-planning failed. Expected 3 tasks, found %d. Task 0 Status: %s", len(currentTasks), currentTasks[0].Status)
 	}
 }
+
+func TestAutoPlanningCapsPlanSize(t *testing.T) {
+	cfg, tmpDir := setupTest(t)
+	cfg.MaxPlanTasks = 2
+
+	cfg.AgentCommand = planAgent(`[{"title": "Subtask 1"}, {"title": " "}, {"title": "Subtask 3"}, {"title": "Subtask 4"}, {"title": "Subtask 5"}]`)
+
+	tasksPath := filepath.Join(tmpDir, "tasks.json")
+	data, _ := json.Marshal([]task.Task{{
+		ID:        "planning-task",
+		Title:     "Plan the release",
+		Role:      "ba",
+		Status:    task.StatusPending,
+		CreatedAt: time.Now(),
+	}})
+	os.WriteFile(tasksPath, data, 0644)
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	o, err := orchestrator.New(cfg, logger, &MockGitClient{}, task.NewManager(tasksPath))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		o.Run(ctx)
+	}()
+
+	var children []task.Task
+	for i := 0; i < 50; i++ {
+		time.Sleep(100 * time.Millisecond)
+		parent, err := task.NewManager(tasksPath).GetByID("planning-task")
+		if err != nil || parent.Status != task.StatusCompleted {
+			continue
+		}
+		// Subtasks are created one at a time
+		children, _ = task.NewManager(tasksPath).Children("planning-task")
+		if len(children) >= 2 {
+			break
+		}
+	}
+
+	// Give subtasks past the cap the chance to show up
+	time.Sleep(300 * time.Millisecond)
+	children, _ = task.NewManager(tasksPath).Children("planning-task")
+
+	cancel()
+	wg.Wait()
+
+	if len(children) != 2 || children[0].Title != "Subtask 1" || children[1].Title != "Subtask 3" {
+		t.Fatalf("expected the plan capped to the first 2 titled subtasks, got %+v", children)
+	}
+}
+
+// planAgent returns an agent command that answers the "Plan the release"
+// task with jsonPlan. Other prompts get no plan, so subtasks don't plan
+// further.
+func planAgent(jsonPlan string) []string {
+	script := `case "$0" in *"Plan the release"*) printf '### PLAN_START ###\n%s\n### PLAN_END ###\n' '` + jsonPlan + `';; esac; echo '### TASK_DONE ###'`
+	return []string{"sh", "-c", script}
+}
+
+func TestPlanReview(t *testing.T) {
+	cfg, tmpDir := setupTest(t)
+	cfg.AgentCommand = planAgent(`[{"title": "Subtask 1", "role": "backend"}, {"title": "Subtask 2"}]`)
+
+	tasksPath := filepath.Join(tmpDir, "tasks.json")
+	data, _ := json.Marshal([]task.Task{{
+		ID:        "planning-task",
+		Title:     "Plan the release",
+		Status:    task.StatusPending,
+		CreatedAt: time.Now(),
+	}})
+	os.WriteFile(tasksPath, data, 0644)
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	o, err := orchestrator.New(cfg, logger, &MockGitClient{}, task.NewManager(tasksPath))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	o.SetPlanReview(true)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		o.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	var plans []orchestrator.PendingPlan
+	for i := 0; i < 50 && len(plans) == 0; i++ {
+		time.Sleep(100 * time.Millisecond)
+		plans = o.Plans()
+	}
+	if len(plans) != 1 || plans[0].TaskID != "planning-task" || len(plans[0].Tasks) != 2 {
+		t.Fatalf("expected the plan to be held for review, got %+v", plans)
+	}
+	if plans[0].Tasks[0].Title != "Subtask 1" || plans[0].Tasks[0].Role != "backend" {
+		t.Errorf("unexpected first planned task: %+v", plans[0].Tasks[0])
+	}
+
+	// Held plans live in the tasks file, so they outlive the orchestrator
+	restarted, err := orchestrator.New(cfg, logger, &MockGitClient{}, task.NewManager(tasksPath))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if got := restarted.Plans(); len(got) != 1 || len(got[0].Tasks) != 2 {
+		t.Fatalf("expected the held plan to survive a restart, got %+v", got)
+	}
+
+	children, _ := task.NewManager(tasksPath).Children("planning-task")
+	if len(children) != 0 {
+		t.Fatalf("expected no subtasks before the plan is accepted, got %d", len(children))
+	}
+
+	added, err := o.AcceptPlan("planning-task")
+	if err != nil || added != 2 {
+		t.Fatalf("AcceptPlan() = %d, %v; want 2 tasks added", added, err)
+	}
+	children, _ = task.NewManager(tasksPath).Children("planning-task")
+	if len(children) != 2 {
+		t.Errorf("expected 2 subtasks after accepting, got %d", len(children))
+	}
+	if len(o.Plans()) != 0 {
+		t.Error("expected the accepted plan to leave the review queue")
+	}
+
+	if err := o.DiscardPlan("planning-task"); !errors.Is(err, orchestrator.ErrPlanNotFound) {
+		t.Errorf("expected ErrPlanNotFound for a handled plan, got %v", err)
+	}
+}
+
+func TestHeadlessRunAddsHeldPlans(t *testing.T) {
+	cfg, tmpDir := setupTest(t)
+	cfg.AgentCommand = []string{"echo", "### TASK_DONE ###"}
+
+	// Held for review by a TUI that has since quit
+	tasksPath := filepath.Join(tmpDir, "tasks.json")
+	tm := task.NewManager(tasksPath)
+	parent := task.NewTask("planning-task", "Plan the release", "")
+	parent.Status = task.StatusCompleted
+	if err := tm.AddTask(parent); err != nil {
+		t.Fatalf("failed to add task: %v", err)
+	}
+	if err := tm.HoldPlan("planning-task", []*task.Task{task.NewTask("", "Subtask 1", ""), task.NewTask("", "Subtask 2", "")}); err != nil {
+		t.Fatalf("HoldPlan() failed: %v", err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	o, err := orchestrator.New(cfg, logger, &MockGitClient{}, tm)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		o.Run(ctx)
+	}()
+
+	var children []task.Task
+	for i := 0; i < 50 && len(children) < 2; i++ {
+		time.Sleep(100 * time.Millisecond)
+		children, _ = tm.Children("planning-task")
+	}
+	cancel()
+	<-done
+
+	if len(children) != 2 {
+		t.Fatalf("expected the held plan's 2 subtasks to be added, got %d", len(children))
+	}
+	if plans := o.Plans(); len(plans) != 0 {
+		t.Errorf("expected no plans left held, got %+v", plans)
+	}
+}
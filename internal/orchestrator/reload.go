@@ -0,0 +1,113 @@
+package orchestrator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/tuanbt/hive/internal/config"
+)
+
+// reloadableFields are the config keys Reload applies to a running
+// orchestrator. Changes to any other key need a restart.
+var reloadableFields = []string{
+	"num_workers",
+	"dispatch_interval_seconds",
+	"dispatch_max_interval_seconds",
+	"response_timeout_seconds",
+	"max_task_duration_seconds",
+	"shutdown_timeout_seconds",
+}
+
+// Reload re-reads the config file at path and applies the hot-reloadable
+// settings: worker count, dispatch intervals and timeouts. Timeouts take
+// effect from the next task a worker picks up. Changed settings that cannot
+// be applied without a restart are logged and otherwise ignored.
+func (o *Orchestrator) Reload(path string) error {
+	next, err := config.Load(path)
+	if err != nil {
+		return fmt.Errorf("failed to reload config: %w", err)
+	}
+	for _, warning := range next.Warnings {
+		o.logger.Warn("config warning", "path", path, "warning", warning)
+	}
+
+	o.reloadMu.Lock()
+	defer o.reloadMu.Unlock()
+
+	current := o.Config()
+	changed, err := changedFields(current, next)
+	if err != nil {
+		return fmt.Errorf("failed to compare configs: %w", err)
+	}
+
+	var applied, ignored []string
+	for _, field := range changed {
+		if slices.Contains(reloadableFields, field) {
+			applied = append(applied, field)
+		} else {
+			ignored = append(ignored, field)
+		}
+	}
+
+	// Publish a copy rather than changing the live config, which workers,
+	// drivers and the dispatcher read without locking
+	updated := *current
+	updated.ResponseTimeoutSeconds = next.ResponseTimeoutSeconds
+	updated.MaxTaskDurationSeconds = next.MaxTaskDurationSeconds
+	updated.ShutdownTimeoutSeconds = next.ShutdownTimeoutSeconds
+	updated.DispatchIntervalSeconds = next.DispatchIntervalSeconds
+	updated.DispatchMaxIntervalSeconds = next.DispatchMaxIntervalSeconds
+	updated.NumWorkers = next.NumWorkers
+	o.config.Store(&updated)
+	o.workerPool.SetConfig(&updated)
+	o.dispatchInterval.Store(int64(time.Duration(next.DispatchIntervalSeconds) * time.Second))
+	o.dispatchMaxInterval.Store(int64(time.Duration(next.DispatchMaxIntervalSeconds) * time.Second))
+
+	if next.NumWorkers != current.NumWorkers {
+		o.workerPool.Scale(next.NumWorkers)
+	}
+
+	o.logger.Info("config reloaded", "path", path, "applied", applied)
+	if len(ignored) > 0 {
+		o.logger.Warn("config changes need a restart to take effect", "ignored", ignored)
+	}
+	return nil
+}
+
+// changedFields returns the JSON keys whose values differ between two
+// configs, in sorted order.
+func changedFields(old, next *config.Config) ([]string, error) {
+	a, err := configFields(old)
+	if err != nil {
+		return nil, err
+	}
+	b, err := configFields(next)
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	for key, value := range b {
+		if !bytes.Equal(a[key], value) {
+			changed = append(changed, key)
+		}
+	}
+	slices.Sort(changed)
+	return changed, nil
+}
+
+// configFields flattens a config into its top-level JSON values.
+func configFields(cfg *config.Config) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
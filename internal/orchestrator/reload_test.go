@@ -0,0 +1,173 @@
+package orchestrator_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tuanbt/hive/internal/orchestrator"
+	"github.com/tuanbt/hive/internal/task"
+)
+
+func TestReloadAppliesHotSettings(t *testing.T) {
+	cfg, tmpDir := setupTest(t)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	o, err := orchestrator.New(cfg, logger, &MockGitClient{}, task.NewManager(cfg.TasksFile))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- o.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	waitForWorkers := func(n int) bool {
+		for i := 0; i < 50; i++ {
+			if o.ActiveWorkers() == n {
+				return true
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		return false
+	}
+	if !waitForWorkers(1) {
+		t.Fatalf("expected 1 worker before reload, got %d", o.ActiveWorkers())
+	}
+
+	// Write a config with hot settings changed plus one that needs a restart
+	next := *cfg
+	next.NumWorkers = 3
+	next.MaxTaskDurationSeconds = 99
+	next.DispatchIntervalSeconds = 1
+	next.TasksFile = filepath.Join(tmpDir, "other.json")
+	data, _ := json.Marshal(next)
+	configPath := filepath.Join(tmpDir, "config.json")
+	if err := os.WriteFile(configPath, data, 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	if err := o.Reload(configPath); err != nil {
+		t.Fatalf("Reload() failed: %v", err)
+	}
+
+	if !waitForWorkers(3) {
+		t.Errorf("expected pool to scale to 3 workers, got %d", o.ActiveWorkers())
+	}
+	got := o.Config()
+	if got.MaxTaskDurationSeconds != 99 {
+		t.Errorf("expected max task duration to reload, got %d", got.MaxTaskDurationSeconds)
+	}
+	if got.DispatchIntervalSeconds != 1 {
+		t.Errorf("expected dispatch interval to reload, got %d", got.DispatchIntervalSeconds)
+	}
+	if got.TasksFile == next.TasksFile {
+		t.Error("tasks_file must not change without a restart")
+	}
+	if cfg.MaxTaskDurationSeconds == 99 {
+		t.Error("reload must publish a new config, not change the one in use")
+	}
+
+	// Scaling down retires idle workers
+	next.NumWorkers = 1
+	data, _ = json.Marshal(next)
+	os.WriteFile(configPath, data, 0644)
+	if err := o.Reload(configPath); err != nil {
+		t.Fatalf("Reload() failed: %v", err)
+	}
+	if !waitForWorkers(1) {
+		t.Errorf("expected pool to scale down to 1 worker, got %d", o.ActiveWorkers())
+	}
+}
+
+func TestReloadRejectsInvalidConfig(t *testing.T) {
+	cfg, tmpDir := setupTest(t)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	o, err := orchestrator.New(cfg, logger, &MockGitClient{}, task.NewManager(cfg.TasksFile))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	configPath := filepath.Join(tmpDir, "config.json")
+	os.WriteFile(configPath, []byte("{not json"), 0644)
+
+	if err := o.Reload(configPath); err == nil {
+		t.Fatal("expected Reload() to fail on a malformed config")
+	}
+	if o.Config() != cfg || cfg.NumWorkers != 1 {
+		t.Errorf("failed reload must leave the config untouched, num_workers=%d", o.Config().NumWorkers)
+	}
+}
+
+// Run with -race: reloads must not race with the workers, drivers and
+// dispatcher reading the config while tasks run.
+func TestReloadWhileTasksRun(t *testing.T) {
+	cfg, tmpDir := setupTest(t)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	cfg.AgentCommand = []string{"bash", "-c", "sleep 0.05; echo '### TASK_DONE ###'"}
+	cfg.NumWorkers = 2
+
+	var tasks []task.Task
+	for i := 1; i <= 6; i++ {
+		tasks = append(tasks, task.Task{
+			ID:        fmt.Sprintf("task-%d", i),
+			Title:     fmt.Sprintf("Task %d", i),
+			Status:    task.StatusPending,
+			CreatedAt: time.Now(),
+		})
+	}
+	data, _ := json.Marshal(tasks)
+	os.WriteFile(cfg.TasksFile, data, 0644)
+
+	mgr := task.NewManager(cfg.TasksFile)
+	o, err := orchestrator.New(cfg, logger, &MockGitClient{}, mgr)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- o.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	configPath := filepath.Join(tmpDir, "config.json")
+	finished := false
+	for i := 0; i < 150 && !finished; i++ {
+		next := *cfg
+		next.NumWorkers = 1 + i%3
+		next.MaxTaskDurationSeconds = 60 + i
+		next.DispatchIntervalSeconds = 1 + i%2
+		data, _ := json.Marshal(next)
+		os.WriteFile(configPath, data, 0644)
+		if err := o.Reload(configPath); err != nil {
+			t.Fatalf("Reload() failed: %v", err)
+		}
+
+		time.Sleep(20 * time.Millisecond)
+		counts, _ := mgr.CountByStatus()
+		finished = counts[task.StatusCompleted] == len(tasks)
+	}
+
+	if !finished {
+		t.Fatal("not all tasks completed while reloading")
+	}
+}
@@ -0,0 +1,134 @@
+package orchestrator_test
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tuanbt/hive/internal/orchestrator"
+	"github.com/tuanbt/hive/internal/task"
+)
+
+func TestShutdownKillsAgentAfterTimeout(t *testing.T) {
+	cfg, tmpDir := setupTest(t)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	cfg.AgentMode = "episodic"
+	cfg.AgentCommand = []string{"bash", "-c", "exec sleep 30"}
+	cfg.ShutdownTimeoutSeconds = 1
+
+	tasksPath := filepath.Join(tmpDir, "tasks.json")
+	testTask := task.Task{
+		ID:        "long-task",
+		Title:     "Long Task",
+		Status:    task.StatusPending,
+		CreatedAt: time.Now(),
+	}
+	data, _ := json.Marshal([]task.Task{testTask})
+	os.WriteFile(tasksPath, data, 0644)
+
+	mgr := task.NewManager(tasksPath)
+	o, err := orchestrator.New(cfg, logger, &MockGitClient{}, mgr)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		o.Run(ctx)
+	}()
+
+	// Wait for the task to be picked up
+	running := false
+	for i := 0; i < 50; i++ {
+		time.Sleep(100 * time.Millisecond)
+		current, _ := mgr.GetByID("long-task")
+		if current != nil && current.Status == task.StatusInProgress {
+			running = true
+			break
+		}
+	}
+	if !running {
+		cancel()
+		wg.Wait()
+		t.Fatal("task was never dispatched")
+	}
+
+	// Let the worker start the agent process
+	time.Sleep(1 * time.Second)
+
+	start := time.Now()
+	cancel()
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	if elapsed < 1*time.Second {
+		t.Errorf("shutdown returned before the drain timeout: %v", elapsed)
+	}
+	if elapsed > 5*time.Second {
+		t.Errorf("agent process was not killed at the timeout, shutdown took %v", elapsed)
+	}
+
+	final, _ := mgr.GetByID("long-task")
+	if final.Status == task.StatusInProgress {
+		t.Error("expected the killed task to leave in_progress")
+	}
+}
+
+func TestShutdownDrainsInFlightTask(t *testing.T) {
+	cfg, tmpDir := setupTest(t)
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+	cfg.AgentMode = "episodic"
+	cfg.AgentCommand = []string{"bash", "-c", "sleep 2; echo done; echo " + cfg.CompletionMarker}
+	cfg.ShutdownTimeoutSeconds = 10
+
+	tasksPath := filepath.Join(tmpDir, "tasks.json")
+	testTask := task.Task{
+		ID:        "drain-task",
+		Title:     "Drain Task",
+		Status:    task.StatusPending,
+		CreatedAt: time.Now(),
+	}
+	data, _ := json.Marshal([]task.Task{testTask})
+	os.WriteFile(tasksPath, data, 0644)
+
+	mgr := task.NewManager(tasksPath)
+	o, err := orchestrator.New(cfg, logger, &MockGitClient{}, mgr)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		o.Run(ctx)
+	}()
+
+	for i := 0; i < 50; i++ {
+		time.Sleep(100 * time.Millisecond)
+		current, _ := mgr.GetByID("drain-task")
+		if current != nil && current.Status == task.StatusInProgress {
+			break
+		}
+	}
+
+	// Shut down while the agent is still working
+	cancel()
+	wg.Wait()
+
+	final, _ := mgr.GetByID("drain-task")
+	if final.Status != task.StatusCompleted {
+		t.Errorf("expected in-flight task to drain to completed, got %s (%s)", final.Status, final.FailReason)
+	}
+}
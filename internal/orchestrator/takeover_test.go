@@ -0,0 +1,27 @@
+package orchestrator
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestTakeOverLockKeepsNewOwner(t *testing.T) {
+	path := filepath.Join(t.TempDir(), LockFileName)
+
+	// Another starter already replaced the stale lock we read
+	os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())), 0644)
+
+	err := takeOverLock(path, 1<<30, false)
+	if !errors.Is(err, ErrAlreadyRunning) {
+		t.Fatalf("expected ErrAlreadyRunning, got %v", err)
+	}
+	if pid := readLockPID(path); pid != os.Getpid() {
+		t.Errorf("expected the new owner's lock to be put back, got pid %d", pid)
+	}
+	if leftovers, _ := filepath.Glob(path + ".*"); len(leftovers) > 0 {
+		t.Errorf("expected no moved lock files, found %v", leftovers)
+	}
+}
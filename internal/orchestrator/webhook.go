@@ -0,0 +1,80 @@
+package orchestrator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/tuanbt/hive/internal/task"
+	"github.com/tuanbt/hive/internal/worker"
+)
+
+// WebhookPayload is the JSON body posted to webhook URLs when a task finishes.
+type WebhookPayload struct {
+	TaskID          string      `json:"task_id"`
+	Title           string      `json:"title"`
+	Status          task.Status `json:"status"`
+	DurationSeconds float64     `json:"duration_seconds"`
+	FailReason      string      `json:"fail_reason,omitempty"`
+}
+
+// notifyWebhook posts the result to the webhook matching its terminal status.
+// Delivery runs in the background, bounded by the webhook timeout, so a slow
+// endpoint never holds up the results behind it; Shutdown waits for it.
+// Delivery errors are logged only; they never affect the task status.
+func (o *Orchestrator) notifyWebhook(result *worker.TaskResult, reason string) {
+	hooks := o.Config().Webhooks
+	var url string
+	switch result.Status {
+	case task.StatusCompleted:
+		url = hooks.OnComplete
+	case task.StatusFailed:
+		url = hooks.OnFail
+	}
+	if url == "" {
+		return
+	}
+
+	payload := WebhookPayload{
+		TaskID:          result.Task.ID,
+		Title:           result.Task.Title,
+		Status:          result.Status,
+		DurationSeconds: result.Duration.Seconds(),
+		FailReason:      reason,
+	}
+
+	o.wg.Add(1)
+	go func() {
+		defer o.wg.Done()
+		if err := o.postWebhook(url, payload); err != nil {
+			o.logger.Error("webhook delivery failed", "task_id", payload.TaskID, "url", url, "error", err)
+			return
+		}
+		o.logger.Debug("webhook delivered", "task_id", payload.TaskID, "url", url)
+	}()
+}
+
+// postWebhook sends the payload as JSON and checks for a 2xx response.
+func (o *Orchestrator) postWebhook(url string, payload WebhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	client := &http.Client{
+		Timeout: time.Duration(o.Config().Webhooks.TimeoutSeconds) * time.Second,
+	}
+
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package orchestrator_test
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tuanbt/hive/internal/orchestrator"
+	"github.com/tuanbt/hive/internal/task"
+)
+
+func TestWebhookOnComplete(t *testing.T) {
+	cfg, tmpDir := setupTest(t)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	cfg.AgentMode = "episodic"
+	cfg.AgentCommand = []string{"echo", "Done\n### TASK_DONE ###"}
+
+	received := make(chan orchestrator.WebhookPayload, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload orchestrator.WebhookPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("failed to decode webhook payload: %v", err)
+		}
+		received <- payload
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg.Webhooks.OnComplete = server.URL
+	cfg.Webhooks.TimeoutSeconds = 1
+
+	tasksPath := filepath.Join(tmpDir, "tasks.json")
+	testTask := task.Task{
+		ID:        "webhook-task",
+		Title:     "Webhook Task",
+		Status:    task.StatusPending,
+		CreatedAt: time.Now(),
+	}
+	data, _ := json.Marshal([]task.Task{testTask})
+	os.WriteFile(tasksPath, data, 0644)
+
+	o, err := orchestrator.New(cfg, logger, &MockGitClient{}, task.NewManager(tasksPath))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		o.Run(ctx)
+	}()
+
+	select {
+	case payload := <-received:
+		if payload.TaskID != "webhook-task" {
+			t.Errorf("expected task_id 'webhook-task', got '%s'", payload.TaskID)
+		}
+		if payload.Status != task.StatusCompleted {
+			t.Errorf("expected status 'completed', got '%s'", payload.Status)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("webhook was not delivered")
+	}
+
+	cancel()
+	wg.Wait()
+}
+
+func TestWebhookFailureDoesNotAffectStatus(t *testing.T) {
+	cfg, tmpDir := setupTest(t)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	cfg.AgentMode = "episodic"
+	cfg.AgentCommand = []string{"echo", "Done\n### TASK_DONE ###"}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	cfg.Webhooks.OnComplete = server.URL
+	cfg.Webhooks.TimeoutSeconds = 1
+
+	tasksPath := filepath.Join(tmpDir, "tasks.json")
+	testTask := task.Task{
+		ID:        "webhook-fail-task",
+		Title:     "Webhook Fail Task",
+		Status:    task.StatusPending,
+		CreatedAt: time.Now(),
+	}
+	data, _ := json.Marshal([]task.Task{testTask})
+	os.WriteFile(tasksPath, data, 0644)
+
+	o, err := orchestrator.New(cfg, logger, &MockGitClient{}, task.NewManager(tasksPath))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		o.Run(ctx)
+	}()
+
+	success := false
+	for i := 0; i < 50; i++ {
+		time.Sleep(100 * time.Millisecond)
+		currentTasks, _ := task.NewManager(tasksPath).LoadAll()
+		if len(currentTasks) > 0 && currentTasks[0].Status == task.StatusCompleted {
+			success = true
+			break
+		}
+	}
+
+	cancel()
+	wg.Wait()
+
+	if !success {
+		t.Fatal("Task not completed despite webhook failure")
+	}
+}
+
+func TestSlowWebhookDoesNotHoldUpResults(t *testing.T) {
+	cfg, tmpDir := setupTest(t)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	cfg.AgentMode = "episodic"
+	cfg.AgentCommand = []string{"echo", "Done\n### TASK_DONE ###"}
+
+	// The endpoint answers nothing until the test lets it
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	released := false
+	defer func() {
+		if !released {
+			close(release)
+		}
+	}()
+
+	cfg.Webhooks.OnComplete = server.URL
+	cfg.Webhooks.TimeoutSeconds = 30
+
+	tasksPath := filepath.Join(tmpDir, "tasks.json")
+	data, _ := json.Marshal([]task.Task{
+		{ID: "slow-hook-1", Title: "First", Status: task.StatusPending, CreatedAt: time.Now()},
+		{ID: "slow-hook-2", Title: "Second", Status: task.StatusPending, CreatedAt: time.Now()},
+	})
+	os.WriteFile(tasksPath, data, 0644)
+
+	o, err := orchestrator.New(cfg, logger, &MockGitClient{}, task.NewManager(tasksPath))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		o.Run(ctx)
+	}()
+
+	// Both results are recorded while the first webhook is still pending
+	completed := 0
+	for i := 0; i < 50 && completed < 2; i++ {
+		time.Sleep(100 * time.Millisecond)
+		counts, _ := task.NewManager(tasksPath).CountByStatus()
+		completed = counts[task.StatusCompleted]
+	}
+
+	close(release)
+	released = true
+	cancel()
+	wg.Wait()
+
+	if completed != 2 {
+		t.Errorf("expected both tasks to complete while the webhook hung, got %d", completed)
+	}
+}
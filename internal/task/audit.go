@@ -0,0 +1,124 @@
+package task
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// AuditEntry is one line of the append-only audit trail.
+type AuditEntry struct {
+	TaskID    string    `json:"task_id"`
+	OldStatus Status    `json:"old_status,omitempty"`
+	NewStatus Status    `json:"new_status,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+// WithAudit enables an append-only NDJSON audit trail at path recording
+// every task creation, deletion and status change. The file is separate
+// from the registry so it survives cleanup. Returns m for chaining.
+func (m *Manager) WithAudit(path string) *Manager {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.auditPath = path
+	return m
+}
+
+// WithLogger sets where the manager reports problems that do not fail the
+// call that hit them, such as a failed audit append. Without one they are
+// dropped. Returns m for chaining.
+func (m *Manager) WithLogger(logger *slog.Logger) *Manager {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.logger = logger
+	return m
+}
+
+// auditChanges appends an entry for every task whose status differs between
+// before and after. Audit failures are logged but never fail the registry
+// write.
+func (m *Manager) auditChanges(before, after []Task) {
+	now := time.Now()
+	old := make(map[string]Status, len(before))
+	for _, t := range before {
+		old[t.ID] = t.Status
+	}
+
+	var entries []AuditEntry
+	for _, t := range after {
+		prev, existed := old[t.ID]
+		delete(old, t.ID)
+		switch {
+		case !existed:
+			entries = append(entries, AuditEntry{TaskID: t.ID, NewStatus: t.Status, Reason: "created", Time: now})
+		case prev != t.Status:
+			// A reason left over from an earlier failure does not explain
+			// a move to any other status
+			var reason string
+			if t.Status == StatusFailed || t.Status == StatusBlocked {
+				reason = t.FailReason
+			}
+			entries = append(entries, AuditEntry{TaskID: t.ID, OldStatus: prev, NewStatus: t.Status, Reason: reason, Time: now})
+		}
+	}
+	for _, t := range before {
+		if status, ok := old[t.ID]; ok {
+			entries = append(entries, AuditEntry{TaskID: t.ID, OldStatus: status, Reason: "deleted", Time: now})
+		}
+	}
+	if len(entries) == 0 {
+		return
+	}
+
+	if err := appendAudit(m.auditPath, entries); err != nil && m.logger != nil {
+		m.logger.Warn("failed to append to audit log", "path", m.auditPath, "entries", len(entries), "error", err)
+	}
+}
+
+// appendAudit writes entries to the end of the audit log at path.
+func appendAudit(path string, entries []AuditEntry) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(f)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// ReadAudit replays the audit trail at path for a single task, oldest first.
+func ReadAudit(path, taskID string) ([]AuditEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("failed to parse audit log: %w", err)
+		}
+		if e.TaskID == taskID {
+			entries = append(entries, e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return entries, nil
+}
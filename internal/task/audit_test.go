@@ -0,0 +1,112 @@
+package task
+
+import (
+	"bytes"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestManagerAudit(t *testing.T) {
+	tmpDir := t.TempDir()
+	auditPath := filepath.Join(tmpDir, "audit.ndjson")
+	mgr := NewManager(filepath.Join(tmpDir, "tasks.json")).WithAudit(auditPath)
+
+	if err := mgr.AddTask(NewTask("task-1", "Audited", "")); err != nil {
+		t.Fatalf("failed to add: %v", err)
+	}
+	if err := mgr.AddTask(NewTask("task-2", "Other", "")); err != nil {
+		t.Fatalf("failed to add: %v", err)
+	}
+	if err := mgr.ClaimTask("task-1", 0); err != nil {
+		t.Fatalf("failed to claim: %v", err)
+	}
+	// Heartbeats don't change status and must not be audited
+	if err := mgr.Heartbeat("task-1"); err != nil {
+		t.Fatalf("failed to heartbeat: %v", err)
+	}
+	if err := mgr.UpdateStatus("task-1", StatusFailed, "agent crashed"); err != nil {
+		t.Fatalf("failed to update: %v", err)
+	}
+	if err := mgr.DeleteTask("task-1"); err != nil {
+		t.Fatalf("failed to delete: %v", err)
+	}
+
+	entries, err := ReadAudit(auditPath, "task-1")
+	if err != nil {
+		t.Fatalf("ReadAudit() failed: %v", err)
+	}
+
+	want := []AuditEntry{
+		{NewStatus: StatusPending, Reason: "created"},
+		{OldStatus: StatusPending, NewStatus: StatusQueued},
+		{OldStatus: StatusQueued, NewStatus: StatusFailed, Reason: "agent crashed"},
+		{OldStatus: StatusFailed, Reason: "deleted"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("expected %d entries, got %d: %+v", len(want), len(entries), entries)
+	}
+	for i, w := range want {
+		got := entries[i]
+		if got.TaskID != "task-1" || got.OldStatus != w.OldStatus || got.NewStatus != w.NewStatus || got.Reason != w.Reason {
+			t.Errorf("entry %d: expected %+v, got %+v", i, w, got)
+		}
+		if got.Time.IsZero() {
+			t.Errorf("entry %d has no timestamp", i)
+		}
+	}
+}
+
+func TestReadAuditMissingFile(t *testing.T) {
+	if _, err := ReadAudit(filepath.Join(t.TempDir(), "missing.ndjson"), "task-1"); err == nil {
+		t.Error("expected error for missing audit log")
+	}
+}
+
+func TestManagerAuditReasonOnlyForFailures(t *testing.T) {
+	tmpDir := t.TempDir()
+	auditPath := filepath.Join(tmpDir, "audit.ndjson")
+	mgr := NewManager(filepath.Join(tmpDir, "tasks.json")).WithAudit(auditPath)
+
+	if err := mgr.AddTask(NewTask("task-1", "Audited", "")); err != nil {
+		t.Fatalf("failed to add: %v", err)
+	}
+	if err := mgr.UpdateStatus("task-1", StatusFailed, "tests failed"); err != nil {
+		t.Fatalf("failed to update: %v", err)
+	}
+	// A manual move back to pending keeps the fail reason on the task
+	if err := mgr.UpdateStatus("task-1", StatusPending, ""); err != nil {
+		t.Fatalf("failed to update: %v", err)
+	}
+
+	entries, err := ReadAudit(auditPath, "task-1")
+	if err != nil {
+		t.Fatalf("ReadAudit() failed: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %+v", entries)
+	}
+	if entries[1].Reason != "tests failed" {
+		t.Errorf("expected the failure to record its reason, got %q", entries[1].Reason)
+	}
+	if entries[2].NewStatus != StatusPending || entries[2].Reason != "" {
+		t.Errorf("expected no reason on the move to pending, got %+v", entries[2])
+	}
+}
+
+func TestManagerAuditLogsAppendErrors(t *testing.T) {
+	tmpDir := t.TempDir()
+	var logs bytes.Buffer
+	// A directory cannot be opened for appending
+	mgr := NewManager(filepath.Join(tmpDir, "tasks.json")).
+		WithAudit(tmpDir).
+		WithLogger(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	if err := mgr.AddTask(NewTask("task-1", "Audited", "")); err != nil {
+		t.Fatalf("expected the write to succeed despite the audit failure, got %v", err)
+	}
+	if !strings.Contains(logs.String(), "failed to append to audit log") {
+		t.Errorf("expected the audit failure to be logged, got %q", logs.String())
+	}
+}
@@ -0,0 +1,70 @@
+package task
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// MaxDescriptionFileSize caps how much of a referenced file may be pulled
+// into a task description.
+const MaxDescriptionFileSize = 256 * 1024
+
+// fileRefPattern matches @path mentions in task text.
+var fileRefPattern = regexp.MustCompile(`@([^\s@]+)`)
+
+// ReadDescriptionFile reads a task description from path, refusing files
+// larger than MaxDescriptionFileSize.
+func ReadDescriptionFile(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read description file: %w", err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("description file %s is a directory", path)
+	}
+	if info.Size() > MaxDescriptionFileSize {
+		return "", fmt.Errorf("description file %s is %d bytes, exceeds the %d byte limit",
+			path, info.Size(), MaxDescriptionFileSize)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read description file: %w", err)
+	}
+	return string(data), nil
+}
+
+// ExpandFileReferences appends the contents of every @path mention in text
+// that names a file under workDir. Mentions that don't resolve to a file are
+// left as plain text.
+func ExpandFileReferences(text, workDir string) (string, error) {
+	var b strings.Builder
+	b.WriteString(text)
+
+	seen := make(map[string]bool)
+	for _, match := range fileRefPattern.FindAllStringSubmatch(text, -1) {
+		ref := match[1]
+		if seen[ref] {
+			continue
+		}
+		seen[ref] = true
+
+		path := ref
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(workDir, path)
+		}
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			continue
+		}
+
+		content, err := ReadDescriptionFile(path)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "\n\n=== @%s ===\n%s", ref, content)
+	}
+	return b.String(), nil
+}
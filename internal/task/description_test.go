@@ -0,0 +1,53 @@
+package task
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadDescriptionFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "spec.md")
+	os.WriteFile(path, []byte("Detailed spec"), 0644)
+
+	desc, err := ReadDescriptionFile(path)
+	if err != nil {
+		t.Fatalf("ReadDescriptionFile() failed: %v", err)
+	}
+	if desc != "Detailed spec" {
+		t.Errorf("unexpected description: %q", desc)
+	}
+
+	big := filepath.Join(tmpDir, "big.md")
+	os.WriteFile(big, make([]byte, MaxDescriptionFileSize+1), 0644)
+	if _, err := ReadDescriptionFile(big); err == nil || !strings.Contains(err.Error(), "exceeds") {
+		t.Errorf("expected size limit error, got %v", err)
+	}
+
+	if _, err := ReadDescriptionFile(filepath.Join(tmpDir, "missing.md")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func TestExpandFileReferences(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, "docs"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "docs", "api.md"), []byte("GET /users"), 0644)
+
+	got, err := ExpandFileReferences("Implement @docs/api.md and mail me@example", tmpDir)
+	if err != nil {
+		t.Fatalf("ExpandFileReferences() failed: %v", err)
+	}
+
+	want := "Implement @docs/api.md and mail me@example\n\n=== @docs/api.md ===\nGET /users"
+	if got != want {
+		t.Errorf("unexpected expansion:\n%q\nwant:\n%q", got, want)
+	}
+
+	plain, err := ExpandFileReferences("No references here", tmpDir)
+	if err != nil || plain != "No references here" {
+		t.Errorf("expected text unchanged, got %q (%v)", plain, err)
+	}
+}
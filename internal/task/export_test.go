@@ -0,0 +1,105 @@
+package task
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestManagerExportSortsByCreated(t *testing.T) {
+	tmpDir := t.TempDir()
+	mgr := NewManager(filepath.Join(tmpDir, "tasks.json"))
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := NewTask("task-b", "Newer", "")
+	newer.CreatedAt = base.Add(time.Hour)
+	older := NewTask("task-a", "Older", "")
+	older.CreatedAt = base
+	tie := NewTask("task-0", "Tie", "")
+	tie.CreatedAt = base
+
+	if err := mgr.SaveAll([]Task{*newer, *older, *tie}); err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := mgr.Export(&buf); err != nil {
+		t.Fatalf("Export() failed: %v", err)
+	}
+
+	// Round-trip into a fresh registry to inspect the order
+	other := NewManager(filepath.Join(tmpDir, "other.json"))
+	if _, err := other.Import(&buf, ImportReplace); err != nil {
+		t.Fatalf("Import() failed: %v", err)
+	}
+	tasks, _ := other.LoadAll()
+
+	var ids []string
+	for _, tk := range tasks {
+		ids = append(ids, tk.ID)
+	}
+	if got := strings.Join(ids, ","); got != "task-0,task-a,task-b" {
+		t.Errorf("unexpected export order: %s", got)
+	}
+}
+
+func TestManagerImportMerge(t *testing.T) {
+	tmpDir := t.TempDir()
+	mgr := NewManager(filepath.Join(tmpDir, "tasks.json"))
+
+	existing := NewTask("task-1", "Local copy", "")
+	existing.Status = StatusCompleted
+	if err := mgr.AddTask(existing); err != nil {
+		t.Fatalf("failed to add: %v", err)
+	}
+
+	input := `[
+		{"id": "task-1", "title": "Imported copy", "status": "pending"},
+		{"id": "task-2", "title": "First", "status": "pending"},
+		{"id": "task-2", "title": "Duplicate", "status": "pending"}
+	]`
+
+	added, err := mgr.Import(strings.NewReader(input), ImportMerge)
+	if err != nil {
+		t.Fatalf("Import() failed: %v", err)
+	}
+	if added != 1 {
+		t.Errorf("expected 1 task added, got %d", added)
+	}
+
+	tasks, _ := mgr.LoadAll()
+	if len(tasks) != 2 {
+		t.Fatalf("expected 2 tasks, got %d", len(tasks))
+	}
+	if tasks[0].Title != "Local copy" || tasks[0].Status != StatusCompleted {
+		t.Errorf("existing task must win over the import: %+v", tasks[0])
+	}
+	if tasks[1].Title != "First" {
+		t.Errorf("expected first occurrence of a duplicate to win, got %q", tasks[1].Title)
+	}
+}
+
+func TestManagerImportReplace(t *testing.T) {
+	tmpDir := t.TempDir()
+	mgr := NewManager(filepath.Join(tmpDir, "tasks.json"))
+
+	if err := mgr.AddTask(NewTask("task-1", "Old", "")); err != nil {
+		t.Fatalf("failed to add: %v", err)
+	}
+
+	input := `{"schema_version": 1, "tasks": [{"id": "task-9", "title": "New", "status": "failed"}]}`
+	added, err := mgr.Import(strings.NewReader(input), ImportReplace)
+	if err != nil {
+		t.Fatalf("Import() failed: %v", err)
+	}
+	if added != 1 {
+		t.Errorf("expected 1 task added, got %d", added)
+	}
+
+	tasks, _ := mgr.LoadAll()
+	if len(tasks) != 1 || tasks[0].ID != "task-9" || tasks[0].Status != StatusFailed {
+		t.Errorf("expected only the imported task, got %+v", tasks)
+	}
+}
@@ -0,0 +1,122 @@
+package task
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultIDFormat is the ID format used when none is configured. It gives
+// the same IDs as NewID("task").
+const DefaultIDFormat = "task-{nanos}-{short}"
+
+// ID format placeholders.
+const (
+	// idSeq is a sequence number stored in the tasks file, starting at 1.
+	idSeq = "{seq}"
+
+	// idDate is the creation date as YYYYMMDD.
+	idDate = "{date}"
+
+	// idNanos is the creation time in Unix nanoseconds.
+	idNanos = "{nanos}"
+
+	// idShort is six random hex digits.
+	idShort = "{short}"
+)
+
+// idPlaceholder matches any placeholder in an ID format.
+var idPlaceholder = regexp.MustCompile(`\{[^{}]*\}`)
+
+// ValidateIDFormat checks that format only uses known placeholders and
+// holds at least one of {seq}, {nanos} or {short}, so the IDs it generates
+// are unique.
+func ValidateIDFormat(format string) error {
+	if strings.ContainsAny(format, " \t\n") {
+		return fmt.Errorf("ID format %q cannot contain whitespace", format)
+	}
+	for _, p := range idPlaceholder.FindAllString(format, -1) {
+		switch p {
+		case idSeq, idDate, idNanos, idShort:
+		default:
+			return fmt.Errorf("ID format %q has unknown placeholder %s", format, p)
+		}
+	}
+	if !strings.Contains(format, idSeq) && !strings.Contains(format, idNanos) && !strings.Contains(format, idShort) {
+		return fmt.Errorf("ID format %q needs {seq}, {nanos} or {short} to keep IDs unique", format)
+	}
+	return nil
+}
+
+// expandID fills the placeholders of format for a task created at now
+// with sequence number seq.
+func expandID(format string, now time.Time, seq int) string {
+	return idPlaceholder.ReplaceAllStringFunc(format, func(p string) string {
+		switch p {
+		case idSeq:
+			return strconv.Itoa(seq)
+		case idDate:
+			return now.Format("20060102")
+		case idNanos:
+			return strconv.FormatInt(now.UnixNano(), 10)
+		case idShort:
+			var b [3]byte
+			rand.Read(b[:])
+			return hex.EncodeToString(b[:])
+		}
+		return p
+	})
+}
+
+// WithIDFormat sets the format of the IDs Create, Clone and
+// RepairDuplicateIDs give new tasks. The placeholders {seq}, {date},
+// {nanos} and {short} are expanded; an empty format means
+// DefaultIDFormat. Returns m for chaining.
+func (m *Manager) WithIDFormat(format string) *Manager {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.idFormat = format
+	return m
+}
+
+// Create gives t a fresh ID in the configured format and adds it to the
+// registry. The ID is assigned under the same lock as the write, so
+// sequence numbers are never handed out twice.
+func (m *Manager) Create(t *Task) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tasks, old, err := m.loadForWriteLocked()
+	if err != nil {
+		return err
+	}
+
+	t.ID = m.newIDLocked(tasks, &old.seq)
+	tasks = append(tasks, *t)
+	return m.saveLocked(old, tasks)
+}
+
+// newIDLocked returns an ID in the configured format that no task in tasks
+// has. When the format uses {seq}, *seq is advanced past any number already
+// taken and the caller must save it with the tasks.
+func (m *Manager) newIDLocked(tasks []Task, seq *int) string {
+	format := m.idFormat
+	if format == "" {
+		format = DefaultIDFormat
+	}
+	usesSeq := strings.Contains(format, idSeq)
+	for {
+		if usesSeq {
+			*seq++
+		}
+		id := expandID(format, time.Now(), *seq)
+		if indexOf(tasks, id) < 0 {
+			return id
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package task
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"testing"
+)
+
+func TestValidateIDFormat(t *testing.T) {
+	for _, tc := range []struct {
+		format  string
+		wantErr bool
+	}{
+		{DefaultIDFormat, false},
+		{"T-{seq}", false},
+		{"{date}-{short}", false},
+		{"{date}", true},
+		{"task", true},
+		{"T-{counter}", true},
+		{"T {seq}", true},
+	} {
+		if err := ValidateIDFormat(tc.format); (err != nil) != tc.wantErr {
+			t.Errorf("ValidateIDFormat(%q) error = %v, wantErr %v", tc.format, err, tc.wantErr)
+		}
+	}
+}
+
+func TestManagerCreateIDFormats(t *testing.T) {
+	for _, tc := range []struct {
+		format string
+		want   *regexp.Regexp
+	}{
+		{"", regexp.MustCompile(`^task-\d+-[0-9a-f]{6}$`)},
+		{"{date}-{short}", regexp.MustCompile(`^\d{8}-[0-9a-f]{6}$`)},
+		{"T-{seq}", regexp.MustCompile(`^T-1$`)},
+	} {
+		mgr := NewManager(filepath.Join(t.TempDir(), "tasks.json")).WithIDFormat(tc.format)
+		created := NewTask("", "Write docs", "")
+		if err := mgr.Create(created); err != nil {
+			t.Fatalf("Create() with format %q failed: %v", tc.format, err)
+		}
+		if !tc.want.MatchString(created.ID) {
+			t.Errorf("format %q: unexpected ID %q", tc.format, created.ID)
+		}
+		if _, err := mgr.GetByID(created.ID); err != nil {
+			t.Errorf("format %q: created task not stored: %v", tc.format, err)
+		}
+	}
+}
+
+func TestManagerSequenceIDs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	mgr := NewManager(path).WithIDFormat("T-{seq}")
+
+	first := NewTask("", "First", "")
+	if err := mgr.Create(first); err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+
+	// Other writes keep the counter, and deleting the newest task doesn't
+	// hand its number out again
+	if err := mgr.UpdateStatus(first.ID, StatusCompleted, ""); err != nil {
+		t.Fatalf("UpdateStatus() failed: %v", err)
+	}
+	if err := mgr.DeleteTask(first.ID); err != nil {
+		t.Fatalf("DeleteTask() failed: %v", err)
+	}
+	if err := mgr.AddTask(NewTask("T-3", "Added by hand", "")); err != nil {
+		t.Fatalf("AddTask() failed: %v", err)
+	}
+
+	// A fresh manager reads the counter back from the file
+	reopened := NewManager(path).WithIDFormat("T-{seq}")
+	var got []string
+	for range 2 {
+		next := NewTask("", "Next", "")
+		if err := reopened.Create(next); err != nil {
+			t.Fatalf("Create() failed: %v", err)
+		}
+		got = append(got, next.ID)
+	}
+	// T-3 is taken, so the counter skips it
+	if got[0] != "T-2" || got[1] != "T-4" {
+		t.Errorf("expected T-2 and T-4, got %v", got)
+	}
+}
+
+func TestManagerSequenceIDsConcurrent(t *testing.T) {
+	mgr := NewManager(filepath.Join(t.TempDir(), "tasks.json")).WithIDFormat("T-{seq}")
+
+	const n = 50
+	ids := make([]string, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			created := NewTask("", fmt.Sprintf("Task %d", i), "")
+			if err := mgr.Create(created); err != nil {
+				t.Errorf("Create() failed: %v", err)
+				return
+			}
+			ids[i] = created.ID
+		}()
+	}
+	wg.Wait()
+
+	seen := make(map[string]bool, n)
+	for _, id := range ids {
+		if seen[id] {
+			t.Errorf("ID %s handed out twice", id)
+		}
+		seen[id] = true
+	}
+	for i := 1; i <= n; i++ {
+		if !seen[fmt.Sprintf("T-%d", i)] {
+			t.Errorf("expected T-%d to be used", i)
+		}
+	}
+
+	tasks, err := mgr.LoadAll()
+	if err != nil {
+		t.Fatalf("LoadAll() failed: %v", err)
+	}
+	if len(tasks) != n {
+		t.Errorf("expected %d tasks, got %d", n, len(tasks))
+	}
+}
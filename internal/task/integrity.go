@@ -0,0 +1,71 @@
+package task
+
+import "fmt"
+
+// CheckIntegrity reports problems in the tasks file that normal writes
+// would never produce but a hand edit can: duplicate IDs, which make
+// lookups hit only the first match and every save fail, and invalid
+// statuses. It returns one message per problem, none for a healthy file.
+func (m *Manager) CheckIntegrity() ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tasks, err := m.loadAllLocked()
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	counts := make(map[string]int, len(tasks))
+	for _, t := range tasks {
+		counts[t.ID]++
+	}
+	reported := make(map[string]bool)
+	for _, t := range tasks {
+		if counts[t.ID] > 1 && !reported[t.ID] {
+			problems = append(problems, fmt.Sprintf("duplicate ID %s used by %d tasks", t.ID, counts[t.ID]))
+			reported[t.ID] = true
+		}
+	}
+	for _, t := range tasks {
+		if !t.Status.IsValid() {
+			problems = append(problems, fmt.Sprintf("task %s has invalid status %q", t.ID, t.Status))
+		}
+	}
+	return problems, nil
+}
+
+// RepairDuplicateIDs gives every task that reuses an earlier task's ID a
+// fresh one, keeping the first task with each ID unchanged. It returns a
+// map from each new ID to the duplicated one. The save still fails if a
+// renamed task has other problems, such as an invalid status.
+func (m *Manager) RepairDuplicateIDs() (map[string]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tasks, old, err := m.loadForWriteLocked()
+	if err != nil {
+		return nil, err
+	}
+
+	renamed := make(map[string]string)
+	seen := make(map[string]bool, len(tasks))
+	for i := range tasks {
+		if !seen[tasks[i].ID] {
+			seen[tasks[i].ID] = true
+			continue
+		}
+		id := m.newIDLocked(tasks, &old.seq)
+		renamed[id] = tasks[i].ID
+		tasks[i].ID = id
+		seen[id] = true
+	}
+	if len(renamed) == 0 {
+		return renamed, nil
+	}
+
+	if err := m.saveLocked(old, tasks); err != nil {
+		return nil, err
+	}
+	return renamed, nil
+}
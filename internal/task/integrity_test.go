@@ -0,0 +1,74 @@
+package task
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestManagerCheckIntegrity(t *testing.T) {
+	tmpDir := t.TempDir()
+	tasksPath := filepath.Join(tmpDir, "tasks.json")
+
+	// A hand-edited file with a duplicated ID, which saves would reject
+	data := `{"schema_version": 1, "tasks": [
+		{"id": "task-1", "title": "First", "status": "pending"},
+		{"id": "task-2", "title": "Second", "status": "completed"},
+		{"id": "task-1", "title": "Copy of first", "status": "pending"}
+	]}`
+	if err := os.WriteFile(tasksPath, []byte(data), 0644); err != nil {
+		t.Fatalf("failed to write tasks file: %v", err)
+	}
+	mgr := NewManager(tasksPath)
+
+	problems, err := mgr.CheckIntegrity()
+	if err != nil {
+		t.Fatalf("CheckIntegrity() failed: %v", err)
+	}
+	if len(problems) != 1 || !strings.Contains(problems[0], "duplicate ID task-1") {
+		t.Fatalf("expected one duplicate ID problem, got %q", problems)
+	}
+
+	renamed, err := mgr.RepairDuplicateIDs()
+	if err != nil {
+		t.Fatalf("RepairDuplicateIDs() failed: %v", err)
+	}
+	if len(renamed) != 1 {
+		t.Fatalf("expected one task renamed, got %v", renamed)
+	}
+	for newID, oldID := range renamed {
+		if oldID != "task-1" {
+			t.Errorf("expected the duplicate of task-1 renamed, got %s", oldID)
+		}
+		copied, err := mgr.GetByID(newID)
+		if err != nil || copied.Title != "Copy of first" {
+			t.Errorf("expected the later duplicate under %s, got %+v (%v)", newID, copied, err)
+		}
+	}
+	if first, err := mgr.GetByID("task-1"); err != nil || first.Title != "First" {
+		t.Errorf("expected the first task to keep its ID, got %+v (%v)", first, err)
+	}
+
+	if problems, err := mgr.CheckIntegrity(); err != nil || len(problems) != 0 {
+		t.Errorf("expected a healthy file after the repair, got %q (%v)", problems, err)
+	}
+}
+
+func TestManagerCheckIntegrityInvalidStatus(t *testing.T) {
+	tmpDir := t.TempDir()
+	tasksPath := filepath.Join(tmpDir, "tasks.json")
+
+	data := `{"schema_version": 1, "tasks": [{"id": "task-1", "title": "First", "status": "finished"}]}`
+	if err := os.WriteFile(tasksPath, []byte(data), 0644); err != nil {
+		t.Fatalf("failed to write tasks file: %v", err)
+	}
+
+	problems, err := NewManager(tasksPath).CheckIntegrity()
+	if err != nil {
+		t.Fatalf("CheckIntegrity() failed: %v", err)
+	}
+	if len(problems) != 1 || !strings.Contains(problems[0], `invalid status "finished"`) {
+		t.Errorf("expected an invalid status problem, got %q", problems)
+	}
+}
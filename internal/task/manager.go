@@ -1,18 +1,61 @@
 package task
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 )
 
+// ErrTaskNotFound is returned when no task has the requested ID.
+var ErrTaskNotFound = errors.New("task not found")
+
+// ErrInvalidTask is returned when a write would persist a malformed task.
+var ErrInvalidTask = errors.New("invalid task")
+
+// DispatchOrder selects which pending task is picked next.
+type DispatchOrder string
+
+const (
+	// DispatchPriority picks the highest (aged) priority first.
+	DispatchPriority DispatchOrder = "priority"
+
+	// DispatchFIFO picks the oldest task first.
+	DispatchFIFO DispatchOrder = "fifo"
+
+	// DispatchLIFO picks the newest task first.
+	DispatchLIFO DispatchOrder = "lifo"
+)
+
+// IsValid reports whether o is a known dispatch order.
+func (o DispatchOrder) IsValid() bool {
+	switch o {
+	case DispatchPriority, DispatchFIFO, DispatchLIFO:
+		return true
+	}
+	return false
+}
+
 // Manager handles loading, saving, and querying tasks from a JSON file.
 type Manager struct {
-	filePath string
-	mu       sync.RWMutex
+	filePath  string
+	auditPath string
+	logger    *slog.Logger
+	fsync     bool
+	ageStep   time.Duration
+	order     DispatchOrder
+	idFormat  string
+	mu        sync.RWMutex
+
+	// transitions are the handlers registered with OnTransition
+	transitions []TransitionFunc
 }
 
 // NewManager creates a new task manager for the given file path.
@@ -22,7 +65,46 @@ func NewManager(filePath string) *Manager {
 	}
 }
 
-// EnsureFile creates the tasks file if it doesn't exist.
+// WithFsync makes every save flush the tasks file and its directory to disk,
+// so a completed write survives a crash or power loss. Without it a save is
+// still atomic (readers see the old or the new file, never a mix), but the
+// last writes may be lost if the machine goes down right after them. Each
+// save then waits on the disk, so enable it for long unattended runs rather
+// than interactive use. Returns m for chaining.
+func (m *Manager) WithFsync(enabled bool) *Manager {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.fsync = enabled
+	return m
+}
+
+// WithPriorityAging makes a pending task's priority grow by one for every
+// step it has waited since creation when choosing the next task, so a
+// stream of higher-priority work cannot starve older tasks. The stored
+// priority is unchanged. A step of zero turns aging off. Returns m for
+// chaining.
+func (m *Manager) WithPriorityAging(step time.Duration) *Manager {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.ageStep = step
+	return m
+}
+
+// WithDispatchOrder sets the order pending tasks are picked in. The
+// default, DispatchPriority, also applies priority aging. Returns m for
+// chaining.
+func (m *Manager) WithDispatchOrder(order DispatchOrder) *Manager {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.order = order
+	return m
+}
+
+// EnsureFile creates the tasks file if it doesn't exist and migrates
+// an existing file written with an older schema version.
 func (m *Manager) EnsureFile() error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -37,11 +119,16 @@ func (m *Manager) EnsureFile() error {
 		}
 
 		// Create empty tasks file
-		if err := os.WriteFile(m.filePath, []byte("[]"), 0644); err != nil {
+		data, err := encodeTasks(nil, 0)
+		if err != nil {
+			return fmt.Errorf("failed to marshal tasks: %w", err)
+		}
+		if err := m.writeLocked(data); err != nil {
 			return fmt.Errorf("failed to create tasks file: %w", err)
 		}
+		return nil
 	}
-	return nil
+	return m.migrateLocked()
 }
 
 // LoadAll reads all tasks from the file.
@@ -49,20 +136,7 @@ func (m *Manager) LoadAll() ([]Task, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	data, err := os.ReadFile(m.filePath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return []Task{}, nil
-		}
-		return nil, fmt.Errorf("failed to read tasks file: %w", err)
-	}
-
-	var tasks []Task
-	if err := json.Unmarshal(data, &tasks); err != nil {
-		return nil, fmt.Errorf("failed to parse tasks file: %w", err)
-	}
-
-	return tasks, nil
+	return m.loadAllLocked()
 }
 
 // SaveAll writes all tasks to the file atomically.
@@ -70,19 +144,72 @@ func (m *Manager) SaveAll(tasks []Task) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	return m.saveAllLocked(tasks)
+	_, old, err := m.loadForWriteLocked()
+	if err != nil {
+		return err
+	}
+	return m.saveLocked(old, tasks)
+}
+
+// stored is the tasks file as read at the start of a write: the rows as they
+// were, which saveLocked diffs the new ones against, and the sequence ID
+// counter to write back.
+type stored struct {
+	tasks []Task
+	seq   int
 }
 
-// saveAllLocked writes tasks without acquiring the lock (caller must hold lock).
-func (m *Manager) saveAllLocked(tasks []Task) error {
-	data, err := json.MarshalIndent(tasks, "", "  ")
+// loadForWriteLocked reads the tasks file once for a caller that will save
+// it with saveLocked (caller must hold lock). It returns the tasks to change
+// and a copy of them as stored, so the save needn't read the file again.
+func (m *Manager) loadForWriteLocked() ([]Task, stored, error) {
+	f, err := m.readLocked()
+	if err != nil {
+		return nil, stored{}, err
+	}
+	migrateTasks(f.Tasks, f.SchemaVersion)
+	return f.Tasks, storedCopy(f.Tasks, f.LastSeq), nil
+}
+
+// storedCopy returns a deep copy of tasks as stored with sequence counter seq.
+func storedCopy(tasks []Task, seq int) stored {
+	before := make([]Task, len(tasks))
+	for i := range tasks {
+		before[i] = copyTask(&tasks[i])
+	}
+	return stored{tasks: before, seq: seq}
+}
+
+// saveLocked writes tasks along with old.seq, the sequence ID counter. The
+// rows that differ from old.tasks are validated, audited and reported to
+// the transition hooks.
+func (m *Manager) saveLocked(old stored, tasks []Task) error {
+	if err := validateTasks(old.tasks, tasks); err != nil {
+		return err
+	}
+
+	data, err := encodeTasks(tasks, old.seq)
 	if err != nil {
 		return fmt.Errorf("failed to marshal tasks: %w", err)
 	}
+	if err := m.writeLocked(data); err != nil {
+		return err
+	}
+
+	if m.auditPath != "" {
+		m.auditChanges(old.tasks, tasks)
+	}
+	m.notifyChanges(old.tasks, tasks)
+	return nil
+}
 
+// writeLocked replaces the tasks file with data atomically, flushing it to
+// disk when fsync is enabled.
+func (m *Manager) writeLocked(data []byte) error {
 	// Write to temp file first, then rename (atomic)
 	tmpPath := m.filePath + ".tmp"
-	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+	if err := writeFile(tmpPath, data, m.fsync); err != nil {
+		os.Remove(tmpPath)
 		return fmt.Errorf("failed to write temp file: %w", err)
 	}
 
@@ -91,9 +218,47 @@ func (m *Manager) saveAllLocked(tasks []Task) error {
 		return fmt.Errorf("failed to rename temp file: %w", err)
 	}
 
+	// Persist the rename itself
+	if m.fsync {
+		if err := syncDir(filepath.Dir(m.filePath)); err != nil {
+			return fmt.Errorf("failed to sync tasks directory: %w", err)
+		}
+	}
 	return nil
 }
 
+// writeFile writes data to path, flushing it to disk before returning when
+// durable is set.
+func writeFile(path string, data []byte, durable bool) error {
+	if !durable {
+		return os.WriteFile(path, data, 0644)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// syncDir flushes a directory's entries to disk.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
 // GetNextPending returns the next pending task and marks it as claimed.
 // Returns nil if no pending tasks are available.
 func (m *Manager) GetNextPending() (*Task, error) {
@@ -105,34 +270,120 @@ func (m *Manager) GetNextPending() (*Task, error) {
 		return nil, err
 	}
 
-	// Find first pending task (respecting priority)
-	var bestTask *Task
-	var bestIdx int = -1
-	for i := range tasks {
-		if tasks[i].Status == StatusPending {
-			if bestTask == nil || tasks[i].Priority > bestTask.Priority {
-				bestTask = &tasks[i]
-				bestIdx = i
-			}
-		}
+	idx := nextPendingIndex(tasks, m.order, m.ageStep)
+	if idx < 0 {
+		return nil, nil
+	}
+
+	// Return a copy
+	result := tasks[idx]
+	return &result, nil
+}
+
+// ClaimNext selects the next pending task in dispatch order and marks it as
+// queued for dispatch under a single lock, so concurrent dispatchers never
+// claim the same task. Returns nil if nothing is pending.
+func (m *Manager) ClaimNext(workerID int) (*Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tasks, old, err := m.loadForWriteLocked()
+	if err != nil {
+		return nil, err
 	}
 
-	if bestTask == nil {
+	idx := nextPendingIndex(tasks, m.order, m.ageStep)
+	if idx < 0 {
 		return nil, nil
 	}
 
-	// Return a copy
-	result := tasks[bestIdx]
+	tasks[idx].MarkQueued(workerID)
+	if err := m.saveLocked(old, tasks); err != nil {
+		return nil, err
+	}
+
+	result := tasks[idx]
 	return &result, nil
 }
 
-// ClaimTask atomically marks a task as in_progress for a worker.
+// validateTasks checks every task that is new or differs from before and
+// rejects it if its ID is used more than once, so a single bad write cannot
+// corrupt the registry. Rows left as they were are not checked, so a file
+// that already holds an invalid task can still be repaired or pruned.
+func validateTasks(before, tasks []Task) error {
+	unchanged := make(map[string][]*Task, len(before))
+	for i := range before {
+		unchanged[before[i].ID] = append(unchanged[before[i].ID], &before[i])
+	}
+	count := make(map[string]int, len(tasks))
+	for i := range tasks {
+		count[tasks[i].ID]++
+	}
+
+	for i := range tasks {
+		// Each stored row vouches for at most one task, so a copy of an
+		// existing task still counts as a change
+		rows := unchanged[tasks[i].ID]
+		if j := slices.IndexFunc(rows, func(t *Task) bool { return reflect.DeepEqual(t, &tasks[i]) }); j >= 0 {
+			unchanged[tasks[i].ID] = slices.Delete(rows, j, j+1)
+			continue
+		}
+		if err := tasks[i].Validate(); err != nil {
+			return err
+		}
+		if count[tasks[i].ID] > 1 {
+			return fmt.Errorf("%w: duplicate ID %s", ErrInvalidTask, tasks[i].ID)
+		}
+	}
+	return nil
+}
+
+// nextPendingIndex returns the index of the pending task to dispatch
+// next under order, or -1 if none is pending. Ties go to the task earliest
+// in the registry. Tasks backing off before a retry are skipped until their
+// RetryAfter has passed.
+func nextPendingIndex(tasks []Task, order DispatchOrder, ageStep time.Duration) int {
+	now := time.Now()
+	best := -1
+	for i := range tasks {
+		if tasks[i].Status != StatusPending || tasks[i].RetryAfter.After(now) {
+			continue
+		}
+		if best < 0 || dispatchesBefore(&tasks[i], &tasks[best], order, now, ageStep) {
+			best = i
+		}
+	}
+	return best
+}
+
+// dispatchesBefore reports whether a should be dispatched ahead of b.
+func dispatchesBefore(a, b *Task, order DispatchOrder, now time.Time, ageStep time.Duration) bool {
+	switch order {
+	case DispatchFIFO:
+		return a.CreatedAt.Before(b.CreatedAt)
+	case DispatchLIFO:
+		return a.CreatedAt.After(b.CreatedAt)
+	default:
+		return effectivePriority(a, now, ageStep) > effectivePriority(b, now, ageStep)
+	}
+}
+
+// effectivePriority is t's priority plus one for every ageStep it has
+// existed at now. A zero ageStep leaves the priority as is.
+func effectivePriority(t *Task, now time.Time, ageStep time.Duration) int {
+	if ageStep <= 0 || t.CreatedAt.IsZero() || !now.After(t.CreatedAt) {
+		return t.Priority
+	}
+	return t.Priority + int(now.Sub(t.CreatedAt)/ageStep)
+}
+
+// ClaimTask atomically marks a pending task as queued for dispatch.
 // Returns error if task is no longer pending.
 func (m *Manager) ClaimTask(taskID string, workerID int) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	tasks, err := m.loadAllLocked()
+	tasks, old, err := m.loadForWriteLocked()
 	if err != nil {
 		return err
 	}
@@ -142,12 +393,34 @@ func (m *Manager) ClaimTask(taskID string, workerID int) error {
 			if tasks[i].Status != StatusPending {
 				return fmt.Errorf("task %s is no longer pending (status: %s)", taskID, tasks[i].Status)
 			}
-			tasks[i].MarkInProgress(workerID)
-			return m.saveAllLocked(tasks)
+			tasks[i].MarkQueued(workerID)
+			return m.saveLocked(old, tasks)
 		}
 	}
 
-	return fmt.Errorf("task not found: %s", taskID)
+	return fmt.Errorf("%w: %s", ErrTaskNotFound, taskID)
+}
+
+// StartTask marks a queued task as in_progress once a worker begins
+// executing it. Returns error if the task is no longer queued.
+func (m *Manager) StartTask(taskID string, workerID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tasks, old, err := m.loadForWriteLocked()
+	if err != nil {
+		return err
+	}
+
+	idx := indexOf(tasks, taskID)
+	if idx < 0 {
+		return fmt.Errorf("%w: %s", ErrTaskNotFound, taskID)
+	}
+	if tasks[idx].Status != StatusQueued {
+		return fmt.Errorf("task %s is not queued (status: %s)", taskID, tasks[idx].Status)
+	}
+	tasks[idx].MarkInProgress(workerID)
+	return m.saveLocked(old, tasks)
 }
 
 // GetByID returns a task by its ID.
@@ -167,7 +440,7 @@ func (m *Manager) GetByID(id string) (*Task, error) {
 		}
 	}
 
-	return nil, fmt.Errorf("task not found: %s", id)
+	return nil, fmt.Errorf("%w: %s", ErrTaskNotFound, id)
 }
 
 // UpdateTask updates a task in the file.
@@ -175,7 +448,7 @@ func (m *Manager) UpdateTask(updated *Task) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	tasks, err := m.loadAllLocked()
+	tasks, old, err := m.loadForWriteLocked()
 	if err != nil {
 		return err
 	}
@@ -191,10 +464,33 @@ func (m *Manager) UpdateTask(updated *Task) error {
 	}
 
 	if !found {
-		return fmt.Errorf("task not found: %s", updated.ID)
+		return fmt.Errorf("%w: %s", ErrTaskNotFound, updated.ID)
 	}
 
-	return m.saveAllLocked(tasks)
+	return m.saveLocked(old, tasks)
+}
+
+// Modify applies fn to the task with the given ID and saves the result, all
+// under one lock, so concurrent changes to other fields are not lost. If fn
+// returns an error nothing is saved and the error is returned.
+func (m *Manager) Modify(taskID string, fn func(t *Task) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tasks, old, err := m.loadForWriteLocked()
+	if err != nil {
+		return err
+	}
+
+	idx := indexOf(tasks, taskID)
+	if idx < 0 {
+		return fmt.Errorf("%w: %s", ErrTaskNotFound, taskID)
+	}
+	if err := fn(&tasks[idx]); err != nil {
+		return err
+	}
+	tasks[idx].UpdatedAt = time.Now()
+	return m.saveLocked(old, tasks)
 }
 
 // UpdateStatus updates just the status of a task.
@@ -202,35 +498,28 @@ func (m *Manager) UpdateStatus(taskID string, status Status, reason string) erro
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	tasks, err := m.loadAllLocked()
+	tasks, old, err := m.loadForWriteLocked()
 	if err != nil {
 		return err
 	}
 
 	for i := range tasks {
 		if tasks[i].ID == taskID {
-			tasks[i].Status = status
-			tasks[i].UpdatedAt = time.Now()
-			if reason != "" {
-				tasks[i].FailReason = reason
-			}
-			if status.IsTerminal() {
-				tasks[i].CompletedAt = time.Now()
-			}
-			return m.saveAllLocked(tasks)
+			tasks[i].SetStatus(status, reason)
+			return m.saveLocked(old, tasks)
 		}
 	}
 
-	return fmt.Errorf("task not found: %s", taskID)
+	return fmt.Errorf("%w: %s", ErrTaskNotFound, taskID)
 }
 
-// RecoverInProgress resets all in_progress tasks to pending.
+// RecoverInProgress resets all queued and in_progress tasks to pending.
 // Returns the number of tasks recovered.
 func (m *Manager) RecoverInProgress() (int, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	tasks, err := m.loadAllLocked()
+	tasks, old, err := m.loadForWriteLocked()
 	if err != nil {
 		return 0, err
 	}
@@ -244,7 +533,7 @@ func (m *Manager) RecoverInProgress() (int, error) {
 	}
 
 	if count > 0 {
-		if err := m.saveAllLocked(tasks); err != nil {
+		if err := m.saveLocked(old, tasks); err != nil {
 			return 0, err
 		}
 	}
@@ -252,12 +541,131 @@ func (m *Manager) RecoverInProgress() (int, error) {
 	return count, nil
 }
 
+// RecoverStuck resets active tasks whose last sign of life is older than
+// threshold. The heartbeat is used when present, falling back to StartedAt.
+// Queued tasks are always reset since the dispatch queue does not survive
+// a restart. Returns the number of tasks recovered.
+func (m *Manager) RecoverStuck(threshold time.Duration) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tasks, old, err := m.loadForWriteLocked()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for i := range tasks {
+		if !tasks[i].Status.IsActive() {
+			continue
+		}
+		lastSeen := tasks[i].HeartbeatAt
+		if lastSeen.IsZero() {
+			lastSeen = tasks[i].StartedAt
+		}
+		if tasks[i].Status == StatusQueued || time.Since(lastSeen) > threshold {
+			tasks[i].ResetForRetry()
+			count++
+		}
+	}
+
+	if count > 0 {
+		if err := m.saveLocked(old, tasks); err != nil {
+			return 0, err
+		}
+	}
+
+	return count, nil
+}
+
+// ForceReset moves a single active task back to pending regardless of its
+// heartbeat, for when its worker died without releasing it.
+func (m *Manager) ForceReset(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tasks, old, err := m.loadForWriteLocked()
+	if err != nil {
+		return err
+	}
+
+	idx := indexOf(tasks, id)
+	if idx < 0 {
+		return fmt.Errorf("%w: %s", ErrTaskNotFound, id)
+	}
+	if !tasks[idx].Status.IsActive() {
+		return fmt.Errorf("task %s is not in progress (status: %s)", id, tasks[idx].Status)
+	}
+
+	workerID := tasks[idx].WorkerID
+	tasks[idx].ResetForRetry()
+	tasks[idx].AddLog("warn", "", fmt.Sprintf("force reset to pending (was held by worker %d)", workerID), nil)
+	return m.saveLocked(old, tasks)
+}
+
+// SetAgentCmd records the agent command a worker used to run a task.
+func (m *Manager) SetAgentCmd(taskID string, cmd []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tasks, old, err := m.loadForWriteLocked()
+	if err != nil {
+		return err
+	}
+
+	idx := indexOf(tasks, taskID)
+	if idx < 0 {
+		return fmt.Errorf("%w: %s", ErrTaskNotFound, taskID)
+	}
+	tasks[idx].AgentCmd = cmd
+	tasks[idx].UpdatedAt = time.Now()
+	return m.saveLocked(old, tasks)
+}
+
+// SetExtraInstructions stores instructions appended to the task's prompt
+// on its next runs. An empty text clears them.
+func (m *Manager) SetExtraInstructions(taskID, text string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tasks, old, err := m.loadForWriteLocked()
+	if err != nil {
+		return err
+	}
+
+	idx := indexOf(tasks, taskID)
+	if idx < 0 {
+		return fmt.Errorf("%w: %s", ErrTaskNotFound, taskID)
+	}
+	tasks[idx].ExtraInstructions = text
+	tasks[idx].UpdatedAt = time.Now()
+	return m.saveLocked(old, tasks)
+}
+
+// Heartbeat records that the worker owning a task is still alive.
+func (m *Manager) Heartbeat(taskID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tasks, old, err := m.loadForWriteLocked()
+	if err != nil {
+		return err
+	}
+
+	idx := indexOf(tasks, taskID)
+	if idx < 0 {
+		return fmt.Errorf("%w: %s", ErrTaskNotFound, taskID)
+	}
+	tasks[idx].HeartbeatAt = time.Now()
+	return m.saveLocked(old, tasks)
+}
+
 // AddTask adds a new task to the file.
 func (m *Manager) AddTask(t *Task) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	tasks, err := m.loadAllLocked()
+	tasks, old, err := m.loadForWriteLocked()
 	if err != nil {
 		return err
 	}
@@ -270,7 +678,65 @@ func (m *Manager) AddTask(t *Task) error {
 	}
 
 	tasks = append(tasks, *t)
-	return m.saveAllLocked(tasks)
+	return m.saveLocked(old, tasks)
+}
+
+// Clone adds a pending copy of the task with the given ID under a fresh ID
+// and returns it. Only what defines the work is copied: title, description,
+// role, parent, context files and priority. Runtime state such as the
+// worker, timestamps, logs, fail reason and retry count starts over.
+func (m *Manager) Clone(id string) (*Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tasks, old, err := m.loadForWriteLocked()
+	if err != nil {
+		return nil, err
+	}
+
+	idx := indexOf(tasks, id)
+	if idx < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrTaskNotFound, id)
+	}
+	src := tasks[idx]
+
+	clone := NewTask(m.newIDLocked(tasks, &old.seq), src.Title, src.Description)
+	clone.Role = src.Role
+	clone.ParentID = src.ParentID
+	clone.ContextFiles = slices.Clone(src.ContextFiles)
+	clone.Priority = src.Priority
+
+	tasks = append(tasks, *clone)
+	if err := m.saveLocked(old, tasks); err != nil {
+		return nil, err
+	}
+	return clone, nil
+}
+
+// Upsert adds t if no task has its ID, otherwise it updates the existing
+// task's title, description, role and priority. Status, logs and other
+// runtime state are left untouched. Returns true if the task was created.
+func (m *Manager) Upsert(t *Task) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tasks, old, err := m.loadForWriteLocked()
+	if err != nil {
+		return false, err
+	}
+
+	idx := indexOf(tasks, t.ID)
+	if idx < 0 {
+		tasks = append(tasks, *t)
+		return true, m.saveLocked(old, tasks)
+	}
+
+	tasks[idx].Title = t.Title
+	tasks[idx].Description = t.Description
+	tasks[idx].Role = t.Role
+	tasks[idx].Priority = t.Priority
+	tasks[idx].UpdatedAt = time.Now()
+	return false, m.saveLocked(old, tasks)
 }
 
 // DeleteTask removes a task from the file.
@@ -278,7 +744,7 @@ func (m *Manager) DeleteTask(taskID string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	tasks, err := m.loadAllLocked()
+	tasks, old, err := m.loadForWriteLocked()
 	if err != nil {
 		return err
 	}
@@ -294,40 +760,281 @@ func (m *Manager) DeleteTask(taskID string) error {
 	}
 
 	if !found {
-		return fmt.Errorf("task not found: %s", taskID)
+		return fmt.Errorf("%w: %s", ErrTaskNotFound, taskID)
 	}
 
-	return m.saveAllLocked(newTasks)
+	return m.saveLocked(old, newTasks)
 }
 
-// CountByStatus returns the count of tasks in each status.
-func (m *Manager) CountByStatus() (map[Status]int, error) {
-	tasks, err := m.LoadAll()
+// MoveBefore moves a task so it sits directly before another task in the file.
+// Under DispatchPriority, ties are broken by file order, so this lets callers
+// control dispatch order without touching priority numbers. DispatchFIFO and
+// DispatchLIFO go by creation time and are not affected by file order.
+func (m *Manager) MoveBefore(id, beforeID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if id == beforeID {
+		return nil
+	}
+
+	tasks, old, err := m.loadForWriteLocked()
+	if err != nil {
+		return err
+	}
+
+	srcIdx := indexOf(tasks, id)
+	if srcIdx < 0 {
+		return fmt.Errorf("%w: %s", ErrTaskNotFound, id)
+	}
+	moved := tasks[srcIdx]
+	tasks = append(tasks[:srcIdx], tasks[srcIdx+1:]...)
+
+	dstIdx := indexOf(tasks, beforeID)
+	if dstIdx < 0 {
+		return fmt.Errorf("%w: %s", ErrTaskNotFound, beforeID)
+	}
+
+	tasks = append(tasks[:dstIdx], append([]Task{moved}, tasks[dstIdx:]...)...)
+	return m.saveLocked(old, tasks)
+}
+
+// MoveToTop moves a task to the front of the file.
+func (m *Manager) MoveToTop(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tasks, old, err := m.loadForWriteLocked()
+	if err != nil {
+		return err
+	}
+
+	idx := indexOf(tasks, id)
+	if idx < 0 {
+		return fmt.Errorf("%w: %s", ErrTaskNotFound, id)
+	}
+	if idx == 0 {
+		return nil
+	}
+
+	moved := tasks[idx]
+	copy(tasks[1:idx+1], tasks[:idx])
+	tasks[0] = moved
+	return m.saveLocked(old, tasks)
+}
+
+// Children returns the tasks generated by the given planning task, in
+// registry order.
+func (m *Manager) Children(parentID string) ([]Task, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tasks, err := m.loadAllLocked()
 	if err != nil {
 		return nil, err
 	}
 
-	counts := make(map[Status]int)
+	var children []Task
+	for _, t := range tasks {
+		if t.ParentID == parentID {
+			children = append(children, t)
+		}
+	}
+	return children, nil
+}
+
+// FindByWorker returns the tasks the given worker is processing or last
+// processed, in registry order.
+func (m *Manager) FindByWorker(workerID int) ([]Task, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tasks, err := m.loadAllLocked()
+	if err != nil {
+		return nil, err
+	}
+
+	var found []Task
+	for _, t := range tasks {
+		if t.WorkerID == workerID {
+			found = append(found, t)
+		}
+	}
+	return found, nil
+}
+
+// Search returns the tasks whose title or description contains query,
+// ignoring case. Title matches come first, then description-only matches,
+// each in registry order. An empty query matches every task.
+func (m *Manager) Search(query string) ([]Task, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tasks, err := m.loadAllLocked()
+	if err != nil {
+		return nil, err
+	}
+	return searchTasks(tasks, query), nil
+}
+
+// searchTasks returns the tasks matching query, ranked as Search does.
+func searchTasks(tasks []Task, query string) []Task {
+	query = strings.ToLower(strings.TrimSpace(query))
+	var byTitle, byDescription []Task
 	for _, t := range tasks {
-		counts[t.Status]++
+		switch {
+		case strings.Contains(strings.ToLower(t.Title), query):
+			byTitle = append(byTitle, t)
+		case strings.Contains(strings.ToLower(t.Description), query):
+			byDescription = append(byDescription, t)
+		}
+	}
+	return append(byTitle, byDescription...)
+}
+
+// CountByStatus returns the count of tasks in each status. It streams the
+// file instead of loading every task.
+func (m *Manager) CountByStatus() (map[Status]int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	counts := make(map[Status]int)
+	err := m.streamLocked(func(r io.Reader) error {
+		return scanStatuses(r, func(s Status) {
+			counts[s]++
+		})
+	})
+	if err != nil {
+		return nil, err
 	}
 	return counts, nil
 }
 
+// FindByStatus returns the tasks with the given status, in registry order.
+// It streams the file and keeps only the matching tasks in memory.
+func (m *Manager) FindByStatus(status Status) ([]Task, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var found []Task
+	err := m.streamLocked(func(r io.Reader) error {
+		return scanTasks(r, func(t *Task, version int) error {
+			one := []Task{*t}
+			migrateTasks(one, version)
+			if one[0].Status == status {
+				found = append(found, one[0])
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// ActiveCount returns the number of queued, in-progress and reviewing
+// tasks. It streams the file and only decodes each task's status, which
+// keeps it cheap enough for polling on large registries.
+func (m *Manager) ActiveCount() (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	count := 0
+	err := m.streamLocked(func(r io.Reader) error {
+		return scanStatuses(r, func(s Status) {
+			if s.IsActive() {
+				count++
+			}
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// streamLocked opens the tasks file and hands it to scan, for read paths
+// that process tasks one at a time. A missing file has no tasks. Caller
+// must hold the lock.
+func (m *Manager) streamLocked(scan func(r io.Reader) error) error {
+	f, err := os.Open(m.filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read tasks file: %w", err)
+	}
+	defer f.Close()
+
+	if err := scan(f); err != nil {
+		return fmt.Errorf("failed to parse tasks file: %w", err)
+	}
+	return nil
+}
+
+// Stats summarizes the registry in a single pass.
+type Stats struct {
+	// Counts holds the number of tasks in each status.
+	Counts map[Status]int
+
+	// Total is the number of tasks in the registry.
+	Total int
+
+	// OldestPendingAge is how long the oldest pending task has been waiting.
+	OldestPendingAge time.Duration
+
+	// LongestRunning is how long the longest in_progress task has been running.
+	LongestRunning time.Duration
+
+	// AvgCompletedDuration is the mean run time of completed tasks, or zero
+	// when no completed task recorded one.
+	AvgCompletedDuration time.Duration
+}
+
+// Stats returns per-status counts along with queue age and run time figures.
+func (m *Manager) Stats() (Stats, error) {
+	snap, err := m.Snapshot()
+	if err != nil {
+		return Stats{}, err
+	}
+	return snap.Stats(), nil
+}
+
 // loadAllLocked reads tasks without acquiring lock (caller must hold lock).
+// Tasks from older schema versions are upgraded in memory.
 func (m *Manager) loadAllLocked() ([]Task, error) {
+	f, err := m.readLocked()
+	if err != nil {
+		return nil, err
+	}
+	migrateTasks(f.Tasks, f.SchemaVersion)
+	return f.Tasks, nil
+}
+
+// readLocked reads the tasks file as stored, in its own schema version.
+func (m *Manager) readLocked() (taskFile, error) {
 	data, err := os.ReadFile(m.filePath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return []Task{}, nil
+			return taskFile{SchemaVersion: CurrentSchemaVersion, Tasks: []Task{}}, nil
 		}
-		return nil, fmt.Errorf("failed to read tasks file: %w", err)
+		return taskFile{}, fmt.Errorf("failed to read tasks file: %w", err)
 	}
 
-	var tasks []Task
-	if err := json.Unmarshal(data, &tasks); err != nil {
-		return nil, fmt.Errorf("failed to parse tasks file: %w", err)
+	f, err := decodeTasks(data)
+	if err != nil {
+		return taskFile{}, fmt.Errorf("failed to parse tasks file: %w", err)
 	}
+	return f, nil
+}
 
-	return tasks, nil
+// indexOf returns the position of the task with the given ID, or -1.
+func indexOf(tasks []Task, id string) int {
+	for i := range tasks {
+		if tasks[i].ID == id {
+			return i
+		}
+	}
+	return -1
 }
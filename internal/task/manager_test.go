@@ -1,10 +1,15 @@
 package task
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestManagerLoadSave(t *testing.T) {
@@ -36,6 +41,37 @@ func TestManagerLoadSave(t *testing.T) {
 	}
 }
 
+func TestManagerWithFsync(t *testing.T) {
+	tmpDir := t.TempDir()
+	tasksPath := filepath.Join(tmpDir, "tasks.json")
+
+	mgr := NewManager(tasksPath).WithFsync(true)
+	if err := mgr.EnsureFile(); err != nil {
+		t.Fatalf("failed to ensure file: %v", err)
+	}
+	// The new file goes through the same temp file and rename as saves
+	if tasks, err := NewManager(tasksPath).LoadAll(); err != nil || len(tasks) != 0 {
+		t.Fatalf("expected an empty tasks file, got %+v, %v", tasks, err)
+	}
+	if _, err := os.Stat(tasksPath + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("expected temp file to be renamed away after creation, stat err: %v", err)
+	}
+	if err := mgr.AddTask(NewTask("task-1", "Durable", "")); err != nil {
+		t.Fatalf("failed to add task: %v", err)
+	}
+
+	tasks, err := NewManager(tasksPath).LoadAll()
+	if err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].ID != "task-1" {
+		t.Errorf("expected the synced task to be readable, got %+v", tasks)
+	}
+	if _, err := os.Stat(tasksPath + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("expected temp file to be renamed away, stat err: %v", err)
+	}
+}
+
 func TestManagerGetNextPending(t *testing.T) {
 	tmpDir := t.TempDir()
 	tasksPath := filepath.Join(tmpDir, "tasks.json")
@@ -84,8 +120,8 @@ func TestManagerClaimTask(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to get task: %v", err)
 	}
-	if task.Status != StatusInProgress {
-		t.Errorf("expected status in_progress, got %s", task.Status)
+	if task.Status != StatusQueued {
+		t.Errorf("expected status queued, got %s", task.Status)
 	}
 	if task.WorkerID != 1 {
 		t.Errorf("expected worker_id=1, got %d", task.WorkerID)
@@ -97,6 +133,326 @@ func TestManagerClaimTask(t *testing.T) {
 	}
 }
 
+func TestManagerClaimNext(t *testing.T) {
+	tmpDir := t.TempDir()
+	tasksPath := filepath.Join(tmpDir, "tasks.json")
+
+	mgr := NewManager(tasksPath)
+
+	tasks := make([]Task, 20)
+	for i := range tasks {
+		tasks[i] = *NewTask(fmt.Sprintf("task-%d", i), "Task", "Description")
+		tasks[i].Priority = i % 3
+	}
+	if err := mgr.SaveAll(tasks); err != nil {
+		t.Fatalf("failed to save tasks: %v", err)
+	}
+
+	// Highest priority goes first
+	first, err := mgr.ClaimNext(1)
+	if err != nil {
+		t.Fatalf("failed to claim task: %v", err)
+	}
+	if first == nil || first.Priority != 2 || first.Status != StatusQueued {
+		t.Fatalf("expected a queued priority-2 task, got %+v", first)
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	claimed := map[string]int{first.ID: 1}
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(workerID int) {
+			defer wg.Done()
+			for {
+				next, err := mgr.ClaimNext(workerID)
+				if err != nil {
+					t.Errorf("ClaimNext failed: %v", err)
+					return
+				}
+				if next == nil {
+					return
+				}
+				mu.Lock()
+				claimed[next.ID]++
+				mu.Unlock()
+			}
+		}(w + 2)
+	}
+	wg.Wait()
+
+	if len(claimed) != len(tasks) {
+		t.Errorf("expected %d tasks claimed, got %d", len(tasks), len(claimed))
+	}
+	for id, n := range claimed {
+		if n != 1 {
+			t.Errorf("task %s claimed %d times", id, n)
+		}
+	}
+
+	if next, err := mgr.ClaimNext(1); err != nil || next != nil {
+		t.Errorf("expected nothing left to claim, got %+v (err %v)", next, err)
+	}
+}
+
+func TestManagerClaimNextWaitsForRetryAfter(t *testing.T) {
+	tmpDir := t.TempDir()
+	tasksPath := filepath.Join(tmpDir, "tasks.json")
+
+	mgr := NewManager(tasksPath)
+
+	backingOff := NewTask("task-1", "Backing off", "")
+	backingOff.Priority = 5
+	backingOff.RetryAfter = time.Now().Add(time.Hour)
+	due := NewTask("task-2", "Retry due", "")
+	due.RetryAfter = time.Now().Add(-time.Second)
+
+	if err := mgr.SaveAll([]Task{*backingOff, *due}); err != nil {
+		t.Fatalf("failed to save tasks: %v", err)
+	}
+
+	next, err := mgr.ClaimNext(1)
+	if err != nil {
+		t.Fatalf("failed to claim task: %v", err)
+	}
+	if next == nil || next.ID != "task-2" {
+		t.Fatalf("expected the due task to be claimed, got %+v", next)
+	}
+
+	if next, err := mgr.ClaimNext(1); err != nil || next != nil {
+		t.Errorf("expected the backing-off task to be held back, got %+v (err %v)", next, err)
+	}
+
+	backingOff.ResetForRetry()
+	if !backingOff.RetryAfter.IsZero() {
+		t.Error("expected a manual retry to clear the backoff")
+	}
+}
+
+func TestManagerClaimNextPriorityAging(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		ageStep time.Duration
+		want    string
+	}{
+		{"aging off", 0, "task-fresh"},
+		{"aging on", 10 * time.Minute, "task-old"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mgr := NewManager(filepath.Join(t.TempDir(), "tasks.json")).WithPriorityAging(tc.ageStep)
+
+			// Waiting 35 minutes lifts the low task three steps, past the
+			// medium one created just now
+			old := NewTask("task-old", "Old low priority", "")
+			old.Priority = 1
+			old.CreatedAt = time.Now().Add(-35 * time.Minute)
+			fresh := NewTask("task-fresh", "Fresh medium priority", "")
+			fresh.Priority = 3
+
+			if err := mgr.SaveAll([]Task{*old, *fresh}); err != nil {
+				t.Fatalf("failed to save tasks: %v", err)
+			}
+
+			next, err := mgr.ClaimNext(1)
+			if err != nil {
+				t.Fatalf("failed to claim task: %v", err)
+			}
+			if next == nil || next.ID != tc.want {
+				t.Fatalf("expected %s to be claimed first, got %+v", tc.want, next)
+			}
+			if next.Priority != 1 && next.ID == "task-old" {
+				t.Errorf("expected aging to leave the stored priority alone, got %d", next.Priority)
+			}
+		})
+	}
+}
+
+func TestManagerClaimNextDispatchOrder(t *testing.T) {
+	now := time.Now()
+	newTask := func(id string, priority int, age time.Duration) Task {
+		task := NewTask(id, id, "")
+		task.Priority = priority
+		task.CreatedAt = now.Add(-age)
+		return *task
+	}
+	// Registry order differs from both creation and priority order
+	tasks := []Task{
+		newTask("task-middle", 1, 20*time.Minute),
+		newTask("task-newest", 2, 10*time.Minute),
+		newTask("task-oldest", 3, 30*time.Minute),
+		newTask("task-urgent", 5, 15*time.Minute),
+	}
+
+	for _, tc := range []struct {
+		order DispatchOrder
+		want  []string
+	}{
+		{"", []string{"task-urgent", "task-oldest", "task-newest", "task-middle"}},
+		{DispatchPriority, []string{"task-urgent", "task-oldest", "task-newest", "task-middle"}},
+		{DispatchFIFO, []string{"task-oldest", "task-middle", "task-urgent", "task-newest"}},
+		{DispatchLIFO, []string{"task-newest", "task-urgent", "task-middle", "task-oldest"}},
+	} {
+		t.Run(string(tc.order), func(t *testing.T) {
+			mgr := NewManager(filepath.Join(t.TempDir(), "tasks.json")).WithDispatchOrder(tc.order)
+			if err := mgr.SaveAll(tasks); err != nil {
+				t.Fatalf("failed to save tasks: %v", err)
+			}
+
+			var got []string
+			for {
+				next, err := mgr.ClaimNext(1)
+				if err != nil {
+					t.Fatalf("failed to claim task: %v", err)
+				}
+				if next == nil {
+					break
+				}
+				got = append(got, next.ID)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("expected claim order %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestManagerRejectsInvalidTasks(t *testing.T) {
+	tmpDir := t.TempDir()
+	tasksPath := filepath.Join(tmpDir, "tasks.json")
+
+	mgr := NewManager(tasksPath)
+
+	valid := NewTask("task-1", "Valid", "")
+	if err := mgr.AddTask(valid); err != nil {
+		t.Fatalf("failed to add task: %v", err)
+	}
+
+	noID := NewTask("", "No ID", "")
+	if err := mgr.AddTask(noID); !errors.Is(err, ErrInvalidTask) {
+		t.Errorf("AddTask with empty ID: expected ErrInvalidTask, got %v", err)
+	}
+
+	bad := *valid
+	bad.Status = "done"
+	if err := mgr.UpdateTask(&bad); !errors.Is(err, ErrInvalidTask) {
+		t.Errorf("UpdateTask with unknown status: expected ErrInvalidTask, got %v", err)
+	}
+
+	dup := []Task{*valid, *NewTask("task-2", "Other", ""), *valid}
+	if err := mgr.SaveAll(dup); !errors.Is(err, ErrInvalidTask) {
+		t.Errorf("SaveAll with duplicate IDs: expected ErrInvalidTask, got %v", err)
+	}
+
+	// The registry is untouched by the rejected writes
+	tasks, err := mgr.LoadAll()
+	if err != nil {
+		t.Fatalf("failed to load tasks: %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].ID != "task-1" || tasks[0].Status != StatusPending {
+		t.Errorf("expected only the valid task to be stored, got %+v", tasks)
+	}
+}
+
+func TestManagerSavesAroundStoredInvalidTasks(t *testing.T) {
+	tmpDir := t.TempDir()
+	tasksPath := filepath.Join(tmpDir, "tasks.json")
+
+	// Written by hand or by an older version: one unknown status and a
+	// duplicated ID
+	raw := `{"schema_version": 1, "tasks": [
+		{"id": "task-1", "title": "Bad status", "status": "done"},
+		{"id": "task-2", "title": "Dup", "status": "pending"},
+		{"id": "task-2", "title": "Dup again", "status": "pending"},
+		{"id": "task-3", "title": "Fine", "status": "pending"}
+	]}`
+	if err := os.WriteFile(tasksPath, []byte(raw), 0644); err != nil {
+		t.Fatalf("failed to write tasks file: %v", err)
+	}
+	mgr := NewManager(tasksPath)
+
+	// Unrelated edits are not blocked by the rows already on disk
+	if err := mgr.UpdateStatus("task-3", StatusQueued, ""); err != nil {
+		t.Fatalf("failed to update a valid task: %v", err)
+	}
+
+	// The invalid rows themselves can be repaired or removed
+	if err := mgr.UpdateStatus("task-1", StatusPending, ""); err != nil {
+		t.Fatalf("failed to repair the invalid status: %v", err)
+	}
+	if err := mgr.DeleteTask("task-2"); err != nil {
+		t.Fatalf("failed to delete a duplicated task: %v", err)
+	}
+
+	// A changed row still has to be valid
+	if err := mgr.UpdateStatus("task-3", "finished", ""); !errors.Is(err, ErrInvalidTask) {
+		t.Errorf("expected ErrInvalidTask for a new invalid status, got %v", err)
+	}
+
+	tasks, err := mgr.LoadAll()
+	if err != nil {
+		t.Fatalf("failed to load tasks: %v", err)
+	}
+	for _, task := range tasks {
+		if err := task.Validate(); err != nil {
+			t.Errorf("expected a valid registry after the repairs, got %v", err)
+		}
+	}
+}
+
+func TestManagerSavesAroundInvalidTasksInOldSchema(t *testing.T) {
+	tmpDir := t.TempDir()
+	tasksPath := filepath.Join(tmpDir, "tasks.json")
+
+	// A file from before schema_version existed is migrated on every load
+	raw := `[
+		{"id": "task-1", "title": "Bad status", "status": "done", "created_at": "2024-01-01T00:00:00Z"},
+		{"id": "task-2", "title": "Fine", "status": "pending", "created_at": "2024-01-01T00:00:00Z"}
+	]`
+	if err := os.WriteFile(tasksPath, []byte(raw), 0644); err != nil {
+		t.Fatalf("failed to write tasks file: %v", err)
+	}
+	mgr := NewManager(tasksPath)
+
+	if err := mgr.UpdateStatus("task-2", StatusQueued, ""); err != nil {
+		t.Fatalf("expected migrated rows not to count as changed, got %v", err)
+	}
+}
+
+func TestManagerStartTask(t *testing.T) {
+	tmpDir := t.TempDir()
+	tasksPath := filepath.Join(tmpDir, "tasks.json")
+
+	mgr := NewManager(tasksPath)
+
+	task1 := NewTask("task-1", "Test Task", "Description")
+	if err := mgr.SaveAll([]Task{*task1}); err != nil {
+		t.Fatalf("failed to save tasks: %v", err)
+	}
+
+	if err := mgr.StartTask("task-1", 1); err == nil {
+		t.Error("expected error starting a task that was never queued")
+	}
+
+	if err := mgr.ClaimTask("task-1", 0); err != nil {
+		t.Fatalf("failed to claim task: %v", err)
+	}
+	if err := mgr.StartTask("task-1", 3); err != nil {
+		t.Fatalf("failed to start task: %v", err)
+	}
+
+	got, _ := mgr.GetByID("task-1")
+	if got.Status != StatusInProgress {
+		t.Errorf("expected status in_progress, got %s", got.Status)
+	}
+	if got.WorkerID != 3 || got.StartedAt.IsZero() {
+		t.Errorf("expected worker 3 with a start time, got worker %d at %v", got.WorkerID, got.StartedAt)
+	}
+
+	if err := mgr.StartTask("missing", 1); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("expected ErrTaskNotFound, got %v", err)
+	}
+}
+
 func TestManagerUpdateStatus(t *testing.T) {
 	tmpDir := t.TempDir()
 	tasksPath := filepath.Join(tmpDir, "tasks.json")
@@ -132,6 +488,56 @@ func TestManagerUpdateStatus(t *testing.T) {
 	}
 }
 
+func TestManagerModify(t *testing.T) {
+	mgr := NewManager(filepath.Join(t.TempDir(), "tasks.json"))
+	if err := mgr.AddTask(NewTask("task-1", "Test Task", "Description")); err != nil {
+		t.Fatalf("failed to add task: %v", err)
+	}
+
+	// Concurrent edits of different fields must all survive
+	var wg sync.WaitGroup
+	for i := 1; i <= 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			mgr.Modify("task-1", func(t *Task) error {
+				t.Priority += i
+				return nil
+			})
+		}()
+		go func() {
+			defer wg.Done()
+			mgr.Modify("task-1", func(t *Task) error {
+				t.Logs = append(t.Logs, LogEntry{Message: fmt.Sprint(i)})
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+
+	got, _ := mgr.GetByID("task-1")
+	if got.Priority != 55 || len(got.Logs) != 10 {
+		t.Errorf("expected every edit to be kept, got priority %d and %d logs", got.Priority, len(got.Logs))
+	}
+
+	// An error from fn leaves the task unchanged
+	refused := errors.New("refused")
+	err := mgr.Modify("task-1", func(t *Task) error {
+		t.Priority = 0
+		return refused
+	})
+	if !errors.Is(err, refused) {
+		t.Errorf("expected fn's error, got %v", err)
+	}
+	if got, _ := mgr.GetByID("task-1"); got.Priority != 55 {
+		t.Errorf("expected a refused edit not to be saved, got priority %d", got.Priority)
+	}
+
+	if err := mgr.Modify("missing", func(*Task) error { return nil }); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("expected ErrTaskNotFound, got %v", err)
+	}
+}
+
 func TestManagerRecoverInProgress(t *testing.T) {
 	tmpDir := t.TempDir()
 	tasksPath := filepath.Join(tmpDir, "tasks.json")
@@ -242,6 +648,48 @@ func TestManagerAddTask(t *testing.T) {
 	}
 }
 
+func TestManagerUpsert(t *testing.T) {
+	tmpDir := t.TempDir()
+	tasksPath := filepath.Join(tmpDir, "tasks.json")
+
+	mgr := NewManager(tasksPath)
+
+	created, err := mgr.Upsert(NewTask("task-1", "Original", "First description"))
+	if err != nil {
+		t.Fatalf("Upsert() failed: %v", err)
+	}
+	if !created {
+		t.Error("expected Upsert to create a missing task")
+	}
+
+	if err := mgr.UpdateStatus("task-1", StatusCompleted, ""); err != nil {
+		t.Fatalf("failed to update status: %v", err)
+	}
+
+	update := NewTask("task-1", "Renamed", "Second description")
+	update.Role = "backend"
+	update.Priority = 5
+	created, err = mgr.Upsert(update)
+	if err != nil {
+		t.Fatalf("Upsert() failed: %v", err)
+	}
+	if created {
+		t.Error("expected Upsert to update an existing task")
+	}
+
+	tasks, _ := mgr.LoadAll()
+	if len(tasks) != 1 {
+		t.Fatalf("expected 1 task, got %d", len(tasks))
+	}
+	got := tasks[0]
+	if got.Title != "Renamed" || got.Description != "Second description" || got.Role != "backend" || got.Priority != 5 {
+		t.Errorf("mutable fields not updated: %+v", got)
+	}
+	if got.Status != StatusCompleted {
+		t.Errorf("expected status to be preserved, got %s", got.Status)
+	}
+}
+
 func TestManagerCountByStatus(t *testing.T) {
 	tmpDir := t.TempDir()
 	tasksPath := filepath.Join(tmpDir, "tasks.json")
@@ -268,6 +716,115 @@ func TestManagerCountByStatus(t *testing.T) {
 	if counts[StatusCompleted] != 1 {
 		t.Errorf("expected 1 completed, got %d", counts[StatusCompleted])
 	}
+
+	// Version 0 tasks without a status count as pending, as after migration
+	os.WriteFile(tasksPath, []byte(`[{"id": "a", "status": "failed"}, {"id": "b"}]`), 0644)
+	counts, err = mgr.CountByStatus()
+	if err != nil {
+		t.Fatalf("failed to count v0 file: %v", err)
+	}
+	if counts[StatusPending] != 1 || counts[StatusFailed] != 1 {
+		t.Errorf("unexpected v0 counts: %v", counts)
+	}
+}
+
+func TestManagerFindByStatus(t *testing.T) {
+	tmpDir := t.TempDir()
+	tasksPath := filepath.Join(tmpDir, "tasks.json")
+
+	mgr := NewManager(tasksPath)
+
+	if found, err := mgr.FindByStatus(StatusPending); err != nil || len(found) != 0 {
+		t.Fatalf("expected no tasks for missing file, got %v (%v)", found, err)
+	}
+
+	failed := NewTask("task-1", "Failed", "")
+	failed.MarkFailed("boom")
+	pending := NewTask("task-2", "Pending", "")
+	pending.AddLog("info", PhaseDispatch, "queued", nil)
+	other := NewTask("task-3", "Also failed", "")
+	other.MarkFailed("bang")
+	if err := mgr.SaveAll([]Task{*failed, *pending, *other}); err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+
+	found, err := mgr.FindByStatus(StatusFailed)
+	if err != nil {
+		t.Fatalf("FindByStatus() failed: %v", err)
+	}
+	if len(found) != 2 || found[0].ID != "task-1" || found[1].ID != "task-3" || found[1].FailReason != "bang" {
+		t.Errorf("unexpected failed tasks: %+v", found)
+	}
+
+	found, err = mgr.FindByStatus(StatusPending)
+	if err != nil {
+		t.Fatalf("FindByStatus() failed: %v", err)
+	}
+	if len(found) != 1 || len(found[0].Logs) != 1 {
+		t.Errorf("expected the pending task with its logs, got %+v", found)
+	}
+
+	// Version 0 tasks are migrated as they are read
+	os.WriteFile(tasksPath, []byte(`[{"id": "a", "status": "failed"}, {"id": "b"}]`), 0644)
+	found, err = mgr.FindByStatus(StatusPending)
+	if err != nil {
+		t.Fatalf("FindByStatus() failed on v0 file: %v", err)
+	}
+	if len(found) != 1 || found[0].ID != "b" || found[0].CreatedAt.IsZero() {
+		t.Errorf("expected the migrated v0 task, got %+v", found)
+	}
+}
+
+func TestManagerStats(t *testing.T) {
+	tmpDir := t.TempDir()
+	tasksPath := filepath.Join(tmpDir, "tasks.json")
+
+	mgr := NewManager(tasksPath)
+
+	now := time.Now()
+	oldPending := NewTask("task-1", "Old pending", "")
+	oldPending.CreatedAt = now.Add(-2 * time.Hour)
+	newPending := NewTask("task-2", "New pending", "")
+	newPending.CreatedAt = now.Add(-5 * time.Minute)
+	running := NewTask("task-3", "Running", "")
+	running.Status = StatusInProgress
+	running.StartedAt = now.Add(-30 * time.Minute)
+	done := NewTask("task-4", "Done", "")
+	done.Status = StatusCompleted
+	// Completed tasks without run times don't count toward the average
+	quick := NewTask("task-5", "Quick", "")
+	quick.Status = StatusCompleted
+	quick.StartedAt = now.Add(-time.Hour)
+	quick.CompletedAt = quick.StartedAt.Add(10 * time.Minute)
+	slow := NewTask("task-6", "Slow", "")
+	slow.Status = StatusCompleted
+	slow.StartedAt = now.Add(-time.Hour)
+	slow.CompletedAt = slow.StartedAt.Add(20 * time.Minute)
+
+	if err := mgr.SaveAll([]Task{*oldPending, *newPending, *running, *done, *quick, *slow}); err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+
+	stats, err := mgr.Stats()
+	if err != nil {
+		t.Fatalf("Stats() failed: %v", err)
+	}
+
+	if stats.Total != 6 {
+		t.Errorf("expected total 6, got %d", stats.Total)
+	}
+	if stats.Counts[StatusPending] != 2 || stats.Counts[StatusInProgress] != 1 || stats.Counts[StatusCompleted] != 3 {
+		t.Errorf("unexpected counts: %v", stats.Counts)
+	}
+	if stats.OldestPendingAge < 2*time.Hour || stats.OldestPendingAge > 2*time.Hour+time.Minute {
+		t.Errorf("expected oldest pending age ~2h, got %v", stats.OldestPendingAge)
+	}
+	if stats.LongestRunning < 30*time.Minute || stats.LongestRunning > 31*time.Minute {
+		t.Errorf("expected longest running ~30m, got %v", stats.LongestRunning)
+	}
+	if stats.AvgCompletedDuration != 15*time.Minute {
+		t.Errorf("expected average completed duration 15m, got %v", stats.AvgCompletedDuration)
+	}
 }
 
 func TestManagerEnsureFile(t *testing.T) {
@@ -287,3 +844,627 @@ func TestManagerEnsureFile(t *testing.T) {
 		t.Errorf("tasks file not created: %v", err)
 	}
 }
+
+func TestManagerMoveBefore(t *testing.T) {
+	tmpDir := t.TempDir()
+	tasksPath := filepath.Join(tmpDir, "tasks.json")
+
+	mgr := NewManager(tasksPath)
+
+	task1 := NewTask("task-1", "First", "")
+	task2 := NewTask("task-2", "Second", "")
+	task3 := NewTask("task-3", "Third", "")
+
+	if err := mgr.SaveAll([]Task{*task1, *task2, *task3}); err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+
+	if err := mgr.MoveBefore("task-3", "task-1"); err != nil {
+		t.Fatalf("failed to move task: %v", err)
+	}
+
+	tasks, _ := mgr.LoadAll()
+	got := []string{tasks[0].ID, tasks[1].ID, tasks[2].ID}
+	want := []string{"task-3", "task-1", "task-2"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected order %v, got %v", want, got)
+		}
+	}
+
+	// Equal priority: file order decides dispatch
+	next, _ := mgr.GetNextPending()
+	if next == nil || next.ID != "task-3" {
+		t.Errorf("expected task-3 to be dispatched first, got %v", next)
+	}
+
+	if err := mgr.MoveBefore("missing", "task-1"); err == nil {
+		t.Error("expected error for unknown task")
+	}
+}
+
+func TestManagerMoveToTop(t *testing.T) {
+	tmpDir := t.TempDir()
+	tasksPath := filepath.Join(tmpDir, "tasks.json")
+
+	mgr := NewManager(tasksPath)
+
+	task1 := NewTask("task-1", "First", "")
+	task2 := NewTask("task-2", "Second", "")
+	task3 := NewTask("task-3", "Third", "")
+
+	if err := mgr.SaveAll([]Task{*task1, *task2, *task3}); err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+
+	if err := mgr.MoveToTop("task-2"); err != nil {
+		t.Fatalf("failed to move task: %v", err)
+	}
+
+	tasks, _ := mgr.LoadAll()
+	got := []string{tasks[0].ID, tasks[1].ID, tasks[2].ID}
+	want := []string{"task-2", "task-1", "task-3"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected order %v, got %v", want, got)
+		}
+	}
+}
+
+func TestManagerRecoverStuck(t *testing.T) {
+	tmpDir := t.TempDir()
+	tasksPath := filepath.Join(tmpDir, "tasks.json")
+
+	mgr := NewManager(tasksPath)
+
+	stale := NewTask("stale", "Stale", "")
+	stale.MarkInProgress(1)
+	stale.HeartbeatAt = time.Now().Add(-10 * time.Minute)
+
+	active := NewTask("active", "Active", "")
+	active.MarkInProgress(2)
+
+	queued := NewTask("queued", "Queued", "")
+	queued.MarkQueued(0)
+
+	if err := mgr.SaveAll([]Task{*stale, *active, *queued}); err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+
+	if err := mgr.Heartbeat("active"); err != nil {
+		t.Fatalf("failed to heartbeat: %v", err)
+	}
+
+	count, err := mgr.RecoverStuck(time.Minute)
+	if err != nil {
+		t.Fatalf("failed to recover: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("expected 2 recovered, got %d", count)
+	}
+
+	got, _ := mgr.GetByID("stale")
+	if got.Status != StatusPending {
+		t.Errorf("expected stale task to be pending, got %s", got.Status)
+	}
+	got, _ = mgr.GetByID("active")
+	if got.Status != StatusInProgress {
+		t.Errorf("expected active task to stay in_progress, got %s", got.Status)
+	}
+}
+
+func TestManagerForceReset(t *testing.T) {
+	tmpDir := t.TempDir()
+	tasksPath := filepath.Join(tmpDir, "tasks.json")
+
+	mgr := NewManager(tasksPath)
+
+	stuck := NewTask("task-1", "Stuck", "")
+	stuck.MarkInProgress(3)
+	stuck.HeartbeatAt = time.Now()
+	done := NewTask("task-2", "Done", "")
+	done.Status = StatusCompleted
+
+	if err := mgr.SaveAll([]Task{*stuck, *done}); err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+
+	if err := mgr.ForceReset("task-1"); err != nil {
+		t.Fatalf("ForceReset() failed: %v", err)
+	}
+
+	got, _ := mgr.GetByID("task-1")
+	if got.Status != StatusPending || got.WorkerID != 0 {
+		t.Errorf("expected pending with no worker, got %s (worker %d)", got.Status, got.WorkerID)
+	}
+	if len(got.Logs) == 0 {
+		t.Error("expected a log note for the reset")
+	}
+
+	if err := mgr.ForceReset("task-2"); err == nil {
+		t.Error("expected error resetting a completed task")
+	}
+
+	if err := mgr.ForceReset("missing"); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("expected ErrTaskNotFound, got %v", err)
+	}
+}
+
+func TestManagerSetAgentCmd(t *testing.T) {
+	tmpDir := t.TempDir()
+	tasksPath := filepath.Join(tmpDir, "tasks.json")
+
+	mgr := NewManager(tasksPath)
+	if err := mgr.AddTask(NewTask("task-1", "Task", "")); err != nil {
+		t.Fatalf("failed to add: %v", err)
+	}
+
+	if err := mgr.SetAgentCmd("task-1", []string{"opencode", "run"}); err != nil {
+		t.Fatalf("SetAgentCmd() failed: %v", err)
+	}
+
+	got, _ := mgr.GetByID("task-1")
+	if len(got.AgentCmd) != 2 || got.AgentCmd[1] != "run" {
+		t.Errorf("unexpected agent command: %v", got.AgentCmd)
+	}
+
+	if err := mgr.SetAgentCmd("missing", nil); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("expected ErrTaskNotFound, got %v", err)
+	}
+}
+
+func TestManagerChildren(t *testing.T) {
+	tmpDir := t.TempDir()
+	tasksPath := filepath.Join(tmpDir, "tasks.json")
+
+	mgr := NewManager(tasksPath)
+
+	parent := NewTask("plan-1", "Plan", "")
+	sub1 := NewTask("task-1", "First", "")
+	sub1.ParentID = "plan-1"
+	other := NewTask("task-2", "Unrelated", "")
+	sub2 := NewTask("task-3", "Second", "")
+	sub2.ParentID = "plan-1"
+
+	if err := mgr.SaveAll([]Task{*parent, *sub1, *other, *sub2}); err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+
+	children, err := mgr.Children("plan-1")
+	if err != nil {
+		t.Fatalf("Children() failed: %v", err)
+	}
+	if len(children) != 2 || children[0].ID != "task-1" || children[1].ID != "task-3" {
+		t.Errorf("unexpected children: %+v", children)
+	}
+
+	none, err := mgr.Children("task-2")
+	if err != nil {
+		t.Fatalf("Children() failed: %v", err)
+	}
+	if len(none) != 0 {
+		t.Errorf("expected no children, got %d", len(none))
+	}
+}
+
+func TestManagerFindByWorker(t *testing.T) {
+	tmpDir := t.TempDir()
+	tasksPath := filepath.Join(tmpDir, "tasks.json")
+
+	mgr := NewManager(tasksPath)
+
+	done := NewTask("task-1", "Done by 1", "")
+	done.MarkInProgress(1)
+	done.MarkCompleted()
+	running := NewTask("task-2", "Running on 2", "")
+	running.MarkInProgress(2)
+	current := NewTask("task-3", "Running on 1", "")
+	current.MarkInProgress(1)
+	pending := NewTask("task-4", "Pending", "")
+
+	if err := mgr.SaveAll([]Task{*done, *running, *current, *pending}); err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+
+	first, err := mgr.FindByWorker(1)
+	if err != nil {
+		t.Fatalf("FindByWorker() failed: %v", err)
+	}
+	if len(first) != 2 || first[0].ID != "task-1" || first[1].ID != "task-3" {
+		t.Errorf("unexpected tasks for worker 1: %+v", first)
+	}
+
+	second, err := mgr.FindByWorker(2)
+	if err != nil {
+		t.Fatalf("FindByWorker() failed: %v", err)
+	}
+	if len(second) != 1 || second[0].ID != "task-2" {
+		t.Errorf("unexpected tasks for worker 2: %+v", second)
+	}
+
+	none, err := mgr.FindByWorker(3)
+	if err != nil {
+		t.Fatalf("FindByWorker() failed: %v", err)
+	}
+	if len(none) != 0 {
+		t.Errorf("expected no tasks for worker 3, got %d", len(none))
+	}
+}
+
+func TestManagerSetExtraInstructions(t *testing.T) {
+	tmpDir := t.TempDir()
+	tasksPath := filepath.Join(tmpDir, "tasks.json")
+
+	mgr := NewManager(tasksPath)
+	if err := mgr.AddTask(NewTask("task-1", "Task", "")); err != nil {
+		t.Fatalf("failed to add: %v", err)
+	}
+
+	if err := mgr.SetExtraInstructions("task-1", "also handle nulls"); err != nil {
+		t.Fatalf("SetExtraInstructions() failed: %v", err)
+	}
+	got, _ := mgr.GetByID("task-1")
+	if got.ExtraInstructions != "also handle nulls" {
+		t.Errorf("unexpected extra instructions: %q", got.ExtraInstructions)
+	}
+
+	if err := mgr.SetExtraInstructions("task-1", ""); err != nil {
+		t.Fatalf("SetExtraInstructions() failed: %v", err)
+	}
+	got, _ = mgr.GetByID("task-1")
+	if got.ExtraInstructions != "" {
+		t.Errorf("expected extra instructions to be cleared, got %q", got.ExtraInstructions)
+	}
+
+	if err := mgr.SetExtraInstructions("missing", ""); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("expected ErrTaskNotFound, got %v", err)
+	}
+}
+
+func TestManagerActiveCount(t *testing.T) {
+	tmpDir := t.TempDir()
+	tasksPath := filepath.Join(tmpDir, "tasks.json")
+
+	mgr := NewManager(tasksPath)
+
+	// Missing file counts as empty
+	if n, err := mgr.ActiveCount(); err != nil || n != 0 {
+		t.Fatalf("expected 0 active for missing file, got %d (%v)", n, err)
+	}
+
+	statuses := []Status{StatusPending, StatusQueued, StatusInProgress, StatusReviewing, StatusCompleted, StatusFailed}
+	var tasks []Task
+	for i, s := range statuses {
+		tk := NewTask(fmt.Sprintf("task-%d", i), "Task", "")
+		tk.Status = s
+		tk.AddLog("info", "", "some log", nil)
+		tasks = append(tasks, *tk)
+	}
+	if err := mgr.SaveAll(tasks); err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+
+	n, err := mgr.ActiveCount()
+	if err != nil {
+		t.Fatalf("ActiveCount() failed: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("expected 3 active tasks, got %d", n)
+	}
+
+	// Version 0 files are a bare array
+	os.WriteFile(tasksPath, []byte(`[{"id": "a", "status": "in_progress"}, {"id": "b"}]`), 0644)
+	n, err = mgr.ActiveCount()
+	if err != nil {
+		t.Fatalf("ActiveCount() failed on v0 file: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 active task in v0 file, got %d", n)
+	}
+}
+
+func TestManagerSearch(t *testing.T) {
+	tmpDir := t.TempDir()
+	tasksPath := filepath.Join(tmpDir, "tasks.json")
+
+	mgr := NewManager(tasksPath)
+
+	descOnly := NewTask("task-1", "Add login page", "Store the session in a Redis cache")
+	both := NewTask("task-2", "Redis connection pool", "Reuse redis clients")
+	unrelated := NewTask("task-3", "Write docs", "Document the CLI")
+	titleOnly := NewTask("task-4", "Tune REDIS eviction", "")
+
+	if err := mgr.SaveAll([]Task{*descOnly, *both, *unrelated, *titleOnly}); err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+
+	found, err := mgr.Search("redis")
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+	var ids []string
+	for _, f := range found {
+		ids = append(ids, f.ID)
+	}
+	want := []string{"task-2", "task-4", "task-1"}
+	if strings.Join(ids, ",") != strings.Join(want, ",") {
+		t.Errorf("expected title matches first %v, got %v", want, ids)
+	}
+
+	found, err = mgr.Search("  DOCUMENT ")
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+	if len(found) != 1 || found[0].ID != "task-3" {
+		t.Errorf("expected description match on task-3, got %+v", found)
+	}
+
+	found, err = mgr.Search("nothing matches this")
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+	if len(found) != 0 {
+		t.Errorf("expected no matches, got %d", len(found))
+	}
+
+	found, err = mgr.Search("")
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+	if len(found) != 4 {
+		t.Errorf("expected empty query to match all tasks, got %d", len(found))
+	}
+}
+
+func TestManagerClone(t *testing.T) {
+	tmpDir := t.TempDir()
+	tasksPath := filepath.Join(tmpDir, "tasks.json")
+
+	mgr := NewManager(tasksPath)
+
+	src := NewTask("task-1", "Add login", "Use OAuth")
+	src.Role = "backend"
+	src.Priority = 2
+	src.ContextFiles = []string{"auth.go"}
+	src.MarkInProgress(3)
+	src.AddLog("info", PhaseDispatch, "started", nil)
+	src.IncrementRetry()
+	src.MarkFailed("review failed")
+	if err := mgr.AddTask(src); err != nil {
+		t.Fatalf("failed to add task: %v", err)
+	}
+
+	clone, err := mgr.Clone("task-1")
+	if err != nil {
+		t.Fatalf("Clone() failed: %v", err)
+	}
+	if clone.ID == src.ID {
+		t.Fatal("expected the clone to get a fresh ID")
+	}
+	if clone.Title != src.Title || clone.Description != src.Description || clone.Role != "backend" || clone.Priority != 2 {
+		t.Errorf("expected the task definition to be copied, got %+v", clone)
+	}
+	if clone.Status != StatusPending || clone.WorkerID != 0 || clone.RetryCount != 0 || clone.FailReason != "" {
+		t.Errorf("expected a pending clone without runtime state, got %+v", clone)
+	}
+	if !clone.StartedAt.IsZero() || !clone.CompletedAt.IsZero() || len(clone.Logs) != 0 {
+		t.Errorf("expected timestamps and logs to be cleared, got %+v", clone)
+	}
+
+	// Editing the clone leaves the original alone
+	clone.ContextFiles[0] = "session.go"
+	clone.Title = "Add login with SSO"
+	if err := mgr.UpdateTask(clone); err != nil {
+		t.Fatalf("failed to update clone: %v", err)
+	}
+	original, err := mgr.GetByID("task-1")
+	if err != nil {
+		t.Fatalf("failed to get original: %v", err)
+	}
+	if original.Title != "Add login" || original.ContextFiles[0] != "auth.go" || original.Status != StatusFailed {
+		t.Errorf("expected the original to be unchanged, got %+v", original)
+	}
+
+	if _, err := mgr.Clone("missing"); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("expected ErrTaskNotFound for a missing task, got %v", err)
+	}
+}
+
+// writeLargeTasksFile saves n tasks with a few log entries each, cycling
+// through the statuses.
+func writeLargeTasksFile(b *testing.B, n int) *Manager {
+	b.Helper()
+	mgr := NewManager(filepath.Join(b.TempDir(), "tasks.json"))
+
+	statuses := []Status{StatusPending, StatusInProgress, StatusCompleted, StatusFailed}
+	tasks := make([]Task, n)
+	for i := range tasks {
+		tk := NewTask(fmt.Sprintf("task-%d", i), "Benchmark task", "Some description of the work")
+		tk.Status = statuses[i%len(statuses)]
+		for j := 0; j < 3; j++ {
+			tk.AddLog("info", PhaseAwaitingResponse, "agent output line", nil)
+		}
+		tasks[i] = *tk
+	}
+	if err := mgr.SaveAll(tasks); err != nil {
+		b.Fatalf("failed to save: %v", err)
+	}
+	return mgr
+}
+
+func BenchmarkCountByStatus10k(b *testing.B) {
+	mgr := writeLargeTasksFile(b, 10000)
+
+	b.Run("load", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			tasks, err := mgr.LoadAll()
+			if err != nil {
+				b.Fatal(err)
+			}
+			counts := make(map[Status]int)
+			for _, t := range tasks {
+				counts[t.Status]++
+			}
+		}
+	})
+	b.Run("stream", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := mgr.CountByStatus(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkFindByStatus10k(b *testing.B) {
+	mgr := writeLargeTasksFile(b, 10000)
+
+	b.Run("load", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			tasks, err := mgr.LoadAll()
+			if err != nil {
+				b.Fatal(err)
+			}
+			var found []Task
+			for _, t := range tasks {
+				if t.Status == StatusFailed {
+					found = append(found, t)
+				}
+			}
+		}
+	})
+	b.Run("stream", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := mgr.FindByStatus(StatusFailed); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestManagerOnTransition(t *testing.T) {
+	tmpDir := t.TempDir()
+	mgr := NewManager(filepath.Join(tmpDir, "tasks.json"))
+
+	for _, id := range []string{"task-1", "task-2"} {
+		if err := mgr.AddTask(NewTask(id, "Task "+id, "")); err != nil {
+			t.Fatalf("failed to add task: %v", err)
+		}
+	}
+
+	type transition struct {
+		id       string
+		from, to Status
+		reason   string
+	}
+	var got []transition
+	mgr.OnTransition(func(id string, from, to Status, reason string) {
+		got = append(got, transition{id, from, to, reason})
+	})
+
+	if err := mgr.ClaimTask("task-1", 1); err != nil {
+		t.Fatalf("ClaimTask() failed: %v", err)
+	}
+	if err := mgr.UpdateStatus("task-1", StatusFailed, "agent crashed"); err != nil {
+		t.Fatalf("UpdateStatus() failed: %v", err)
+	}
+	if _, err := mgr.ClaimNext(2); err != nil {
+		t.Fatalf("ClaimNext() failed: %v", err)
+	}
+
+	// Failed writes and unchanged statuses are not reported
+	if err := mgr.ClaimTask("task-1", 1); err == nil {
+		t.Fatal("expected claiming a failed task to fail")
+	}
+	if err := mgr.UpdateStatus("task-2", StatusQueued, ""); err != nil {
+		t.Fatalf("UpdateStatus() failed: %v", err)
+	}
+
+	// Modify reports the status it leaves, and only when it changed
+	modify := func(status Status, reason string) {
+		t.Helper()
+		err := mgr.Modify("task-2", func(task *Task) error {
+			task.SetStatus(status, reason)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Modify() failed: %v", err)
+		}
+	}
+	modify(StatusQueued, "")
+	modify(StatusBlocked, "needs a decision")
+	modify(StatusPending, "")
+
+	want := []transition{
+		{"task-1", StatusPending, StatusQueued, ""},
+		{"task-1", StatusQueued, StatusFailed, "agent crashed"},
+		{"task-2", StatusPending, StatusQueued, ""},
+		{"task-2", StatusQueued, StatusBlocked, "needs a decision"},
+		{"task-2", StatusBlocked, StatusPending, ""},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("unexpected transitions:\n got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestManagerOnTransitionFromEverySave(t *testing.T) {
+	tmpDir := t.TempDir()
+	mgr := NewManager(filepath.Join(tmpDir, "tasks.json"))
+
+	for _, id := range []string{"task-1", "task-2", "task-3"} {
+		if err := mgr.AddTask(NewTask(id, "Task "+id, "")); err != nil {
+			t.Fatalf("failed to add task: %v", err)
+		}
+	}
+
+	type transition struct {
+		id       string
+		from, to Status
+	}
+	var got []transition
+	mgr.OnTransition(func(id string, from, to Status, reason string) {
+		got = append(got, transition{id, from, to})
+	})
+
+	for _, id := range []string{"task-1", "task-2", "task-3"} {
+		if err := mgr.ClaimTask(id, 1); err != nil {
+			t.Fatalf("ClaimTask() failed: %v", err)
+		}
+		if err := mgr.StartTask(id, 1); err != nil {
+			t.Fatalf("StartTask() failed: %v", err)
+		}
+	}
+	started := transition{"task-3", StatusQueued, StatusInProgress}
+	if len(got) != 6 || got[5] != started {
+		t.Fatalf("expected each claim and start reported, ending with %+v, got %+v", started, got)
+	}
+	got = got[:0]
+
+	if err := mgr.ForceReset("task-1"); err != nil {
+		t.Fatalf("ForceReset() failed: %v", err)
+	}
+	task2, err := mgr.GetByID("task-2")
+	if err != nil {
+		t.Fatalf("GetByID() failed: %v", err)
+	}
+	task2.SetStatus(StatusCompleted, "")
+	if err := mgr.UpdateTask(task2); err != nil {
+		t.Fatalf("UpdateTask() failed: %v", err)
+	}
+	if n, err := mgr.RecoverInProgress(); err != nil || n != 1 {
+		t.Fatalf("RecoverInProgress() = %d, %v; want 1 recovered", n, err)
+	}
+
+	want := []transition{
+		{"task-1", StatusInProgress, StatusPending},
+		{"task-2", StatusInProgress, StatusCompleted},
+		{"task-3", StatusInProgress, StatusPending},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("unexpected transitions:\n got %+v\nwant %+v", got, want)
+	}
+}
@@ -0,0 +1,98 @@
+package task
+
+import (
+	"fmt"
+	"time"
+)
+
+// HoldPlan stores planned subtasks on the task with the given ID, as its
+// children, until AcceptPlan adds them or TakePlan claims them. They are
+// kept in the tasks file, so a held plan survives a restart.
+func (m *Manager) HoldPlan(taskID string, planned []*Task) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tasks, old, err := m.loadForWriteLocked()
+	if err != nil {
+		return err
+	}
+
+	idx := indexOf(tasks, taskID)
+	if idx < 0 {
+		return fmt.Errorf("%w: %s", ErrTaskNotFound, taskID)
+	}
+	for _, nt := range planned {
+		held := *nt
+		held.ParentID = taskID
+		tasks[idx].HeldPlan = append(tasks[idx].HeldPlan, held)
+	}
+	tasks[idx].UpdatedAt = time.Now()
+	return m.saveLocked(old, tasks)
+}
+
+// TakePlan removes and returns the plan held on the task with the given ID.
+// Returns nil if the task holds no plan.
+func (m *Manager) TakePlan(taskID string) ([]*Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tasks, old, err := m.loadForWriteLocked()
+	if err != nil {
+		return nil, err
+	}
+
+	idx := indexOf(tasks, taskID)
+	if idx < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrTaskNotFound, taskID)
+	}
+	if len(tasks[idx].HeldPlan) == 0 {
+		return nil, nil
+	}
+
+	planned := make([]*Task, len(tasks[idx].HeldPlan))
+	for i := range tasks[idx].HeldPlan {
+		planned[i] = &tasks[idx].HeldPlan[i]
+	}
+	tasks[idx].HeldPlan = nil
+	tasks[idx].UpdatedAt = time.Now()
+	if err := m.saveLocked(old, tasks); err != nil {
+		return nil, err
+	}
+	return planned, nil
+}
+
+// AcceptPlan adds the plan held on the task with the given ID to the
+// registry, giving each subtask a fresh ID like Create, and clears it in the
+// same save: either every subtask is added or the plan stays held. Returns
+// the added subtasks, or nil if the task holds no plan.
+func (m *Manager) AcceptPlan(taskID string) ([]*Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tasks, old, err := m.loadForWriteLocked()
+	if err != nil {
+		return nil, err
+	}
+
+	idx := indexOf(tasks, taskID)
+	if idx < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrTaskNotFound, taskID)
+	}
+	held := tasks[idx].HeldPlan
+	if len(held) == 0 {
+		return nil, nil
+	}
+	tasks[idx].HeldPlan = nil
+	tasks[idx].UpdatedAt = time.Now()
+
+	planned := make([]*Task, len(held))
+	for i := range held {
+		planned[i] = &held[i]
+		planned[i].ID = m.newIDLocked(tasks, &old.seq)
+		tasks = append(tasks, *planned[i])
+	}
+	if err := m.saveLocked(old, tasks); err != nil {
+		return nil, err
+	}
+	return planned, nil
+}
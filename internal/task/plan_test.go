@@ -0,0 +1,84 @@
+package task
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestManagerHoldAndTakePlan(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	m := NewManager(path)
+	if err := m.AddTask(NewTask("plan-1", "Plan the feature", "")); err != nil {
+		t.Fatalf("failed to add task: %v", err)
+	}
+
+	planned := []*Task{NewTask("", "Build it", ""), NewTask("", "Test it", "")}
+	if err := m.HoldPlan("plan-1", planned); err != nil {
+		t.Fatalf("HoldPlan failed: %v", err)
+	}
+
+	// A fresh manager stands in for a restart
+	m = NewManager(path)
+	got, err := m.TakePlan("plan-1")
+	if err != nil {
+		t.Fatalf("TakePlan failed: %v", err)
+	}
+	if len(got) != 2 || got[0].Title != "Build it" || got[1].Title != "Test it" {
+		t.Fatalf("expected the held subtasks back, got %+v", got)
+	}
+
+	// Taking a plan clears it
+	if again, err := m.TakePlan("plan-1"); err != nil || again != nil {
+		t.Errorf("expected no plan after taking it, got %v, %v", again, err)
+	}
+	if parent, _ := m.GetByID("plan-1"); len(parent.HeldPlan) != 0 {
+		t.Errorf("expected the held plan to be removed from the file, got %d tasks", len(parent.HeldPlan))
+	}
+
+	if err := m.HoldPlan("missing", planned); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("expected ErrTaskNotFound, got %v", err)
+	}
+}
+
+func TestManagerAcceptPlan(t *testing.T) {
+	m := NewManager(filepath.Join(t.TempDir(), "tasks.json")).WithIDFormat("task-{seq}")
+	if err := m.AddTask(NewTask("plan-1", "Plan the feature", "")); err != nil {
+		t.Fatalf("failed to add task: %v", err)
+	}
+
+	// A subtask the registry rejects fails the whole accept
+	bad := NewTask("", "Test it", "")
+	bad.Status = "bogus"
+	if err := m.HoldPlan("plan-1", []*Task{NewTask("", "Build it", ""), bad}); err != nil {
+		t.Fatalf("HoldPlan failed: %v", err)
+	}
+	if _, err := m.AcceptPlan("plan-1"); !errors.Is(err, ErrInvalidTask) {
+		t.Fatalf("expected ErrInvalidTask, got %v", err)
+	}
+	tasks, _ := m.LoadAll()
+	if len(tasks) != 1 || len(tasks[0].HeldPlan) != 2 {
+		t.Fatalf("expected the plan to stay held with nothing added, got %d tasks and %d held", len(tasks), len(tasks[0].HeldPlan))
+	}
+
+	if _, err := m.TakePlan("plan-1"); err != nil {
+		t.Fatalf("TakePlan failed: %v", err)
+	}
+	if err := m.HoldPlan("plan-1", []*Task{NewTask("", "Build it", ""), NewTask("", "Test it", "")}); err != nil {
+		t.Fatalf("HoldPlan failed: %v", err)
+	}
+	added, err := m.AcceptPlan("plan-1")
+	if err != nil {
+		t.Fatalf("AcceptPlan failed: %v", err)
+	}
+	if len(added) != 2 || added[0].ID != "task-1" || added[1].ID != "task-2" {
+		t.Fatalf("expected both subtasks added with fresh IDs, got %+v", added)
+	}
+	tasks, _ = m.LoadAll()
+	if len(tasks) != 3 || len(tasks[0].HeldPlan) != 0 || tasks[1].ParentID != "plan-1" {
+		t.Errorf("expected the subtasks added as children and the plan cleared, got %+v", tasks)
+	}
+	if again, err := m.AcceptPlan("plan-1"); err != nil || again != nil {
+		t.Errorf("expected no plan after accepting it, got %v, %v", again, err)
+	}
+}
@@ -0,0 +1,214 @@
+package task
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// CurrentSchemaVersion is the tasks file layout written by this build.
+//
+//	0: bare JSON array of tasks
+//	1: object with schema_version and tasks
+const CurrentSchemaVersion = 1
+
+// taskFile is the on-disk layout of the tasks file from schema version 1 on.
+type taskFile struct {
+	SchemaVersion int    `json:"schema_version"`
+	LastSeq       int    `json:"last_seq,omitempty"` // last {seq} ID number handed out
+	Tasks         []Task `json:"tasks"`
+}
+
+// migrations[v] upgrades tasks from schema version v to v+1.
+var migrations = []func(tasks []Task){
+	migrateV0,
+}
+
+// decodeTasks parses the tasks file. Version 0 files, a bare array, come
+// back with SchemaVersion 0 and no sequence counter.
+func decodeTasks(data []byte) (taskFile, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return taskFile{SchemaVersion: CurrentSchemaVersion, Tasks: []Task{}}, nil
+	}
+
+	// Version 0 files are a bare array
+	if trimmed[0] == '[' {
+		var tasks []Task
+		if err := json.Unmarshal(trimmed, &tasks); err != nil {
+			return taskFile{}, err
+		}
+		return taskFile{Tasks: tasks}, nil
+	}
+
+	var f taskFile
+	if err := json.Unmarshal(trimmed, &f); err != nil {
+		return taskFile{}, err
+	}
+	if f.SchemaVersion > CurrentSchemaVersion {
+		return taskFile{}, fmt.Errorf("unsupported schema version %d (max %d)", f.SchemaVersion, CurrentSchemaVersion)
+	}
+	if f.Tasks == nil {
+		f.Tasks = []Task{}
+	}
+	return f, nil
+}
+
+// scanTasks streams a tasks file of any schema version and calls fn with
+// each task decoded into T, either Task or a struct holding just the fields
+// the caller needs, together with the file's schema version so callers can
+// apply migrations. Only one task is held in memory at a time. An error
+// from fn stops the scan.
+func scanTasks[T any](r io.Reader, fn func(t *T, version int) error) error {
+	dec := json.NewDecoder(r)
+
+	tok, err := dec.Token()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Version 1 and later wrap the array in an object, skip to "tasks".
+	// encodeTasks writes schema_version ahead of it.
+	version := 0
+	if tok == json.Delim('{') {
+		found := false
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			if key == "tasks" {
+				found = true
+				break
+			}
+			if key == "schema_version" {
+				if err := dec.Decode(&version); err != nil {
+					return err
+				}
+				continue
+			}
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return err
+			}
+		}
+		if !found {
+			return nil
+		}
+		if tok, err = dec.Token(); err != nil {
+			return err
+		}
+		if tok == nil {
+			return nil // "tasks": null
+		}
+	}
+	if version > CurrentSchemaVersion {
+		return fmt.Errorf("unsupported schema version %d (max %d)", version, CurrentSchemaVersion)
+	}
+
+	if tok != json.Delim('[') {
+		return fmt.Errorf("unexpected token %v", tok)
+	}
+	for dec.More() {
+		var t T
+		if err := dec.Decode(&t); err != nil {
+			return err
+		}
+		if err := fn(&t, version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanStatuses streams a tasks file of any schema version and calls fn with
+// the status of each task, without decoding the rest of the task.
+func scanStatuses(r io.Reader, fn func(Status)) error {
+	return scanTasks(r, func(t *statusOnly, version int) error {
+		fn(t.migratedStatus(version))
+		return nil
+	})
+}
+
+// statusOnly decodes just the status of a task.
+type statusOnly struct {
+	Status Status `json:"status"`
+}
+
+// migratedStatus returns the status the task has after migration from
+// version: version 0 tasks without one are pending, as migrateV0 sets.
+func (t statusOnly) migratedStatus(version int) Status {
+	if version == 0 && t.Status == "" {
+		return StatusPending
+	}
+	return t.Status
+}
+
+// encodeTasks renders tasks in the current schema layout, recording
+// lastSeq for sequence IDs when it is set.
+func encodeTasks(tasks []Task, lastSeq int) ([]byte, error) {
+	if tasks == nil {
+		tasks = []Task{}
+	}
+	return json.MarshalIndent(taskFile{
+		SchemaVersion: CurrentSchemaVersion,
+		LastSeq:       lastSeq,
+		Tasks:         tasks,
+	}, "", "  ")
+}
+
+// migrateTasks applies every migration from version up to the current one.
+func migrateTasks(tasks []Task, version int) {
+	for v := version; v < CurrentSchemaVersion; v++ {
+		migrations[v](tasks)
+	}
+}
+
+// migrateV0 fills in fields that early tasks files could leave empty.
+func migrateV0(tasks []Task) {
+	now := time.Now()
+	for i := range tasks {
+		t := &tasks[i]
+		if t.Status == "" {
+			t.Status = StatusPending
+		}
+		if t.CreatedAt.IsZero() {
+			t.CreatedAt = now
+		}
+		if t.UpdatedAt.IsZero() {
+			t.UpdatedAt = t.CreatedAt
+		}
+	}
+}
+
+// Migrate upgrades the tasks file to the current schema version,
+// filling in defaults for fields older versions did not record.
+// Files already at the current version are left untouched.
+func (m *Manager) Migrate() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.migrateLocked()
+}
+
+// migrateLocked performs Migrate without acquiring the lock.
+func (m *Manager) migrateLocked() error {
+	f, err := m.readLocked()
+	if err != nil {
+		return err
+	}
+	if f.SchemaVersion == CurrentSchemaVersion {
+		return nil
+	}
+
+	migrateTasks(f.Tasks, f.SchemaVersion)
+	if err := m.saveLocked(storedCopy(f.Tasks, f.LastSeq), f.Tasks); err != nil {
+		return fmt.Errorf("failed to migrate tasks file from version %d: %w", f.SchemaVersion, err)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package task
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const v0TasksFile = `[
+  {
+    "id": "task-1",
+    "title": "Legacy task",
+    "description": "written before schema_version existed",
+    "role": "coder",
+    "context_files": ["main.go"],
+    "priority": 5,
+    "logs": [{"time": "2024-01-02T03:04:05Z", "level": "info", "message": "hello"}],
+    "created_at": "2024-01-02T03:04:05Z"
+  },
+  {
+    "id": "task-2",
+    "title": "Finished task",
+    "status": "completed",
+    "retry_count": 1
+  }
+]`
+
+func TestManagerMigrateV0(t *testing.T) {
+	tmpDir := t.TempDir()
+	tasksPath := filepath.Join(tmpDir, "tasks.json")
+	if err := os.WriteFile(tasksPath, []byte(v0TasksFile), 0644); err != nil {
+		t.Fatalf("failed to write v0 file: %v", err)
+	}
+
+	mgr := NewManager(tasksPath)
+
+	// EnsureFile upgrades older files automatically
+	if err := mgr.EnsureFile(); err != nil {
+		t.Fatalf("EnsureFile() failed: %v", err)
+	}
+
+	data, err := os.ReadFile(tasksPath)
+	if err != nil {
+		t.Fatalf("failed to read tasks file: %v", err)
+	}
+	var raw struct {
+		SchemaVersion int               `json:"schema_version"`
+		Tasks         []json.RawMessage `json:"tasks"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatalf("migrated file is not a versioned object: %v\n%s", err, data)
+	}
+	if raw.SchemaVersion != CurrentSchemaVersion {
+		t.Errorf("expected schema version %d, got %d", CurrentSchemaVersion, raw.SchemaVersion)
+	}
+	if len(raw.Tasks) != 2 {
+		t.Fatalf("expected 2 tasks, got %d", len(raw.Tasks))
+	}
+
+	t1, err := mgr.GetByID("task-1")
+	if err != nil {
+		t.Fatalf("failed to get task-1: %v", err)
+	}
+	if t1.Status != StatusPending {
+		t.Errorf("expected missing status to default to pending, got %q", t1.Status)
+	}
+	if t1.UpdatedAt != t1.CreatedAt {
+		t.Errorf("expected updated_at to default to created_at, got %v", t1.UpdatedAt)
+	}
+	if t1.Description != "written before schema_version existed" || t1.Role != "coder" ||
+		t1.Priority != 5 || len(t1.ContextFiles) != 1 || len(t1.Logs) != 1 {
+		t.Errorf("task-1 lost data during migration: %+v", t1)
+	}
+
+	t2, err := mgr.GetByID("task-2")
+	if err != nil {
+		t.Fatalf("failed to get task-2: %v", err)
+	}
+	if t2.Status != StatusCompleted || t2.RetryCount != 1 {
+		t.Errorf("task-2 lost data during migration: %+v", t2)
+	}
+	if t2.CreatedAt.IsZero() {
+		t.Error("expected missing created_at to be filled in")
+	}
+
+	// Migrating an up-to-date file is a no-op
+	before, _ := os.ReadFile(tasksPath)
+	if err := mgr.Migrate(); err != nil {
+		t.Fatalf("Migrate() failed: %v", err)
+	}
+	after, _ := os.ReadFile(tasksPath)
+	if string(before) != string(after) {
+		t.Error("expected Migrate() to leave a current file untouched")
+	}
+}
+
+func TestManagerRejectsNewerSchema(t *testing.T) {
+	tmpDir := t.TempDir()
+	tasksPath := filepath.Join(tmpDir, "tasks.json")
+	os.WriteFile(tasksPath, []byte(`{"schema_version": 99, "tasks": []}`), 0644)
+
+	mgr := NewManager(tasksPath)
+	err := mgr.EnsureFile()
+	if err == nil || !strings.Contains(err.Error(), "unsupported schema version") {
+		t.Errorf("expected unsupported schema error, got %v", err)
+	}
+}
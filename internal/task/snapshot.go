@@ -0,0 +1,140 @@
+package task
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Snapshot is an immutable view of the registry read in one go, so several
+// queries over it agree even while the file changes. Its methods return
+// copies and are safe for concurrent use.
+type Snapshot struct {
+	tasks   []Task
+	index   map[string]int // task ID -> position in tasks
+	takenAt time.Time
+}
+
+// Snapshot reads the whole registry once and returns it as a Snapshot.
+func (m *Manager) Snapshot() (*Snapshot, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tasks, err := m.loadAllLocked()
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int, len(tasks))
+	for i := len(tasks) - 1; i >= 0; i-- {
+		index[tasks[i].ID] = i // the first task wins, as in GetByID
+	}
+	return &Snapshot{tasks: tasks, index: index, takenAt: time.Now()}, nil
+}
+
+// TakenAt returns when the snapshot was read.
+func (s *Snapshot) TakenAt() time.Time {
+	return s.takenAt
+}
+
+// Len returns the number of tasks in the snapshot.
+func (s *Snapshot) Len() int {
+	return len(s.tasks)
+}
+
+// All returns every task in registry order.
+func (s *Snapshot) All() []Task {
+	all := make([]Task, len(s.tasks))
+	for i := range s.tasks {
+		all[i] = copyTask(&s.tasks[i])
+	}
+	return all
+}
+
+// Search returns the tasks whose title or description contains query,
+// ranked as Manager.Search does.
+func (s *Snapshot) Search(query string) []Task {
+	return searchTasks(s.All(), query)
+}
+
+// ByID returns the task with the given ID.
+func (s *Snapshot) ByID(id string) (*Task, error) {
+	i, ok := s.index[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrTaskNotFound, id)
+	}
+	t := copyTask(&s.tasks[i])
+	return &t, nil
+}
+
+// ByStatus groups the tasks by status, each group in registry order.
+func (s *Snapshot) ByStatus() map[Status][]Task {
+	groups := make(map[Status][]Task)
+	for i := range s.tasks {
+		groups[s.tasks[i].Status] = append(groups[s.tasks[i].Status], copyTask(&s.tasks[i]))
+	}
+	return groups
+}
+
+// Counts returns the number of tasks in each status.
+func (s *Snapshot) Counts() map[Status]int {
+	counts := make(map[Status]int)
+	for i := range s.tasks {
+		counts[s.tasks[i].Status]++
+	}
+	return counts
+}
+
+// ActiveCount returns the number of queued, in-progress and reviewing tasks.
+func (s *Snapshot) ActiveCount() int {
+	count := 0
+	for i := range s.tasks {
+		if s.tasks[i].Status.IsActive() {
+			count++
+		}
+	}
+	return count
+}
+
+// Stats returns per-status counts along with queue age and run time
+// figures as of when the snapshot was taken.
+func (s *Snapshot) Stats() Stats {
+	stats := Stats{
+		Counts: s.Counts(),
+		Total:  len(s.tasks),
+	}
+	var completedTime time.Duration
+	completed := 0
+	for _, t := range s.tasks {
+		switch t.Status {
+		case StatusCompleted:
+			if d := t.Duration(); d > 0 && !t.CompletedAt.IsZero() {
+				completedTime += d
+				completed++
+			}
+		case StatusPending:
+			if !t.CreatedAt.IsZero() {
+				stats.OldestPendingAge = max(stats.OldestPendingAge, s.takenAt.Sub(t.CreatedAt))
+			}
+		case StatusInProgress:
+			if !t.StartedAt.IsZero() {
+				stats.LongestRunning = max(stats.LongestRunning, s.takenAt.Sub(t.StartedAt))
+			}
+		}
+	}
+	if completed > 0 {
+		stats.AvgCompletedDuration = completedTime / time.Duration(completed)
+	}
+	return stats
+}
+
+// copyTask returns t with its slices copied, so changes to the copy don't
+// reach the snapshot. Log entry data is shared.
+func copyTask(t *Task) Task {
+	c := *t
+	c.AgentCmd = slices.Clone(t.AgentCmd)
+	c.ContextFiles = slices.Clone(t.ContextFiles)
+	c.Logs = slices.Clone(t.Logs)
+	c.HeldPlan = slices.Clone(t.HeldPlan)
+	return c
+}
@@ -0,0 +1,63 @@
+package task
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestManagerSnapshot(t *testing.T) {
+	tmpDir := t.TempDir()
+	mgr := NewManager(filepath.Join(tmpDir, "tasks.json"))
+
+	pending := NewTask("task-1", "Write docs", "")
+	pending.ContextFiles = []string{"README.md"}
+	running := NewTask("task-2", "Fix login", "")
+	running.MarkInProgress(1)
+	if err := mgr.SaveAll([]Task{*pending, *running}); err != nil {
+		t.Fatalf("failed to save tasks: %v", err)
+	}
+
+	snap, err := mgr.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot() failed: %v", err)
+	}
+
+	// Later writes don't reach the snapshot
+	if err := mgr.UpdateStatus("task-1", StatusCompleted, ""); err != nil {
+		t.Fatalf("UpdateStatus() failed: %v", err)
+	}
+	if err := mgr.DeleteTask("task-2"); err != nil {
+		t.Fatalf("DeleteTask() failed: %v", err)
+	}
+
+	if snap.Len() != 2 || snap.ActiveCount() != 1 {
+		t.Fatalf("expected 2 tasks with 1 active, got %d with %d active", snap.Len(), snap.ActiveCount())
+	}
+	groups := snap.ByStatus()
+	if len(groups[StatusPending]) != 1 || len(groups[StatusInProgress]) != 1 || len(groups[StatusCompleted]) != 0 {
+		t.Errorf("unexpected status groups: %+v", groups)
+	}
+	if counts := snap.Counts(); counts[StatusPending] != 1 || counts[StatusInProgress] != 1 {
+		t.Errorf("unexpected counts: %v", counts)
+	}
+
+	// Changing returned tasks doesn't change the snapshot either
+	got, err := snap.ByID("task-1")
+	if err != nil {
+		t.Fatalf("ByID() failed: %v", err)
+	}
+	got.Status = StatusFailed
+	got.ContextFiles[0] = "changed.md"
+	all := snap.All()
+	all[0].Title = "Changed"
+
+	again, _ := snap.ByID("task-1")
+	if again.Status != StatusPending || again.ContextFiles[0] != "README.md" || again.Title != "Write docs" {
+		t.Errorf("expected the snapshot to be unchanged, got %+v", again)
+	}
+
+	if _, err := snap.ByID("task-3"); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("expected ErrTaskNotFound, got %v", err)
+	}
+}
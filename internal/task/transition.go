@@ -0,0 +1,45 @@
+package task
+
+// TransitionFunc is called when a task moves from one status to another.
+// reason is the task's fail reason when it moves to failed or blocked, and
+// empty otherwise.
+type TransitionFunc func(id string, from, to Status, reason string)
+
+// OnTransition registers fn to be called after any save that changes the
+// status of an existing task, whichever method made it. Created and deleted
+// tasks are not transitions. Handlers run synchronously while the manager's
+// lock is held, in registration order: they must return quickly and must
+// not call back into the manager, which would deadlock. Hand the event to
+// a goroutine or channel for slow work.
+func (m *Manager) OnTransition(fn TransitionFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.transitions = append(m.transitions, fn)
+}
+
+// notifyChanges calls the registered handlers for every task whose status
+// differs between before and after (caller must hold lock).
+func (m *Manager) notifyChanges(before, after []Task) {
+	if len(m.transitions) == 0 {
+		return
+	}
+	old := make(map[string]Status, len(before))
+	for _, t := range before {
+		old[t.ID] = t.Status
+	}
+
+	for _, t := range after {
+		from, existed := old[t.ID]
+		if !existed || from == t.Status {
+			continue
+		}
+		var reason string
+		if t.Status == StatusFailed || t.Status == StatusBlocked {
+			reason = t.FailReason
+		}
+		for _, fn := range m.transitions {
+			fn(t.ID, from, t.Status, reason)
+		}
+	}
+}
@@ -2,6 +2,10 @@
 package task
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
 	"time"
 )
 
@@ -12,7 +16,11 @@ const (
 	// StatusPending indicates the task is waiting to be processed.
 	StatusPending Status = "pending"
 
-	// StatusInProgress indicates the task is currently being processed.
+	// StatusQueued indicates the task was claimed by the dispatcher and is
+	// waiting for a worker to start it.
+	StatusQueued Status = "queued"
+
+	// StatusInProgress indicates a worker is currently processing the task.
 	StatusInProgress Status = "in_progress"
 
 	// StatusReviewing indicates the task is in the review phase.
@@ -23,15 +31,43 @@ const (
 
 	// StatusFailed indicates the task failed after retries.
 	StatusFailed Status = "failed"
+
+	// StatusBlocked indicates the agent stopped because the task needs human
+	// input. Blocked tasks are not retried automatically.
+	StatusBlocked Status = "blocked"
 )
 
+// Lifecycle phases recorded in LogEntry.Phase and the per-task log file.
+const (
+	PhaseDispatch         = "dispatch"
+	PhaseAgentStart       = "agent_start"
+	PhaseAwaitingResponse = "awaiting_response"
+	PhaseReview           = "review"
+	PhaseCommit           = "commit"
+	PhaseDone             = "done"
+)
+
+// IsValid returns true if the status is one of the known task states.
+func (s Status) IsValid() bool {
+	switch s {
+	case StatusPending, StatusQueued, StatusInProgress, StatusReviewing, StatusCompleted, StatusFailed, StatusBlocked:
+		return true
+	}
+	return false
+}
+
 // IsTerminal returns true if the status is a final state.
 func (s Status) IsTerminal() bool {
-	return s == StatusCompleted || s == StatusFailed
+	return s == StatusCompleted || s == StatusFailed || s == StatusBlocked
 }
 
-// IsActive returns true if the task is currently being worked on.
+// IsActive returns true if the task is claimed or currently being worked on.
 func (s Status) IsActive() bool {
+	return s == StatusQueued || s == StatusInProgress || s == StatusReviewing
+}
+
+// IsRunning returns true if a worker has started executing the task.
+func (s Status) IsRunning() bool {
 	return s == StatusInProgress || s == StatusReviewing
 }
 
@@ -49,6 +85,12 @@ type Task struct {
 	// Role defines the agent persona (e.g., coder, qa).
 	Role string `json:"role,omitempty"`
 
+	// ParentID is the ID of the planning task that generated this one.
+	ParentID string `json:"parent_id,omitempty"`
+
+	// AgentCmd is the agent command line the worker ran for this task.
+	AgentCmd []string `json:"agent_cmd,omitempty"`
+
 	// Status is the current state of the task.
 	Status Status `json:"status"`
 
@@ -67,20 +109,46 @@ type Task struct {
 	// StartedAt is when the task started processing.
 	StartedAt time.Time `json:"started_at,omitempty"`
 
+	// HeartbeatAt is refreshed periodically by the worker processing the task.
+	HeartbeatAt time.Time `json:"heartbeat_at,omitempty"`
+
 	// CompletedAt is when the task finished (success or failure).
 	CompletedAt time.Time `json:"completed_at,omitempty"`
 
 	// FailReason contains the error message if task failed.
 	FailReason string `json:"fail_reason,omitempty"`
 
+	// CompletionReason records how the last agent run ended, such as
+	// "marker_found" or "hard_timeout".
+	CompletionReason string `json:"completion_reason,omitempty"`
+
+	// LastError keeps the fail reason of the previous attempt after a retry.
+	LastError string `json:"last_error,omitempty"`
+
+	// ExtraInstructions are appended to the prompt on retries until the
+	// task completes successfully.
+	ExtraInstructions string `json:"extra_instructions,omitempty"`
+
 	// WorkerID is the ID of the worker processing this task.
 	WorkerID int `json:"worker_id,omitempty"`
 
-	// RetryCount tracks how many review retries have been attempted.
+	// RetryCount counts every retry of the task, automatic or by hand.
 	RetryCount int `json:"retry_count,omitempty"`
 
+	// AutoRetries counts the automatic retries since the task was last
+	// retried by hand, which bounds the autopilot's retry budget.
+	AutoRetries int `json:"auto_retries,omitempty"`
+
+	// RetryAfter holds a pending task back from dispatch until this time,
+	// set when an automatic retry backs off.
+	RetryAfter time.Time `json:"retry_after,omitempty"`
+
 	// Priority allows ordering tasks (higher = more important).
 	Priority int `json:"priority,omitempty"`
+
+	// HeldPlan holds the subtasks this task's agent planned while they
+	// wait for approval. Accepting the plan adds them to the registry.
+	HeldPlan []Task `json:"held_plan,omitempty"`
 }
 
 // LogEntry represents a single log message for a task.
@@ -92,6 +160,17 @@ type LogEntry struct {
 	Data    any       `json:"data,omitempty"`
 }
 
+// NewID returns a unique task ID of the form "<prefix>-<unix nanos>-<hex>".
+// The random suffix keeps IDs unique even when generated in a tight loop
+// or by concurrent processes sharing the same tasks file.
+func NewID(prefix string) string {
+	var suffix [3]byte
+	// crypto/rand.Read never returns an error as of Go 1.24; it crashes
+	// the program instead if the system's randomness source fails.
+	rand.Read(suffix[:])
+	return fmt.Sprintf("%s-%d-%s", prefix, time.Now().UnixNano(), hex.EncodeToString(suffix[:]))
+}
+
 // NewTask creates a new task with the given ID, title, and description.
 func NewTask(id, title, description string) *Task {
 	now := time.Now()
@@ -105,6 +184,13 @@ func NewTask(id, title, description string) *Task {
 	}
 }
 
+// Copy returns a copy of the task that can be changed without affecting t.
+// Log entry data is shared.
+func (t *Task) Copy() *Task {
+	c := copyTask(t)
+	return &c
+}
+
 // AddLog appends a log entry to the task.
 func (t *Task) AddLog(level, phase, message string, data any) {
 	entry := LogEntry{
@@ -118,6 +204,13 @@ func (t *Task) AddLog(level, phase, message string, data any) {
 	t.UpdatedAt = time.Now()
 }
 
+// MarkQueued transitions the task to queued status.
+func (t *Task) MarkQueued(workerID int) {
+	t.Status = StatusQueued
+	t.WorkerID = workerID
+	t.UpdatedAt = time.Now()
+}
+
 // MarkInProgress transitions the task to in_progress status.
 func (t *Task) MarkInProgress(workerID int) {
 	t.Status = StatusInProgress
@@ -147,6 +240,27 @@ func (t *Task) MarkFailed(reason string) {
 	t.UpdatedAt = time.Now()
 }
 
+// MarkBlocked transitions the task to blocked status with a reason.
+func (t *Task) MarkBlocked(reason string) {
+	t.Status = StatusBlocked
+	t.FailReason = reason
+	t.CompletedAt = time.Now()
+	t.UpdatedAt = time.Now()
+}
+
+// SetStatus moves the task to status, recording reason as the fail reason
+// when it is not empty and the completion time when status is terminal.
+func (t *Task) SetStatus(status Status, reason string) {
+	t.Status = status
+	t.UpdatedAt = time.Now()
+	if reason != "" {
+		t.FailReason = reason
+	}
+	if status.IsTerminal() {
+		t.CompletedAt = time.Now()
+	}
+}
+
 // IncrementRetry increases the retry count and returns the new count.
 func (t *Task) IncrementRetry() int {
 	t.RetryCount++
@@ -154,17 +268,75 @@ func (t *Task) IncrementRetry() int {
 	return t.RetryCount
 }
 
+// IncrementAutoRetry counts an automatic retry and returns how many have
+// been made since the task was last retried by hand.
+func (t *Task) IncrementAutoRetry() int {
+	t.IncrementRetry()
+	t.AutoRetries++
+	return t.AutoRetries
+}
+
+// RetryByHand resets the task for a retry asked for by a user. It counts
+// as a retry and gives the autopilot a fresh retry budget.
+func (t *Task) RetryByHand() {
+	t.IncrementRetry()
+	t.AutoRetries = 0
+	t.ResetForRetry()
+}
+
 // ResetForRetry resets the task to pending status for reprocessing.
+// The retry count is kept and the current fail reason moves to LastError
+// so the next attempt can be told why the previous one failed.
 func (t *Task) ResetForRetry() {
 	t.Status = StatusPending
 	t.WorkerID = 0
-	t.RetryCount = 0
+	if t.FailReason != "" {
+		t.LastError = t.FailReason
+	}
 	t.FailReason = ""
 	t.StartedAt = time.Time{}
 	t.CompletedAt = time.Time{}
+	t.RetryAfter = time.Time{}
 	t.UpdatedAt = time.Now()
 }
 
+// ApplyRolePriority sets the priority to the role's default when the task
+// has a role and no explicit priority. Explicit priorities always win.
+func (t *Task) ApplyRolePriority(priorities map[string]int) {
+	if t.Priority != 0 || t.Role == "" {
+		return
+	}
+	if p, ok := priorities[t.Role]; ok {
+		t.Priority = p
+	}
+}
+
+// Outcome describes the task status, followed by how the last agent run
+// ended once the task is finished, e.g. "failed: hard timeout".
+func (t *Task) Outcome() string {
+	if !t.Status.IsTerminal() || t.CompletionReason == "" {
+		return string(t.Status)
+	}
+	return fmt.Sprintf("%s: %s", t.Status, t.CompletionLabel())
+}
+
+// CompletionLabel returns CompletionReason in readable form.
+func (t *Task) CompletionLabel() string {
+	return strings.ReplaceAll(t.CompletionReason, "_", " ")
+}
+
+// Validate checks that the task can be persisted: it needs an ID and a
+// known status.
+func (t *Task) Validate() error {
+	if strings.TrimSpace(t.ID) == "" {
+		return fmt.Errorf("%w: empty ID", ErrInvalidTask)
+	}
+	if !t.Status.IsValid() {
+		return fmt.Errorf("%w: task %s has unknown status %q", ErrInvalidTask, t.ID, t.Status)
+	}
+	return nil
+}
+
 // Duration returns how long the task has been/was running.
 func (t *Task) Duration() time.Duration {
 	if t.StartedAt.IsZero() {
@@ -0,0 +1,109 @@
+package task
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNewIDUnique(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		id := NewID("task")
+		if !strings.HasPrefix(id, "task-") {
+			t.Fatalf("expected prefix 'task-', got %s", id)
+		}
+		if seen[id] {
+			t.Fatalf("duplicate ID generated: %s", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestApplyRolePriority(t *testing.T) {
+	priorities := map[string]int{"architect": 10, "qa": -10}
+
+	arch := NewTask("task-1", "Design", "")
+	arch.Role = "architect"
+	arch.ApplyRolePriority(priorities)
+	if arch.Priority != 10 {
+		t.Errorf("expected role default 10, got %d", arch.Priority)
+	}
+
+	explicit := NewTask("task-2", "Urgent QA", "")
+	explicit.Role = "qa"
+	explicit.Priority = 5
+	explicit.ApplyRolePriority(priorities)
+	if explicit.Priority != 5 {
+		t.Errorf("explicit priority should win, got %d", explicit.Priority)
+	}
+
+	unknown := NewTask("task-3", "Backend", "")
+	unknown.Role = "backend"
+	unknown.ApplyRolePriority(priorities)
+	if unknown.Priority != 0 {
+		t.Errorf("unknown role should keep priority 0, got %d", unknown.Priority)
+	}
+
+	noRole := NewTask("task-4", "Misc", "")
+	noRole.ApplyRolePriority(map[string]int{"": 3})
+	if noRole.Priority != 0 {
+		t.Errorf("task without role should keep priority 0, got %d", noRole.Priority)
+	}
+}
+
+func TestRetryByHandResetsAutoRetries(t *testing.T) {
+	tk := NewTask("task-1", "Flaky", "")
+	tk.IncrementAutoRetry()
+	tk.IncrementAutoRetry()
+	tk.Status = StatusFailed
+
+	tk.RetryByHand()
+	if tk.Status != StatusPending {
+		t.Errorf("expected pending after a manual retry, got %s", tk.Status)
+	}
+	if tk.RetryCount != 3 {
+		t.Errorf("expected every retry to be counted, got %d", tk.RetryCount)
+	}
+	if tk.AutoRetries != 0 {
+		t.Errorf("expected a fresh automatic retry budget, got %d", tk.AutoRetries)
+	}
+	if got := tk.IncrementAutoRetry(); got != 1 {
+		t.Errorf("expected the first automatic retry after a manual one, got %d", got)
+	}
+}
+
+func TestTaskOutcome(t *testing.T) {
+	tk := NewTask("task-1", "Work", "")
+	tk.CompletionReason = "hard_timeout"
+	if got := tk.Outcome(); got != "pending" {
+		t.Errorf("unfinished task should show only its status, got %q", got)
+	}
+
+	tk.MarkFailed("agent went quiet")
+	if got := tk.Outcome(); got != "failed: hard timeout" {
+		t.Errorf("unexpected outcome: %q", got)
+	}
+
+	tk.CompletionReason = ""
+	if got := tk.Outcome(); got != "failed" {
+		t.Errorf("task without a reason should show only its status, got %q", got)
+	}
+}
+
+func TestTaskValidate(t *testing.T) {
+	if err := NewTask("task-1", "Work", "").Validate(); err != nil {
+		t.Errorf("expected a new task to be valid, got %v", err)
+	}
+
+	for name, tk := range map[string]*Task{
+		"empty ID":       {ID: " ", Status: StatusPending},
+		"empty status":   {ID: "task-1"},
+		"unknown status": {ID: "task-1", Status: "done"},
+	} {
+		err := tk.Validate()
+		if !errors.Is(err, ErrInvalidTask) {
+			t.Errorf("%s: expected ErrInvalidTask, got %v", name, err)
+		}
+	}
+}
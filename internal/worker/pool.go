@@ -15,25 +15,42 @@ type Pool struct {
 	workers    []*Worker
 	taskChan   chan *task.Task
 	resultChan chan *TaskResult
-	config     *config.Config
+	config     atomic.Pointer[config.Config]
 	logger     *slog.Logger
 	workDir    string
 
+	tracker     *taskTracker
+	heartbeat   func(taskID string) error
+	recordCmd   func(taskID string, cmd []string) error
+	onStart     func(taskID string, workerID int) error
+	onResult    func(result *TaskResult)
+	handler     func(ctx context.Context, t *task.Task) *TaskResult
 	activeCount atomic.Int32
 	wg          sync.WaitGroup
+	ctx         context.Context
+	nextID      int
 	started     bool
+	stopped     bool
 	mu          sync.Mutex
 }
 
 // NewPool creates a new worker pool.
 func NewPool(cfg *config.Config, logger *slog.Logger, workDir string) *Pool {
-	return &Pool{
+	p := &Pool{
 		taskChan:   make(chan *task.Task, cfg.NumWorkers*2), // Buffer for smooth dispatching
 		resultChan: make(chan *TaskResult, cfg.NumWorkers*2),
-		config:     cfg,
 		logger:     logger,
 		workDir:    workDir,
+		tracker:    newTaskTracker(),
 	}
+	p.config.Store(cfg)
+	return p
+}
+
+// SetConfig publishes a reloaded config. Each worker switches to it before
+// its next task; tasks already running keep the config they started with.
+func (p *Pool) SetConfig(cfg *config.Config) {
+	p.config.Store(cfg)
 }
 
 // Start launches all workers in the pool.
@@ -44,31 +61,103 @@ func (p *Pool) Start(ctx context.Context) error {
 		return nil
 	}
 	p.started = true
-	p.mu.Unlock()
+	p.ctx = ctx
+	defer p.mu.Unlock()
 
-	p.logger.Info("starting worker pool", "num_workers", p.config.NumWorkers)
+	numWorkers := p.config.Load().NumWorkers
+	p.logger.Info("starting worker pool", "num_workers", numWorkers)
 
-	// Create and start workers
-	for i := 1; i <= p.config.NumWorkers; i++ {
-		worker := New(i, p.config, p.taskChan, p.resultChan, p.logger, p.workDir)
-		p.workers = append(p.workers, worker)
+	for i := 0; i < numWorkers; i++ {
+		p.spawnLocked()
+	}
 
-		p.wg.Add(1)
-		go func(w *Worker) {
-			defer p.wg.Done()
-			p.activeCount.Add(1)
-			defer p.activeCount.Add(-1)
+	p.logger.Info("worker pool started", "active_workers", numWorkers)
+	return nil
+}
+
+// spawnLocked creates and starts one worker. Callers must hold p.mu.
+func (p *Pool) spawnLocked() {
+	p.nextID++
+	worker := New(p.nextID, p.config.Load(), p.taskChan, p.resultChan, p.logger, p.workDir)
+	worker.loadConfig = p.config.Load
+	worker.tracker = p.tracker
+	worker.heartbeat = p.heartbeat
+	worker.recordCmd = p.recordCmd
+	worker.onStart = p.onStart
+	worker.onResult = p.onResult
+	worker.handler = p.handler
+	worker.quit = make(chan struct{})
+	p.workers = append(p.workers, worker)
 
-			if err := w.Start(ctx); err != nil {
-				if ctx.Err() == nil {
-					p.logger.Error("worker exited with error", "worker_id", w.ID, "error", err)
-				}
+	ctx := p.ctx
+	p.wg.Add(1)
+	go func(w *Worker) {
+		defer p.wg.Done()
+		p.activeCount.Add(1)
+		defer p.activeCount.Add(-1)
+
+		if err := w.Start(ctx); err != nil {
+			if ctx.Err() == nil {
+				p.logger.Error("worker exited with error", "worker_id", w.ID, "error", err)
 			}
-		}(worker)
+		}
+	}(worker)
+}
+
+// Scale changes the number of workers in a running pool. New workers are
+// started immediately; surplus workers finish their current task before
+// exiting. Does nothing if the pool is not running.
+func (p *Pool) Scale(n int) {
+	if n < 1 {
+		n = 1
 	}
 
-	p.logger.Info("worker pool started", "active_workers", p.config.NumWorkers)
-	return nil
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.started || p.stopped {
+		return
+	}
+
+	current := len(p.workers)
+	for i := current; i < n; i++ {
+		p.spawnLocked()
+	}
+	for i := current; i > n; i-- {
+		w := p.workers[i-1]
+		close(w.quit)
+		p.workers = p.workers[:i-1]
+	}
+
+	if n != current {
+		p.logger.Info("worker pool scaled", "from", current, "to", n)
+	}
+}
+
+// SetHeartbeat registers a function that workers call periodically while
+// processing a task. It must be called before Start.
+func (p *Pool) SetHeartbeat(fn func(taskID string) error) {
+	p.heartbeat = fn
+}
+
+// SetCommandRecorder registers a function that workers call with the agent
+// command line before running a task. It must be called before Start.
+func (p *Pool) SetCommandRecorder(fn func(taskID string, cmd []string) error) {
+	p.recordCmd = fn
+}
+
+// SetStartNotifier registers a function that workers call when they begin
+// executing a task. It must be called before Start.
+func (p *Pool) SetStartNotifier(fn func(taskID string, workerID int) error) {
+	p.onStart = fn
+}
+
+// OnResult registers a function called with every task result sent on
+// Results. It runs on its own goroutine with its own copy of the result, so
+// it may be called concurrently but never sees changes made by the Results
+// consumer. Results dropped because Results was blocked are not passed to
+// it. It must be called before Start.
+func (p *Pool) OnResult(fn func(result *TaskResult)) {
+	p.onResult = fn
 }
 
 // Stop gracefully shuts down all workers.
@@ -78,6 +167,7 @@ func (p *Pool) Stop() {
 		p.mu.Unlock()
 		return
 	}
+	p.stopped = true
 	p.mu.Unlock()
 
 	p.logger.Info("stopping worker pool")
@@ -118,11 +208,39 @@ func (p *Pool) SubmitBlocking(ctx context.Context, t *task.Task) error {
 	}
 }
 
+// CancelTask stops the agent running the given task by cancelling its context.
+// If the task is still queued, it is skipped when a worker picks it up.
+// Returns true if the task was running.
+func (p *Pool) CancelTask(taskID string) bool {
+	running := p.tracker.cancel(taskID)
+	p.logger.Info("task cancellation requested", "task_id", taskID, "running", running)
+	return running
+}
+
+// ForgetCancel drops any cancellation recorded for a task. Call it once
+// the task's result has been processed.
+func (p *Pool) ForgetCancel(taskID string) {
+	p.tracker.forget(taskID)
+}
+
 // Results returns the channel for receiving task results.
 func (p *Pool) Results() <-chan *TaskResult {
 	return p.resultChan
 }
 
+// Status returns a snapshot of each worker in the pool, ordered by ID.
+// Workers retired by a scale-down are not included.
+func (p *Pool) Status() []WorkerInfo {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	infos := make([]WorkerInfo, 0, len(p.workers))
+	for _, w := range p.workers {
+		infos = append(infos, w.info())
+	}
+	return infos
+}
+
 // ActiveWorkers returns the number of currently active workers.
 func (p *Pool) ActiveWorkers() int {
 	return int(p.activeCount.Load())
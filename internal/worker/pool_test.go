@@ -2,8 +2,11 @@ package worker
 
 import (
 	"context"
+	"encoding/json"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -133,3 +136,383 @@ func TestPoolIsFull(t *testing.T) {
 		t.Error("expected pool to be full")
 	}
 }
+
+func TestWorkerPhaseLogging(t *testing.T) {
+	cfg := testConfig()
+	cfg.NumWorkers = 1
+	cfg.AgentCommand = []string{"echo", "### TASK_DONE ###"}
+	logger := testLogger()
+
+	tmpDir := t.TempDir()
+	cfg.LogDirectory = tmpDir
+	pool := NewPool(cfg, logger, tmpDir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pool.Start(ctx)
+	defer pool.Stop()
+
+	pool.Submit(task.NewTask("phase-1", "Phase Task", "Do something"))
+
+	select {
+	case result := <-pool.Results():
+		phases := make(map[string]bool)
+		for _, entry := range result.Task.Logs {
+			phases[entry.Phase] = true
+		}
+		for _, want := range []string{task.PhaseDispatch, task.PhaseAgentStart, task.PhaseAwaitingResponse, task.PhaseReview, task.PhaseDone} {
+			if !phases[want] {
+				t.Errorf("expected phase %q in task logs", want)
+			}
+		}
+
+		content, err := os.ReadFile(filepath.Join(tmpDir, "phase-1.log"))
+		if err != nil {
+			t.Fatalf("failed to read task log: %v", err)
+		}
+		if !strings.Contains(string(content), "["+task.PhaseReview+"]") {
+			t.Error("expected review phase in task log file")
+		}
+
+		workerLog, err := os.ReadFile(filepath.Join(tmpDir, "worker-1.log"))
+		if err != nil {
+			t.Fatalf("failed to read worker log: %v", err)
+		}
+		if !strings.Contains(string(workerLog), "task received by worker") {
+			t.Error("expected worker lifecycle in worker log file")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no result received")
+	}
+}
+
+func TestWorkerStructuredTaskLogs(t *testing.T) {
+	cfg := testConfig()
+	cfg.NumWorkers = 1
+	cfg.AgentCommand = []string{"bash", "-c", "echo 'working on it'; echo 'a warning' >&2; echo '### TASK_DONE ###'"}
+	cfg.StructuredTaskLogs = true
+	logger := testLogger()
+
+	tmpDir := t.TempDir()
+	cfg.LogDirectory = tmpDir
+	pool := NewPool(cfg, logger, tmpDir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pool.Start(ctx)
+	defer pool.Stop()
+
+	pool.Submit(task.NewTask("events-1", "Events Task", "Do something"))
+
+	select {
+	case <-pool.Results():
+	case <-time.After(5 * time.Second):
+		t.Fatal("no result received")
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "events-1.events.jsonl"))
+	if err != nil {
+		t.Fatalf("failed to read task event log: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		var event struct {
+			Time   time.Time `json:"time"`
+			Type   string    `json:"type"`
+			Phase  string    `json:"phase"`
+			Line   string    `json:"line"`
+			TaskID string    `json:"task_id"`
+		}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("invalid event line %q: %v", line, err)
+		}
+		if event.TaskID != "events-1" || event.Time.IsZero() {
+			t.Errorf("event missing task ID or time: %q", line)
+		}
+		seen[event.Type+":"+event.Phase+":"+event.Line] = true
+	}
+
+	for _, want := range []string{
+		"phase:" + task.PhaseDispatch + ":",
+		"stdout:" + task.PhaseAwaitingResponse + ":working on it",
+		"stderr:" + task.PhaseAwaitingResponse + ":a warning",
+		"marker:" + task.PhaseAwaitingResponse + ":### TASK_DONE ###",
+		"marker:" + task.PhaseReview + ":### TASK_DONE ###",
+	} {
+		if !seen[want] {
+			t.Errorf("expected event %q, got %v", want, seen)
+		}
+	}
+
+	// The plain log keeps its human-readable format
+	plain, err := os.ReadFile(filepath.Join(tmpDir, "events-1.log"))
+	if err != nil {
+		t.Fatalf("failed to read task log: %v", err)
+	}
+	if !strings.Contains(string(plain), "working on it") {
+		t.Error("expected agent output in the plain task log")
+	}
+}
+
+func TestWorkerRecordsAgentCommand(t *testing.T) {
+	cfg := testConfig()
+	cfg.NumWorkers = 1
+	cfg.AgentCommand = []string{"echo", "### TASK_DONE ###"}
+	logger := testLogger()
+
+	tmpDir := t.TempDir()
+	cfg.LogDirectory = tmpDir
+	pool := NewPool(cfg, logger, tmpDir)
+
+	recorded := make(chan []string, 1)
+	pool.SetCommandRecorder(func(taskID string, cmd []string) error {
+		recorded <- cmd
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pool.Start(ctx)
+	defer pool.Stop()
+
+	pool.Submit(task.NewTask("cmd-1", "Command Task", "Do something"))
+
+	select {
+	case result := <-pool.Results():
+		if strings.Join(result.Task.AgentCmd, " ") != "echo ### TASK_DONE ###" {
+			t.Errorf("unexpected agent command on result: %v", result.Task.AgentCmd)
+		}
+		select {
+		case cmd := <-recorded:
+			if len(cmd) != 2 || cmd[0] != "echo" {
+				t.Errorf("unexpected recorded command: %v", cmd)
+			}
+		default:
+			t.Error("expected the command to be recorded")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no result received")
+	}
+}
+
+func TestPoolOnResult(t *testing.T) {
+	cfg := testConfig()
+	cfg.NumWorkers = 1
+	cfg.AgentCommand = []string{"echo", "### TASK_DONE ###"}
+	logger := testLogger()
+
+	tmpDir := t.TempDir()
+	cfg.LogDirectory = tmpDir
+	pool := NewPool(cfg, logger, tmpDir)
+
+	hooked := make(chan *TaskResult, 1)
+	pool.OnResult(func(result *TaskResult) {
+		hooked <- result
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pool.Start(ctx)
+	defer pool.Stop()
+
+	pool.Submit(task.NewTask("hook-1", "Hook Task", "Do something"))
+
+	var fromChan *TaskResult
+	select {
+	case fromChan = <-pool.Results():
+	case <-time.After(5 * time.Second):
+		t.Fatal("no result received on the channel")
+	}
+
+	// The consumer changing its result must not reach the hook's copy
+	fromChan.Task.Status = task.StatusFailed
+	fromChan.Task.AddLog("info", "test", "changed by consumer", nil)
+
+	select {
+	case result := <-hooked:
+		if result == fromChan || result.Task == fromChan.Task {
+			t.Error("expected the hook to receive its own copy of the result")
+		}
+		if result.Task.ID != "hook-1" || result.Status != fromChan.Status {
+			t.Errorf("expected the hook copy to match the sent result, got %+v", result)
+		}
+		if result.Task.Status == task.StatusFailed || len(result.Task.Logs) == len(fromChan.Task.Logs) {
+			t.Error("consumer changes leaked into the hook's result")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnResult hook was not called")
+	}
+}
+
+func TestPoolRecoversFromPanic(t *testing.T) {
+	cfg := testConfig()
+	cfg.NumWorkers = 1
+	cfg.AgentCommand = []string{"echo", "### TASK_DONE ###"}
+	logger := testLogger()
+
+	tmpDir := t.TempDir()
+	cfg.LogDirectory = tmpDir
+	pool := NewPool(cfg, logger, tmpDir)
+	pool.handler = func(ctx context.Context, tk *task.Task) *TaskResult {
+		if tk.ID == "panic-1" {
+			panic("boom")
+		}
+		return &TaskResult{Task: tk, Status: task.StatusCompleted}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pool.Start(ctx)
+	defer pool.Stop()
+
+	pool.Submit(task.NewTask("panic-1", "Panicking Task", ""))
+	select {
+	case result := <-pool.Results():
+		if result.Task.ID != "panic-1" || result.Status != task.StatusFailed {
+			t.Fatalf("expected a failed result for panic-1, got %s %s", result.Task.ID, result.Status)
+		}
+		if result.Error == nil || !strings.Contains(result.Error.Error(), "boom") {
+			t.Errorf("expected the panic in the error, got %v", result.Error)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no result for the panicking task")
+	}
+
+	// The same worker keeps taking tasks
+	pool.Submit(task.NewTask("after-1", "Next Task", ""))
+	select {
+	case result := <-pool.Results():
+		if result.Task.ID != "after-1" || result.Status != task.StatusCompleted {
+			t.Errorf("expected after-1 to complete, got %s %s", result.Task.ID, result.Status)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("pool stopped processing after a panic")
+	}
+	if n := pool.ActiveWorkers(); n != 1 {
+		t.Errorf("expected 1 active worker, got %d", n)
+	}
+}
+
+func TestPoolStatus(t *testing.T) {
+	cfg := testConfig()
+	cfg.NumWorkers = 2
+	cfg.AgentCommand = []string{"bash", "-c", "sleep 2; echo '### TASK_DONE ###'"}
+	logger := testLogger()
+
+	tmpDir := t.TempDir()
+	cfg.LogDirectory = tmpDir
+	pool := NewPool(cfg, logger, tmpDir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pool.Start(ctx)
+	defer pool.Stop()
+
+	pool.Submit(task.NewTask("status-1", "Status Task", "Do something"))
+
+	// Wait for a worker to pick the task up
+	var busy []WorkerInfo
+	for i := 0; i < 50 && len(busy) == 0; i++ {
+		time.Sleep(50 * time.Millisecond)
+		for _, info := range pool.Status() {
+			if info.Busy() {
+				busy = append(busy, info)
+			}
+		}
+	}
+
+	status := pool.Status()
+	if len(status) != 2 {
+		t.Fatalf("expected 2 workers in status, got %d", len(status))
+	}
+	if len(busy) != 1 || busy[0].TaskID != "status-1" || busy[0].StartedAt.IsZero() {
+		t.Fatalf("expected one busy worker on status-1, got %+v", busy)
+	}
+
+	select {
+	case <-pool.Results():
+	case <-time.After(10 * time.Second):
+		t.Fatal("no result received")
+	}
+	for _, info := range pool.Status() {
+		if info.Busy() {
+			t.Errorf("expected all workers idle after the result, got %+v", info)
+		}
+	}
+}
+
+func TestPoolForgetCancel(t *testing.T) {
+	pool := NewPool(testConfig(), testLogger(), t.TempDir())
+
+	// A task cancelled after its worker finished is only marked
+	if pool.CancelTask("done-1") {
+		t.Fatal("expected a task that is not running to report false")
+	}
+	pool.ForgetCancel("done-1")
+	if len(pool.tracker.cancelled) != 0 {
+		t.Errorf("expected the cancel mark to be dropped, got %v", pool.tracker.cancelled)
+	}
+
+	// A later run of the same task is not skipped
+	if !pool.tracker.start("done-1", func() {}) {
+		t.Error("expected the task to start after its mark was dropped")
+	}
+}
+
+func TestRunTask(t *testing.T) {
+	cfg := testConfig()
+	cfg.AgentCommand = []string{"echo", "### TASK_DONE ###"}
+	tmpDir := t.TempDir()
+	cfg.LogDirectory = tmpDir
+
+	tk := task.NewTask("single-1", "Single Task", "Do something")
+	tk.Role = "backend"
+
+	result, err := RunTask(context.Background(), cfg, testLogger(), tmpDir, tk)
+	if err != nil {
+		t.Fatalf("RunTask() failed: %v", err)
+	}
+	if result.Status != task.StatusCompleted {
+		t.Errorf("expected task to complete, got %s (%v)", result.Status, result.Error)
+	}
+	if result.Task != tk || len(tk.AgentCmd) == 0 {
+		t.Error("expected the result to carry the task with its agent command")
+	}
+}
+
+func TestRunTaskReviewAgentCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	calls := filepath.Join(tmpDir, "calls")
+
+	cfg := testConfig()
+	cfg.LogDirectory = tmpDir
+	cfg.AgentCommand = []string{"sh", "-c", "echo implement >> " + calls + "; echo '### TASK_DONE ###'"}
+	cfg.ReviewAgentCommand = []string{"sh", "-c", "echo review >> " + calls + "; echo '### TASK_DONE ###'"}
+
+	tk := task.NewTask("review-1", "Reviewed Task", "Do something")
+	result, err := RunTask(context.Background(), cfg, testLogger(), tmpDir, tk)
+	if err != nil {
+		t.Fatalf("RunTask() failed: %v", err)
+	}
+	if result.Status != task.StatusCompleted {
+		t.Fatalf("expected task to complete, got %s (%v)", result.Status, result.Error)
+	}
+
+	data, err := os.ReadFile(calls)
+	if err != nil {
+		t.Fatalf("failed to read agent calls: %v", err)
+	}
+	if got := string(data); got != "implement\nreview\n" {
+		t.Errorf("expected the review to run the review command, got calls %q", got)
+	}
+	if strings.Join(tk.AgentCmd, " ") != strings.Join(cfg.AgentCommand, " ") {
+		t.Errorf("expected the recorded command to be the implementation one, got %v", tk.AgentCmd)
+	}
+}
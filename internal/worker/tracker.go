@@ -0,0 +1,68 @@
+package worker
+
+import (
+	"context"
+	"sync"
+)
+
+// taskTracker records the cancel funcs of in-flight tasks so they can be
+// stopped from outside the worker that is running them.
+type taskTracker struct {
+	mu        sync.Mutex
+	running   map[string]context.CancelFunc
+	cancelled map[string]bool
+}
+
+func newTaskTracker() *taskTracker {
+	return &taskTracker{
+		running:   make(map[string]context.CancelFunc),
+		cancelled: make(map[string]bool),
+	}
+}
+
+// start registers a task as running. It returns false if the task was
+// cancelled while it was still queued, in which case it must not run.
+func (tr *taskTracker) start(taskID string, cancel context.CancelFunc) bool {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	if tr.cancelled[taskID] {
+		delete(tr.cancelled, taskID)
+		return false
+	}
+	tr.running[taskID] = cancel
+	return true
+}
+
+// finish removes a task once its worker is done with it.
+func (tr *taskTracker) finish(taskID string) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	delete(tr.running, taskID)
+	delete(tr.cancelled, taskID)
+}
+
+// forget drops the cancel mark of a task whose result has been handled.
+// A task cancelled just after its worker finished is marked but never
+// started again, so without this the mark would stay for good.
+func (tr *taskTracker) forget(taskID string) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	delete(tr.cancelled, taskID)
+}
+
+// cancel stops a running task, or marks a queued one so it is skipped.
+// Returns true if the task was running.
+func (tr *taskTracker) cancel(taskID string) bool {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	if cancel, ok := tr.running[taskID]; ok {
+		cancel()
+		return true
+	}
+	tr.cancelled[taskID] = true
+	return false
+}
@@ -8,11 +8,14 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"runtime/debug"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/tuanbt/hive/internal/agent"
 	"github.com/tuanbt/hive/internal/config"
+	"github.com/tuanbt/hive/internal/logger"
 	"github.com/tuanbt/hive/internal/task"
 )
 
@@ -20,13 +23,29 @@ import (
 // It includes generated code, status, errors, and any new sub-tasks
 // discovered during the planning phase.
 type TaskResult struct {
-	Task     *task.Task
-	Status   task.Status
-	Output   string
-	Error    error
-	WorkerID int
-	Duration time.Duration
-	NewTasks []*task.Task // Sub-tasks generated by the agent
+	Task       *task.Task
+	Status     task.Status
+	Output     string
+	Error      error
+	WorkerID   int
+	Duration   time.Duration
+	NewTasks   []*task.Task           // Sub-tasks generated by the agent
+	Completion agent.CompletionReason // How the last agent run ended
+	ExitCode   int                    // Exit code of the last agent run
+}
+
+// clone returns a copy of the result whose tasks can be changed without
+// affecting r.
+func (r *TaskResult) clone() *TaskResult {
+	c := *r
+	if r.Task != nil {
+		c.Task = r.Task.Copy()
+	}
+	c.NewTasks = make([]*task.Task, len(r.NewTasks))
+	for i, nt := range r.NewTasks {
+		c.NewTasks[i] = nt.Copy()
+	}
+	return &c
 }
 
 // Worker is a single execution thread that manages an autonomous agent.
@@ -37,8 +56,48 @@ type Worker struct {
 	taskChan   <-chan *task.Task
 	resultChan chan<- *TaskResult
 	config     *config.Config
+	loadConfig func() *config.Config // returns the latest reloaded config; nil keeps config
 	logger     *slog.Logger
 	workDir    string
+	tracker    *taskTracker
+	heartbeat  func(taskID string) error
+	recordCmd  func(taskID string, cmd []string) error
+	onStart    func(taskID string, workerID int) error
+	onResult   func(result *TaskResult)
+	events     *slog.Logger                                        // structured event log of the current task
+	handler    func(ctx context.Context, t *task.Task) *TaskResult // nil runs processTask
+	quit       chan struct{}
+
+	mu        sync.Mutex
+	current   string    // ID of the task being processed, "" when idle
+	startedAt time.Time // when the current task started
+}
+
+// WorkerInfo is a snapshot of what a worker is doing.
+type WorkerInfo struct {
+	ID        int
+	TaskID    string    // empty when the worker is idle
+	StartedAt time.Time // when TaskID started
+}
+
+// Busy reports whether the worker is processing a task.
+func (i WorkerInfo) Busy() bool {
+	return i.TaskID != ""
+}
+
+// info returns a snapshot of the worker's state.
+func (w *Worker) info() WorkerInfo {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return WorkerInfo{ID: w.ID, TaskID: w.current, StartedAt: w.startedAt}
+}
+
+// setCurrent records the task the worker is processing; "" marks it idle.
+func (w *Worker) setCurrent(taskID string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.current = taskID
+	w.startedAt = time.Now()
 }
 
 // New initializes a new Worker with its own ID and communication channels.
@@ -55,8 +114,17 @@ func New(id int, cfg *config.Config, taskChan <-chan *task.Task,
 }
 
 // Start begins processing tasks from the task channel.
-// Blocks until context is cancelled or task channel is closed.
+// Blocks until context is cancelled, the task channel is closed, or the
+// pool retires the worker.
 func (w *Worker) Start(ctx context.Context) error {
+	// Mirror worker logs into a dedicated worker-<id>.log file
+	if fileLogger, cleanup, err := logger.NewWorkerLogger(w.config, w.ID); err != nil {
+		w.logger.Warn("failed to create worker log file", "error", err)
+	} else {
+		defer cleanup()
+		w.logger = logger.Tee(w.logger, fileLogger)
+	}
+
 	w.logger.Info("worker starting")
 
 	// Create agent driver
@@ -65,9 +133,6 @@ func (w *Worker) Start(ctx context.Context) error {
 		return fmt.Errorf("failed to start agent: %w", err)
 	}
 
-	// Wait for agent to initialize
-	time.Sleep(500 * time.Millisecond)
-
 	w.logger.Info("worker ready, waiting for tasks")
 
 	// Process tasks
@@ -78,6 +143,11 @@ func (w *Worker) Start(ctx context.Context) error {
 			w.agent.Stop()
 			return ctx.Err()
 
+		case <-w.quit:
+			w.logger.Info("worker retired by pool scale-down")
+			w.agent.Stop()
+			return nil
+
 		case t, ok := <-w.taskChan:
 			if !ok {
 				w.logger.Info("task channel closed, worker stopping")
@@ -85,16 +155,72 @@ func (w *Worker) Start(ctx context.Context) error {
 				return nil
 			}
 
-			result := w.processTask(ctx, t)
+			w.refreshConfig()
+			result := w.runTask(ctx, t)
+
+			// The consumer may change the result as soon as it is sent,
+			// so the hook gets its own copy
+			var hooked *TaskResult
+			if w.onResult != nil {
+				hooked = result.clone()
+			}
 
 			// Send result (non-blocking with timeout)
 			select {
 			case w.resultChan <- result:
+				// Run the hook on its own goroutine so a slow embedder
+				// never holds up the next task
+				if hooked != nil {
+					go w.onResult(hooked)
+				}
 			case <-time.After(5 * time.Second):
-				w.logger.Error("failed to send result, channel blocked")
+				w.logger.Error("failed to send result, channel blocked", "task_id", t.ID)
+			}
+		}
+	}
+}
+
+// refreshConfig switches the worker and its agent to the latest reloaded
+// config. It runs between tasks, so a task sees one config throughout.
+func (w *Worker) refreshConfig() {
+	if w.loadConfig == nil {
+		return
+	}
+	if cfg := w.loadConfig(); cfg != w.config {
+		w.config = cfg
+		w.agent.SetConfig(cfg)
+	}
+}
+
+// runTask processes a task and turns a panic into a failed result, so the
+// task is not left in progress and the worker keeps serving the pool. The
+// agent is restarted since its state is unknown after a panic.
+func (w *Worker) runTask(ctx context.Context, t *task.Task) (result *TaskResult) {
+	startTime := time.Now()
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		w.logger.Error("worker panicked while processing task", "task_id", t.ID, "panic", r, "stack", string(debug.Stack()))
+		result = &TaskResult{
+			Task:     t,
+			Status:   task.StatusFailed,
+			Error:    fmt.Errorf("worker panicked: %v", r),
+			WorkerID: w.ID,
+			Duration: time.Since(startTime),
+		}
+		if w.agent != nil {
+			if err := w.agent.Restart(); err != nil {
+				w.logger.Error("failed to restart agent after panic", "error", err)
 			}
 		}
+	}()
+
+	if w.handler != nil {
+		return w.handler(ctx, t)
 	}
+	return w.processTask(ctx, t)
 }
 
 // processTask handles a single task through all phases.
@@ -106,6 +232,35 @@ func (w *Worker) processTask(ctx context.Context, t *task.Task) *TaskResult {
 	taskCtx, cancel := context.WithTimeout(ctx, time.Duration(w.config.MaxTaskDurationSeconds)*time.Second)
 	defer cancel()
 
+	// Register the cancel func so the task can be stopped externally
+	if w.tracker != nil {
+		if !w.tracker.start(t.ID, cancel) {
+			w.logger.Info("skipping task cancelled before start", "task_id", t.ID)
+			return &TaskResult{
+				Task:     t,
+				Status:   task.StatusFailed,
+				Error:    fmt.Errorf("task cancelled before start"),
+				WorkerID: w.ID,
+				Duration: time.Since(startTime),
+			}
+		}
+		defer w.tracker.finish(t.ID)
+	}
+
+	w.setCurrent(t.ID)
+	defer w.setCurrent("")
+
+	// The task was only queued until now
+	t.MarkInProgress(w.ID)
+	if w.onStart != nil {
+		if err := w.onStart(t.ID, w.ID); err != nil {
+			w.logger.Warn("failed to mark task in progress", "task_id", t.ID, "error", err)
+		}
+	}
+
+	stopHeartbeat := w.startHeartbeat(taskCtx, t.ID)
+	defer stopHeartbeat()
+
 	// Open task log file
 	logPath := filepath.Join(w.config.LogDirectory, fmt.Sprintf("%s.log", t.ID))
 	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
@@ -115,7 +270,26 @@ func (w *Worker) processTask(ctx context.Context, t *task.Task) *TaskResult {
 		defer logFile.Close()
 	}
 
+	if w.config.StructuredTaskLogs {
+		events, cleanup, err := logger.NewTaskEventLogger(w.config, t.ID)
+		if err != nil {
+			w.logger.Error("failed to open task event log", "task_id", t.ID, "error", err)
+		} else {
+			w.events = events
+			defer func() {
+				w.events = nil
+				if w.agent != nil {
+					w.agent.SetEventLogger(nil)
+				}
+				cleanup()
+			}()
+		}
+	}
+
+	w.logPhase(t, logFile, task.PhaseDispatch, "task received by worker")
+
 	// Ensure agent is alive
+	w.logPhase(t, logFile, task.PhaseAgentStart, "ensuring agent is available")
 	if err := w.agent.EnsureAlive(); err != nil {
 		return &TaskResult{
 			Task:     t,
@@ -126,6 +300,19 @@ func (w *Worker) processTask(ctx context.Context, t *task.Task) *TaskResult {
 		}
 	}
 
+	w.agent.SetRole(t.Role)
+	w.agent.SetTaskID(t.ID)
+	w.agent.SetReviewing(false)
+
+	// Record the exact command for reproducibility
+	t.AgentCmd = w.agent.Command()
+	w.logPhase(t, logFile, task.PhaseAgentStart, fmt.Sprintf("agent command: %s", strings.Join(t.AgentCmd, " ")))
+	if w.recordCmd != nil {
+		if err := w.recordCmd(t.ID, t.AgentCmd); err != nil {
+			w.logger.Warn("failed to record agent command", "task_id", t.ID, "error", err)
+		}
+	}
+
 	// Phase 1: Load context files
 	if len(t.ContextFiles) > 0 {
 		w.logger.Debug("loading context files", "count", len(t.ContextFiles))
@@ -141,24 +328,16 @@ func (w *Worker) processTask(ctx context.Context, t *task.Task) *TaskResult {
 	// Phase 2: Implementation
 	w.logger.Debug("sending implementation prompt")
 
-	// Construct instructions
-	var instructions strings.Builder
-	instructions.WriteString("=== SYSTEM INSTRUCTIONS ===\n")
-	for _, rule := range w.config.Instructions.GlobalRules {
-		instructions.WriteString("- " + rule + "\n")
-	}
-	if t.Role != "" {
-		if roleRule, ok := w.config.Instructions.RoleInstructions[t.Role]; ok {
-			instructions.WriteString("\n=== ROLE: " + strings.ToUpper(t.Role) + " ===\n")
-			instructions.WriteString(roleRule + "\n")
+	implPrompt, err := w.buildImplementationPrompt(t)
+	if err != nil {
+		return &TaskResult{
+			Task:     t,
+			Status:   task.StatusFailed,
+			Error:    fmt.Errorf("failed to build implementation prompt: %w", err),
+			WorkerID: w.ID,
+			Duration: time.Since(startTime),
 		}
 	}
-	instructions.WriteString("\n=== TASK ===\n")
-
-	implPrompt := fmt.Sprintf(`%sTask: %s
-Description: %s
-Please implement this now. When you are finished, output '%s'.`,
-		instructions.String(), t.Title, t.Description, w.config.CompletionMarker)
 
 	if err := w.agent.SendInput(implPrompt); err != nil {
 		return &TaskResult{
@@ -170,32 +349,69 @@ Please implement this now. When you are finished, output '%s'.`,
 		}
 	}
 
-	implOutput, implMarkerFound, err := w.agent.WaitForResponse(taskCtx, logFile)
+	w.logPhase(t, logFile, task.PhaseAwaitingResponse, "waiting for implementation response")
+	w.setEventPhase(task.PhaseAwaitingResponse)
+	implOutput, implReason, err := w.agent.WaitForResponse(taskCtx, logFile)
 	if err != nil {
 		return &TaskResult{
-			Task:     t,
-			Status:   task.StatusFailed,
-			Output:   implOutput,
-			Error:    fmt.Errorf("implementation phase failed: %w", err),
-			WorkerID: w.ID,
-			Duration: time.Since(startTime),
+			Task:       t,
+			Status:     task.StatusFailed,
+			Output:     implOutput,
+			Error:      fmt.Errorf("implementation phase failed: %w", err),
+			WorkerID:   w.ID,
+			Duration:   time.Since(startTime),
+			Completion: implReason,
+			ExitCode:   w.agent.LastExitCode(),
 		}
 	}
 
-	if !implMarkerFound {
-		w.logger.Warn("implementation phase completed without marker (silence timeout)")
+	if implReason == agent.CompletionNoOutput {
+		return &TaskResult{
+			Task:       t,
+			Status:     task.StatusFailed,
+			Error:      fmt.Errorf("agent produced no output"),
+			WorkerID:   w.ID,
+			Duration:   time.Since(startTime),
+			Completion: implReason,
+			ExitCode:   w.agent.LastExitCode(),
+		}
+	}
+
+	if implReason == agent.CompletionBlocked {
+		w.logger.Info("agent reported the task is blocked", "task_id", t.ID)
+		return &TaskResult{
+			Task:       t,
+			Status:     task.StatusBlocked,
+			Output:     implOutput,
+			Error:      fmt.Errorf("agent reported the task is blocked"),
+			WorkerID:   w.ID,
+			Duration:   time.Since(startTime),
+			Completion: implReason,
+			ExitCode:   w.agent.LastExitCode(),
+		}
+	}
+
+	if !implReason.HasMarker() {
+		w.logger.Warn("implementation phase completed without marker", "reason", implReason)
 	}
 
 	// Phase 3: Review with retries
-	w.logger.Debug("starting review phase")
+	w.logPhase(t, logFile, task.PhaseReview, "starting review phase")
+	w.setEventPhase(task.PhaseReview)
 	reviewPrompt := fmt.Sprintf(`Review the implementation:
 1. Run any tests if possible
 2. Fix any syntax errors
 3. If everything is correct, say '%s'`,
 		w.config.CompletionMarker)
 
+	if len(w.config.ReviewAgentCommand) > 0 {
+		w.agent.SetReviewing(true)
+		w.logPhase(t, logFile, task.PhaseReview, fmt.Sprintf("review agent command: %s", strings.Join(w.agent.Command(), " ")))
+	}
+
 	var reviewOutput string
 	reviewSuccess := false
+	completion := implReason
 
 	for attempt := 1; attempt <= w.config.MaxReviewCycles; attempt++ {
 		w.logger.Debug("review attempt", "attempt", attempt, "max", w.config.MaxReviewCycles)
@@ -205,28 +421,45 @@ Please implement this now. When you are finished, output '%s'.`,
 			continue
 		}
 
-		output, markerFound, err := w.agent.WaitForResponse(taskCtx, logFile)
+		output, reason, err := w.agent.WaitForResponse(taskCtx, logFile)
 		reviewOutput = output
+		completion = reason
 
 		if err != nil {
 			if taskCtx.Err() != nil {
 				// Context cancelled/timeout
 				return &TaskResult{
-					Task:     t,
-					Status:   task.StatusFailed,
-					Output:   implOutput + "\n---\n" + reviewOutput,
-					Error:    fmt.Errorf("task timeout during review: %w", err),
-					WorkerID: w.ID,
-					Duration: time.Since(startTime),
+					Task:       t,
+					Status:     task.StatusFailed,
+					Output:     implOutput + "\n---\n" + reviewOutput,
+					Error:      fmt.Errorf("task timeout during review: %w", err),
+					WorkerID:   w.ID,
+					Duration:   time.Since(startTime),
+					Completion: completion,
+					ExitCode:   w.agent.LastExitCode(),
 				}
 			}
 			w.logger.Warn("review attempt failed", "attempt", attempt, "error", err)
 			continue
 		}
 
-		if markerFound {
+		if reason == agent.CompletionBlocked {
+			w.logger.Info("agent reported the task is blocked during review", "task_id", t.ID, "attempt", attempt)
+			return &TaskResult{
+				Task:       t,
+				Status:     task.StatusBlocked,
+				Output:     implOutput + "\n---\n" + reviewOutput,
+				Error:      fmt.Errorf("agent reported the task is blocked"),
+				WorkerID:   w.ID,
+				Duration:   time.Since(startTime),
+				Completion: completion,
+				ExitCode:   w.agent.LastExitCode(),
+			}
+		}
+
+		if reason.IsSuccess() {
 			reviewSuccess = true
-			w.logger.Info("review completed successfully", "attempt", attempt)
+			w.logger.Info("review completed successfully", "attempt", attempt, "reason", reason)
 			break
 		}
 
@@ -241,6 +474,8 @@ Please implement this now. When you are finished, output '%s'.`,
 		w.logger.Warn("review attempt did not find completion marker", "attempt", attempt)
 	}
 
+	w.agent.SetReviewing(false)
+
 	// Determine final status
 	finalStatus := task.StatusFailed
 	var finalError error
@@ -250,6 +485,7 @@ Please implement this now. When you are finished, output '%s'.`,
 		w.agent.ResetRestartCount() // Reset on success
 	} else {
 		finalError = fmt.Errorf("review failed after %d attempts", w.config.MaxReviewCycles)
+		completion = agent.CompletionReviewRejected
 	}
 
 	// Clear context for next task
@@ -283,25 +519,120 @@ Please implement this now. When you are finished, output '%s'.`,
 			} else {
 				w.logger.Info("extracted new tasks from plan", "count", len(rawTasks))
 				for _, rt := range rawTasks {
-					// Generate unique ID for subtask
-					subID := fmt.Sprintf("task-%d", time.Now().UnixNano())
-					nt := task.NewTask(subID, rt.Title, rt.Description)
+					// The registry assigns the ID when the plan is added
+					nt := task.NewTask("", rt.Title, rt.Description)
 					nt.Role = rt.Role
+					nt.ApplyRolePriority(w.config.RolePriorities)
 					newTasks = append(newTasks, nt)
-					// Small sleep to ensure unique timestamps if multiple tasks
-					time.Sleep(time.Millisecond)
 				}
 			}
 		}
 	}
 
+	w.logPhase(t, logFile, task.PhaseDone, fmt.Sprintf("worker finished with status %s", finalStatus))
+
 	return &TaskResult{
-		Task:     t,
-		Status:   finalStatus,
-		Output:   fullOutput,
-		Error:    finalError,
-		WorkerID: w.ID,
-		Duration: time.Since(startTime),
-		NewTasks: newTasks,
+		Task:       t,
+		Status:     finalStatus,
+		Output:     fullOutput,
+		Error:      finalError,
+		WorkerID:   w.ID,
+		Duration:   time.Since(startTime),
+		NewTasks:   newTasks,
+		Completion: completion,
+		ExitCode:   w.agent.LastExitCode(),
+	}
+}
+
+// RunTask runs a single task outside a pool, through the same phases and
+// prompts a pool worker uses, and returns its result. The task registry is
+// not touched; persisting the result is up to the caller.
+func RunTask(ctx context.Context, cfg *config.Config, logger *slog.Logger, workDir string, t *task.Task) (*TaskResult, error) {
+	w := New(0, cfg, nil, nil, logger, workDir)
+	w.agent = agent.New(cfg, w.logger, workDir)
+	if err := w.agent.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start agent: %w", err)
+	}
+	defer w.agent.Stop()
+
+	return w.processTask(ctx, t), nil
+}
+
+// buildImplementationPrompt renders the configured prompt template over the
+// task, its global and role instructions, and the retry context.
+func (w *Worker) buildImplementationPrompt(t *task.Task) (string, error) {
+	tmpl, err := config.ParsePromptTemplate(w.config.PromptTemplate)
+	if err != nil {
+		return "", err
+	}
+
+	var prompt strings.Builder
+	if err := tmpl.Execute(&prompt, w.config.NewPromptData(t)); err != nil {
+		return "", fmt.Errorf("failed to render prompt: %w", err)
+	}
+	if w.config.AppendCompletionInstruction {
+		prompt.WriteString("\n\n" + w.config.CompletionInstruction())
+	}
+	return prompt.String(), nil
+}
+
+// startHeartbeat periodically reports that the task is still being worked on.
+// The returned func stops the heartbeat and waits for it to exit.
+func (w *Worker) startHeartbeat(ctx context.Context, taskID string) func() {
+	if w.heartbeat == nil || w.config.StuckTaskThresholdSeconds <= 0 {
+		return func() {}
+	}
+
+	// Beat several times per threshold so a live task is never considered stuck
+	interval := time.Duration(w.config.StuckTaskThresholdSeconds) * time.Second / 3
+	if interval < time.Second {
+		interval = time.Second
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := w.heartbeat(taskID); err != nil {
+				w.logger.Warn("heartbeat failed", "task_id", taskID, "error", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// logPhase records a lifecycle phase in the system log, the task log file,
+// and the task's own log entries.
+func (w *Worker) logPhase(t *task.Task, logFile *os.File, phase, message string) {
+	w.logger.Info(message, "task_id", t.ID, "phase", phase)
+	if logFile != nil {
+		fmt.Fprintf(logFile, "[%s] [%s] %s\n", time.Now().Format(time.RFC3339), phase, message)
+	}
+	t.AddLog("info", phase, message, nil)
+	if w.events != nil {
+		w.events.Info(message, "type", "phase", "phase", phase)
+	}
+}
+
+// setEventPhase tags the agent output recorded in the task event log with
+// the current phase.
+func (w *Worker) setEventPhase(phase string) {
+	if w.events != nil {
+		w.agent.SetEventLogger(w.events.With("phase", phase))
 	}
 }
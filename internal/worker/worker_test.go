@@ -0,0 +1,101 @@
+package worker
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tuanbt/hive/internal/task"
+)
+
+func TestBuildImplementationPromptFirstAttempt(t *testing.T) {
+	w := New(1, testConfig(), nil, nil, testLogger(), t.TempDir())
+
+	prompt, err := w.buildImplementationPrompt(task.NewTask("t-1", "Title", "Desc"))
+	if err != nil {
+		t.Fatalf("buildImplementationPrompt() failed: %v", err)
+	}
+
+	if strings.Contains(prompt, "=== RETRY ===") {
+		t.Error("first attempt should not include retry context")
+	}
+	if !strings.Contains(prompt, "Task: Title") {
+		t.Error("expected task title in prompt")
+	}
+}
+
+func TestBuildImplementationPromptRetry(t *testing.T) {
+	w := New(1, testConfig(), nil, nil, testLogger(), t.TempDir())
+
+	tk := task.NewTask("t-1", "Title", "Desc")
+	tk.FailReason = "tests did not compile"
+	tk.IncrementRetry()
+	tk.ResetForRetry()
+
+	prompt, err := w.buildImplementationPrompt(tk)
+	if err != nil {
+		t.Fatalf("buildImplementationPrompt() failed: %v", err)
+	}
+
+	if !strings.Contains(prompt, "This is retry attempt 1; the prior attempt failed because: tests did not compile") {
+		t.Errorf("expected retry context in prompt, got:\n%s", prompt)
+	}
+}
+
+func TestBuildImplementationPromptCustomTemplate(t *testing.T) {
+	cfg := testConfig()
+	cfg.PromptTemplate = "[{{upper .Task.Role}}] {{.Task.Title}}\n{{.RoleInstruction}}\n{{.Task.Description}}"
+	cfg.Instructions.RoleInstructions = map[string]string{"qa": "Test everything."}
+	w := New(1, cfg, nil, nil, testLogger(), t.TempDir())
+
+	tk := task.NewTask("t-1", "Title", "Desc")
+	tk.Role = "qa"
+
+	prompt, err := w.buildImplementationPrompt(tk)
+	if err != nil {
+		t.Fatalf("buildImplementationPrompt() failed: %v", err)
+	}
+	if prompt != "[QA] Title\nTest everything.\nDesc" {
+		t.Errorf("unexpected prompt:\n%s", prompt)
+	}
+}
+
+func TestBuildImplementationPromptCompletionInstruction(t *testing.T) {
+	for _, appendInstruction := range []bool{false, true} {
+		cfg := testConfig()
+		cfg.PromptTemplate = "{{.Task.Title}}"
+		cfg.CompletionMarker = "<<ALL DONE>>"
+		cfg.AppendCompletionInstruction = appendInstruction
+		w := New(1, cfg, nil, nil, testLogger(), t.TempDir())
+
+		prompt, err := w.buildImplementationPrompt(task.NewTask("t-1", "Title", "Desc"))
+		if err != nil {
+			t.Fatalf("buildImplementationPrompt() failed: %v", err)
+		}
+		if got := strings.Contains(prompt, "<<ALL DONE>>"); got != appendInstruction {
+			t.Errorf("append=%v: unexpected prompt:\n%s", appendInstruction, prompt)
+		}
+		if appendInstruction && !strings.HasSuffix(prompt, cfg.CompletionInstruction()) {
+			t.Errorf("expected the instruction at the end of the prompt, got:\n%s", prompt)
+		}
+	}
+}
+
+func TestBuildImplementationPromptExtraInstructions(t *testing.T) {
+	w := New(1, testConfig(), nil, nil, testLogger(), t.TempDir())
+
+	tk := task.NewTask("t-1", "Title", "Desc")
+	tk.IncrementRetry()
+	tk.ResetForRetry()
+	tk.ExtraInstructions = "also handle nulls"
+
+	prompt, err := w.buildImplementationPrompt(tk)
+	if err != nil {
+		t.Fatalf("buildImplementationPrompt() failed: %v", err)
+	}
+	if !strings.Contains(prompt, "=== ADDITIONAL INSTRUCTIONS ===\nalso handle nulls\n") {
+		t.Errorf("expected extra instructions in prompt, got:\n%s", prompt)
+	}
+	if strings.Index(prompt, "also handle nulls") > strings.Index(prompt, "=== TASK ===") {
+		t.Error("expected extra instructions before the task section")
+	}
+}